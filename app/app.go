@@ -3,28 +3,43 @@ package app
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strconv"
-	"strings"
 
 	"HyPrism/internal/config"
+	"HyPrism/internal/crash"
 	"HyPrism/internal/env"
 	"HyPrism/internal/game"
+	"HyPrism/internal/logging"
 	"HyPrism/internal/mods"
 	"HyPrism/internal/news"
 	"HyPrism/internal/pwr"
+	"HyPrism/internal/store"
+	"HyPrism/internal/tasks"
+	"HyPrism/internal/util/download"
 
 	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
+// logger writes the launcher's own records to the launcher log
+var logger = logging.For(logging.Launcher)
+
+// launcherLogBackups is how many rotated launcher logs are kept next to launcher.log
+const launcherLogBackups = 3
+
+// maxLogLines is the most records GetLogs returns
+const maxLogLines = 5000
+
 // App struct
 type App struct {
 	ctx         context.Context
 	cfg         *config.Config
 	newsService *news.NewsService
+	tasks       *tasks.Queue
 }
 
 // ProgressUpdate represents download/install progress
@@ -61,33 +76,91 @@ func InitServer(appInstance *App) {
 // Startup is called when the app starts
 func (a *App) Startup(ctx context.Context) {
 	a.ctx = ctx
+	a.setupLogging()
+	a.tasks = a.newTaskQueue(ctx)
+	game.SetSupervisor(game.NewSupervisor(func(event game.ProcessEvent) {
+		wailsRuntime.EventsEmit(a.ctx, "game:"+event.Type, event.Process)
+	}))
 
-	fmt.Println("╔══════════════════════════════════════════════════════════════╗")
-	fmt.Println("║           HyPrism - Hytale Launcher Starting...             ║")
-	fmt.Printf("║           Version: %-43s║\n", AppVersion)
-	fmt.Println("╚══════════════════════════════════════════════════════════════╝")
+	logger.Info("HyPrism starting", "version", AppVersion, "os", runtime.GOOS, "arch", runtime.GOARCH)
 
 	// Set custom instance directory if configured
 	if a.cfg.CustomInstanceDir != "" {
 		env.SetCustomInstanceDir(a.cfg.CustomInstanceDir)
-		fmt.Printf("Using custom instances directory: %s\n", a.cfg.CustomInstanceDir)
+		logger.Info("Using custom instances directory", "dir", a.cfg.CustomInstanceDir)
 	}
 
 	// Initialize environment
 	if err := env.CreateFolders(); err != nil {
-		fmt.Printf("Warning: Failed to create folders: %v\n", err)
+		logger.Warn("Failed to create folders", "error", err)
 	}
 
+	// Finish or undo a change of the instances directory that was interrupted
+	env.RecoverRelocation()
+
+	// Give instances from older launcher versions an instance.json
+	env.MigrateInstances(a.cfg.VersionType)
+
+	// Roll back or finish installs that were interrupted last time
+	game.SetKeptGenerations(a.cfg.KeptGenerations)
+	game.RecoverInterruptedInstalls()
+
+	download.SetMaxConnections(a.cfg.DownloadConnections)
+	download.SetBandwidthLimit(a.cfg.BandwidthLimitKB * 1024)
+	pwr.SetMirrors(a.cfg.PatchMirrors)
+	pwr.SetCacheBudget(a.cfg.PatchCacheLimitMB * 1024 * 1024)
+	game.SetSessionLogLimits(a.cfg.SessionLogLimitMB*1024*1024, a.cfg.SessionLogRetention)
+	game.SetSessionLineHandler(func(line game.SessionLine) {
+		wailsRuntime.EventsEmit(a.ctx, "game:log", line)
+	})
+	crash.LauncherVersion = AppVersion
+	game.SetCrashHandler(func(report *crash.Report) {
+		wailsRuntime.EventsEmit(a.ctx, "game:crash", report)
+	})
+
+	// Drop stored game files left behind by instances removed outside the launcher
+	go store.CollectGarbage()
+
+	// Finish instance moves that were interrupted last time
+	go a.resumeInstanceMoves()
+
 	// Check for launcher updates in background
-	go func() {
-		fmt.Println("Starting background update check...")
-		a.checkUpdateSilently()
-	}()
+	go a.checkUpdateSilently()
 }
 
 // Shutdown is called when the app closes
 func (a *App) Shutdown(ctx context.Context) {
-	fmt.Println("HyPrism shutting down...")
+	logger.Info("HyPrism shutting down")
+	logging.Close()
+}
+
+// launcherLogPath returns the file the launcher log is written to
+func launcherLogPath() string {
+	return filepath.Join(env.GetLogsDir(), "launcher.log")
+}
+
+// setupLogging starts writing the launcher log with the configured format and levels
+func (a *App) setupLogging() {
+	opts := logging.Options{
+		Path:       launcherLogPath(),
+		Format:     a.cfg.LogFormat,
+		MaxBytes:   a.cfg.LogLimitMB * 1024 * 1024,
+		MaxBackups: launcherLogBackups,
+		Level:      a.cfg.LogLevel,
+		Levels:     a.cfg.LogLevels,
+	}
+	err := logging.Setup(opts)
+	if err != nil && (opts.Level != "" || len(opts.Levels) > 0) {
+		// A bad level in a hand-edited config should not cost the whole log
+		opts.Level, opts.Levels = "", nil
+		if retryErr := logging.Setup(opts); retryErr == nil {
+			logger.Warn("Ignoring configured log levels", "error", err)
+			return
+		}
+	}
+	if err != nil {
+		logger.Warn("Failed to open launcher log, logging to the console only", "error", err)
+	}
 }
 
 // SelectInstanceDirectory opens a folder picker dialog and saves the selected directory
@@ -116,14 +189,35 @@ func (a *App) SelectInstanceDirectory() (string, error) {
 	}
 	os.Remove(testFile)
 
-	// Save to config
-	a.cfg.CustomInstanceDir = selectedDir
-	env.SetCustomInstanceDir(selectedDir)
-	if err := config.Save(a.cfg); err != nil {
-		return "", fmt.Errorf("failed to save config: %w", err)
+	// Offer to bring existing instances along
+	mode := env.RelocateNone
+	if plan, err := env.PlanRelocation(selectedDir, env.RelocateMove); err == nil && len(plan.Instances) > 0 {
+		answer, err := wailsRuntime.MessageDialog(a.ctx, wailsRuntime.MessageDialogOptions{
+			Type:  wailsRuntime.QuestionDialog,
+			Title: "Move Instances",
+			Message: fmt.Sprintf("Move your %d instances (%.1f GB) to the new directory?\n\nYes moves them. No copies them and keeps the originals where they are.",
+				len(plan.Instances), float64(plan.Bytes)/(1024*1024*1024)),
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to open dialog: %w", err)
+		}
+		switch answer {
+		case "Yes":
+			mode = env.RelocateMove
+		case "No":
+			mode = env.RelocateCopy
+		default:
+			// Dialog closed without an answer
+			return "", nil
+		}
+	}
+
+	// Move the instances and save to config
+	if err := a.relocateInstances(selectedDir, mode); err != nil {
+		return "", err
 	}
 
-	fmt.Printf("Instance directory updated to: %s\n", selectedDir)
+	logger.Info("Instance directory updated", "dir", selectedDir)
 	return selectedDir, nil
 }
 
@@ -187,7 +281,16 @@ func (a *App) DownloadAndLaunch(playerName string, fakeServer bool) error {
 	version := a.GetSelectedVersion()
 
 	// Ensure game is installed for the configured version type and version
-	if err := game.EnsureInstalledVersionSpecific(a.ctx, versionType, version, a.progressCallback); err != nil {
+	err := a.runTask(tasks.Options{
+		Kind:     "install",
+		Title:    fmt.Sprintf("Install %s %s", versionType, versionLabel(version)),
+		Priority: tasks.PriorityHigh,
+		Key:      fmt.Sprintf("install:%s:%d", versionType, version),
+		Group:    installGroup,
+	}, func(ctx context.Context, progress tasks.ProgressFunc) error {
+		return game.EnsureInstalledVersionSpecific(ctx, versionType, version, progress)
+	})
+	if err != nil {
 		wrappedErr := GameError("Failed to install or update game", err)
 		a.emitError(wrappedErr)
 		return wrappedErr
@@ -205,14 +308,22 @@ func (a *App) DownloadAndLaunch(playerName string, fakeServer bool) error {
 	return nil
 }
 
-// GetLogs returns launcher logs
-func (a *App) GetLogs() (string, error) {
-	logPath := filepath.Join(env.GetDefaultAppDir(), "logs", "launcher.log")
-	data, err := os.ReadFile(logPath)
-	if err != nil {
-		return "", err
+// GetLogs returns the newest launcher log records at or above level, of one subsystem.
+// An empty level or subsystem does not filter.
+func (a *App) GetLogs(level string, subsystem string) (string, error) {
+	filter := logging.Filter{
+		MinLevel:  slog.LevelDebug,
+		Subsystem: subsystem,
+		MaxLines:  maxLogLines,
 	}
-	return string(data), nil
+	if level != "" {
+		minLevel, err := logging.ParseLevel(level)
+		if err != nil {
+			return "", ValidationError(err.Error())
+		}
+		filter.MinLevel = minLevel
+	}
+	return logging.Read(launcherLogPath(), launcherLogBackups, filter)
 }
 
 // ==================== MOD MANAGER ====================
@@ -256,11 +367,8 @@ func (a *App) InstallMod(modID int) error {
 		return err
 	}
 
-	return mods.DownloadMod(a.ctx, *cfMod, func(progress float64, message string) {
-		wailsRuntime.EventsEmit(a.ctx, "mod-progress", map[string]interface{}{
-			"progress": progress,
-			"message":  message,
-		})
+	return a.runModTask(cfMod.Name, fmt.Sprintf("mod:%d", modID), func(ctx context.Context, progress func(float64, string)) error {
+		return mods.DownloadMod(ctx, *cfMod, progress)
 	})
 }
 
@@ -271,30 +379,39 @@ func (a *App) InstallModToInstance(modID int, branch string, version int) error
 		return err
 	}
 
-	return mods.DownloadModToInstance(a.ctx, *cfMod, branch, version, func(progress float64, message string) {
-		wailsRuntime.EventsEmit(a.ctx, "mod-progress", map[string]interface{}{
-			"progress": progress,
-			"message":  message,
-		})
+	return a.runModTask(cfMod.Name, fmt.Sprintf("mod:%d:%s:%d", modID, branch, version), func(ctx context.Context, progress func(float64, string)) error {
+		return mods.DownloadModToInstance(ctx, *cfMod, branch, version, progress)
 	})
 }
 
 // InstallModFile downloads and installs a specific mod file version from CurseForge (legacy)
 func (a *App) InstallModFile(modID int, fileID int) error {
-	return mods.DownloadModFile(a.ctx, modID, fileID, func(progress float64, message string) {
-		wailsRuntime.EventsEmit(a.ctx, "mod-progress", map[string]interface{}{
-			"progress": progress,
-			"message":  message,
-		})
+	return a.runModTask(fmt.Sprintf("mod %d", modID), fmt.Sprintf("mod:%d", modID), func(ctx context.Context, progress func(float64, string)) error {
+		return mods.DownloadModFile(ctx, modID, fileID, progress)
 	})
 }
 
 // InstallModFileToInstance downloads and installs a specific mod file version to an instance
 func (a *App) InstallModFileToInstance(modID int, fileID int, branch string, version int) error {
-	return mods.DownloadModFileToInstance(a.ctx, modID, fileID, branch, version, func(progress float64, message string) {
-		wailsRuntime.EventsEmit(a.ctx, "mod-progress", map[string]interface{}{
-			"progress": progress,
-			"message":  message,
+	return a.runModTask(fmt.Sprintf("mod %d", modID), fmt.Sprintf("mod:%d:%s:%d", modID, branch, version), func(ctx context.Context, progress func(float64, string)) error {
+		return mods.DownloadModFileToInstance(ctx, modID, fileID, branch, version, progress)
+	})
+}
+
+// runModTask queues a mod download; progress goes to the task and to "mod-progress"
+func (a *App) runModTask(name string, key string, run func(ctx context.Context, progress func(float64, string)) error) error {
+	return a.tasks.Run(a.ctx, tasks.Options{
+		Kind:     "mod",
+		Title:    fmt.Sprintf("Download %s", name),
+		Priority: tasks.PriorityNormal,
+		Key:      key,
+	}, func(ctx context.Context, progress tasks.ProgressFunc) error {
+		return run(ctx, func(value float64, message string) {
+			progress("mod", value, message, "", "", 0, 0)
+			wailsRuntime.EventsEmit(a.ctx, "mod-progress", map[string]interface{}{
+				"progress": value,
+				"message":  message,
+			})
 		})
 	})
 }
@@ -398,21 +515,58 @@ func (a *App) QuickLaunch() error {
 	return a.DownloadAndLaunch(nick, false)
 }
 
-// ExitGame terminates the running game process
+// ExitGame asks the running game to close and kills it if it does not
 func (a *App) ExitGame() error {
 	return game.KillGame()
 }
 
+// ListRunningSessions returns the running game sessions of every instance, oldest first
+func (a *App) ListRunningSessions() []game.Process {
+	return game.ListRunningSessions()
+}
+
+// KillSession asks the running sessions of an instance to close and kills them if they do not
+func (a *App) KillSession(instanceID string) error {
+	if err := game.KillSession(instanceID); err != nil {
+		wrappedErr := GameError("Failed to stop game session", err)
+		a.emitError(wrappedErr)
+		return wrappedErr
+	}
+	return nil
+}
+
 // IsGameRunning returns whether the game is currently running
 func (a *App) IsGameRunning() bool {
 	return game.IsGameRunning()
 }
 
-// GetGameLogs returns the game log content
+// GetGameLogs returns the end of the most recent game session log
 func (a *App) GetGameLogs() (string, error) {
 	return game.GetGameLogs()
 }
 
+// ListSessions returns the logged game sessions of an instance, newest first
+func (a *App) ListSessions(instanceID string) ([]game.Session, error) {
+	sessions, err := game.ListSessions(instanceID)
+	if err != nil {
+		wrappedErr := FileSystemError("listing game sessions", err)
+		a.emitError(wrappedErr)
+		return nil, wrappedErr
+	}
+	return sessions, nil
+}
+
+// GetSessionLogs returns the log of one game session of an instance
+func (a *App) GetSessionLogs(instanceID string, sessionID string) (string, error) {
+	content, err := game.ReadSessionLog(instanceID, sessionID, 0)
+	if err != nil {
+		wrappedErr := FileSystemError("reading game session log", err)
+		a.emitError(wrappedErr)
+		return "", wrappedErr
+	}
+	return content, nil
+}
+
 // GetAvailableVersions returns list of available game versions (release and prerelease)
 func (a *App) GetAvailableVersions() map[string]int {
 	versions := make(map[string]int)
@@ -423,19 +577,39 @@ func (a *App) GetAvailableVersions() map[string]int {
 
 // GetVersionList returns all available version numbers for a branch (latest=0, then specific versions)
 func (a *App) GetVersionList(branch string) []int {
-	latest := pwr.FindLatestVersion(branch)
-	if latest <= 0 {
-		return []int{0} // Always include version 0 (latest auto-updating instance)
+	// Version 0 is always offered: it is the auto-updating latest instance
+	versions := []int{0}
+
+	index, _ := pwr.GetVersionIndex().Branch(branch)
+	if index == nil {
+		return versions
 	}
-	// Return versions: 0 (latest), then latest down to 1 (newest first)
-	versions := make([]int, latest+1)
-	versions[0] = 0 // Version 0 = auto-updating latest
-	for i := 0; i < latest; i++ {
-		versions[i+1] = latest - i
+
+	// Newest first
+	for i := len(index.Builds) - 1; i >= 0; i-- {
+		versions = append(versions, index.Builds[i].Version)
 	}
 	return versions
 }
 
+// GetVersionIndex returns the indexed builds of a branch, including sizes and patch availability
+// Served from the on-disk index when the patch server is unreachable
+func (a *App) GetVersionIndex(branch string) (*pwr.BranchIndex, error) {
+	index, err := pwr.GetVersionIndex().Branch(branch)
+	if index != nil {
+		return index, nil
+	}
+	return nil, NetworkError("checking game versions", err)
+}
+
+// RefreshVersionIndex forces a new scan of the patch server for a branch
+func (a *App) RefreshVersionIndex(branch string) error {
+	if err := pwr.GetVersionIndex().Refresh(branch); err != nil {
+		return NetworkError("checking game versions", err)
+	}
+	return nil
+}
+
 // IsVersionInstalled checks if a specific branch/version combination is installed
 func (a *App) IsVersionInstalled(branch string, version int) bool {
 	return env.IsVersionInstalled(branch, version)
@@ -447,7 +621,8 @@ func (a *App) GetInstalledVersionsForBranch(branch string) []int {
 }
 
 // CheckLatestNeedsUpdate checks if the 'latest' instance needs updating
-// Returns true if latest is installed but not at current latest version
+// Returns true if latest is installed but not at current latest version,
+// unless that version is one the instance was rolled back from
 func (a *App) CheckLatestNeedsUpdate(branch string) bool {
 	// Check if latest instance is installed
 	if !env.IsVersionInstalled(branch, 0) {
@@ -460,20 +635,15 @@ func (a *App) CheckLatestNeedsUpdate(branch string) bool {
 		return false
 	}
 
-	// Check if the latest instance has the current version
-	// We can check this by looking at the instance's installed version file
-	instanceDir := env.GetInstanceDir(branch, 0)
-	versionFile := filepath.Join(instanceDir, "version.txt")
-	data, err := os.ReadFile(versionFile)
-	if err != nil {
-		// No version file means fresh install or corrupted
-		return true
+	// A build the instance was rolled back from waits until a newer one is out
+	if inst := env.FindInstance(branch, 0); inst != nil && latestVersion <= inst.SkipBuild {
+		return false
 	}
 
-	installedVersionStr := string(data)
-	installedVersionStr = filepath.Base(strings.TrimSpace(installedVersionStr))
-	installedVersion, err := strconv.Atoi(installedVersionStr)
-	if err != nil {
+	// Check if the latest instance has the current version
+	installedVersion := env.GetInstanceBuild(branch, 0)
+	if installedVersion == 0 {
+		// No version file means fresh install or corrupted
 		return true
 	}
 
@@ -481,6 +651,84 @@ func (a *App) CheckLatestNeedsUpdate(branch string) bool {
 	return installedVersion < latestVersion
 }
 
+// ListInstanceGenerations returns the previous builds kept for an instance, most recent first
+func (a *App) ListInstanceGenerations(branch string, version int) []game.Generation {
+	return game.ListInstanceGenerations(branch, version)
+}
+
+// RollbackInstance restores a kept build of an instance and records it in instance.json.
+// build selects the generation; 0 restores the most recently archived one.
+// Returns the build number that is now installed
+func (a *App) RollbackInstance(branch string, version int, build int) (int, error) {
+	err := a.runTask(tasks.Options{
+		Kind:     "rollback",
+		Title:    fmt.Sprintf("Roll back %s %s", branch, versionLabel(version)),
+		Priority: tasks.PriorityNormal,
+		Key:      fmt.Sprintf("rollback:%s:%d", branch, version),
+		Group:    installGroup,
+	}, func(ctx context.Context, progress tasks.ProgressFunc) error {
+		if inst := env.FindInstance(branch, version); inst != nil {
+			if err := checkInstanceNotRunning(inst.ID); err != nil {
+				return err
+			}
+		}
+		progress("rollback", 0, "Restoring kept build...", "", "", 0, 0)
+		var err error
+		build, err = game.RollbackInstance(branch, version, build)
+		return err
+	})
+	if err != nil {
+		wrappedErr := GameError("Failed to roll back instance", err)
+		a.emitError(wrappedErr)
+		return 0, wrappedErr
+	}
+	return build, nil
+}
+
+// VerifyInstance checks an instance's game files against its build signature and reports damaged files
+func (a *App) VerifyInstance(branch string, version int) (*pwr.VerifyResult, error) {
+	var result *pwr.VerifyResult
+	err := a.runTask(tasks.Options{
+		Kind:     "verify",
+		Title:    fmt.Sprintf("Verify %s %s", branch, versionLabel(version)),
+		Priority: tasks.PriorityNormal,
+		Key:      fmt.Sprintf("verify:%s:%d", branch, version),
+		Group:    installGroup,
+	}, func(ctx context.Context, progress tasks.ProgressFunc) error {
+		var err error
+		result, err = game.VerifyInstance(ctx, branch, version, progress)
+		return err
+	})
+	if err != nil {
+		wrappedErr := GameError("Failed to verify game files", err)
+		a.emitError(wrappedErr)
+		return nil, wrappedErr
+	}
+	return result, nil
+}
+
+// HealInstance repairs only the damaged files of an instance instead of reinstalling it
+func (a *App) HealInstance(branch string, version int) (*pwr.HealResult, error) {
+	var result *pwr.HealResult
+	err := a.runTask(tasks.Options{
+		Kind:     "heal",
+		Title:    fmt.Sprintf("Repair %s %s", branch, versionLabel(version)),
+		Priority: tasks.PriorityNormal,
+		Key:      fmt.Sprintf("heal:%s:%d", branch, version),
+		Group:    installGroup,
+	}, func(ctx context.Context, progress tasks.ProgressFunc) error {
+		var err error
+		result, err = game.HealInstance(ctx, branch, version, progress)
+		return err
+	})
+	if err != nil {
+		wrappedErr := GameError("Failed to repair game files", err)
+		a.emitError(wrappedErr)
+		return nil, wrappedErr
+	}
+	return result, nil
+}
+
 // GetCurrentVersion returns the currently installed game version with formatted date
 func (a *App) GetCurrentVersion() string {
 	return pwr.GetLocalVersionFull()
@@ -532,7 +780,16 @@ func (a *App) DownloadVersion(versionType string, playerName string) error {
 	}
 
 	// Install specific version
-	if err := game.EnsureInstalledVersion(a.ctx, versionType, a.progressCallback); err != nil {
+	err := a.runTask(tasks.Options{
+		Kind:     "install",
+		Title:    fmt.Sprintf("Install %s latest", versionType),
+		Priority: tasks.PriorityHigh,
+		Key:      fmt.Sprintf("install:%s:0", versionType),
+		Group:    installGroup,
+	}, func(ctx context.Context, progress tasks.ProgressFunc) error {
+		return game.EnsureInstalledVersion(ctx, versionType, progress)
+	})
+	if err != nil {
 		wrappedErr := GameError("Failed to install game version", err)
 		a.emitError(wrappedErr)
 		return wrappedErr
@@ -1,9 +1,16 @@
 package app
 
 import (
+	"fmt"
+	"net/url"
+	"strings"
+
 	"HyPrism/internal/config"
 	"HyPrism/internal/env"
+	"HyPrism/internal/game"
+	"HyPrism/internal/logging"
 	"HyPrism/internal/pwr"
+	"HyPrism/internal/util/download"
 )
 
 // SetNick sets the player nickname
@@ -95,11 +102,9 @@ func (a *App) GetCustomInstanceDir() string {
 	return a.cfg.CustomInstanceDir
 }
 
-// SetCustomInstanceDir sets a custom directory for instances
+// SetCustomInstanceDir sets a custom directory for instances and moves existing instances there
 func (a *App) SetCustomInstanceDir(path string) error {
-	a.cfg.CustomInstanceDir = path
-	env.SetCustomInstanceDir(path) // Update the env module
-	return config.Save(a.cfg)
+	return a.relocateInstances(path, env.RelocateMove)
 }
 
 // GetAutoUpdateLatest returns whether the latest instance should auto-update
@@ -112,3 +117,207 @@ func (a *App) SetAutoUpdateLatest(enabled bool) error {
 	a.cfg.AutoUpdateLatest = enabled
 	return config.Save(a.cfg)
 }
+
+// GetKeptGenerations returns how many previous builds are kept per instance for rollback
+func (a *App) GetKeptGenerations() int {
+	return a.cfg.KeptGenerations
+}
+
+// SetKeptGenerations sets how many previous builds are kept per instance for rollback
+func (a *App) SetKeptGenerations(count int) error {
+	if count < 0 {
+		count = 0
+	}
+	a.cfg.KeptGenerations = count
+	game.SetKeptGenerations(count)
+	return config.Save(a.cfg)
+}
+
+// GetDownloadConnections returns how many parallel connections a patch download uses
+func (a *App) GetDownloadConnections() int {
+	return a.cfg.DownloadConnections
+}
+
+// SetDownloadConnections sets how many parallel connections a patch download uses
+func (a *App) SetDownloadConnections(count int) error {
+	if count < 1 {
+		count = 1
+	}
+	if count > 16 {
+		count = 16
+	}
+	a.cfg.DownloadConnections = count
+	download.SetMaxConnections(count)
+	return config.Save(a.cfg)
+}
+
+// GetBandwidthLimit returns the download cap in KB/s (0 = unlimited)
+func (a *App) GetBandwidthLimit() int64 {
+	return a.cfg.BandwidthLimitKB
+}
+
+// SetBandwidthLimit sets the download cap in KB/s (0 = unlimited)
+func (a *App) SetBandwidthLimit(kbPerSec int64) error {
+	if kbPerSec < 0 {
+		kbPerSec = 0
+	}
+	a.cfg.BandwidthLimitKB = kbPerSec
+	download.SetBandwidthLimit(kbPerSec * 1024)
+	return config.Save(a.cfg)
+}
+
+// GetPatchMirrors returns the patch base URLs in the order they are tried
+func (a *App) GetPatchMirrors() []string {
+	return a.cfg.PatchMirrors
+}
+
+// SetPatchMirrors sets the patch base URLs in the order they should be tried
+// An empty list restores the official patch server
+func (a *App) SetPatchMirrors(mirrors []string) error {
+	var cleaned []string
+	for _, mirror := range mirrors {
+		mirror = strings.TrimSpace(mirror)
+		if mirror == "" {
+			continue
+		}
+		u, err := url.Parse(mirror)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return ValidationError(fmt.Sprintf("Invalid mirror URL: %s", mirror))
+		}
+		cleaned = append(cleaned, strings.TrimRight(mirror, "/"))
+	}
+	if len(cleaned) == 0 {
+		cleaned = []string{config.DefaultPatchMirror}
+	}
+
+	a.cfg.PatchMirrors = cleaned
+	pwr.SetMirrors(cleaned)
+	return config.Save(a.cfg)
+}
+
+// GetPatchMirrorStatus returns the health of each configured patch mirror
+func (a *App) GetPatchMirrorStatus() []pwr.MirrorStatus {
+	return pwr.GetMirrorStatus()
+}
+
+// GetPatchCacheLimit returns the size budget of the patch cache in MB
+func (a *App) GetPatchCacheLimit() int64 {
+	return a.cfg.PatchCacheLimitMB
+}
+
+// SetPatchCacheLimit sets the size budget of the patch cache in MB
+// Least recently used patches are evicted right away if the cache is over the new budget
+func (a *App) SetPatchCacheLimit(limitMB int64) error {
+	if limitMB < 0 {
+		limitMB = 0
+	}
+	a.cfg.PatchCacheLimitMB = limitMB
+	pwr.SetCacheBudget(limitMB * 1024 * 1024)
+	return config.Save(a.cfg)
+}
+
+// GetSessionLogLimit returns the size in MB at which a game session log is rotated
+func (a *App) GetSessionLogLimit() int64 {
+	return a.cfg.SessionLogLimitMB
+}
+
+// SetSessionLogLimit sets the size in MB at which a game session log is rotated
+func (a *App) SetSessionLogLimit(limitMB int64) error {
+	if limitMB < 1 {
+		limitMB = 1
+	}
+	a.cfg.SessionLogLimitMB = limitMB
+	game.SetSessionLogLimits(limitMB*1024*1024, 0)
+	return config.Save(a.cfg)
+}
+
+// GetSessionLogRetention returns how many game session logs are kept per instance
+func (a *App) GetSessionLogRetention() int {
+	return a.cfg.SessionLogRetention
+}
+
+// SetSessionLogRetention sets how many game session logs are kept per instance
+// Older logs are removed when the next session starts
+func (a *App) SetSessionLogRetention(count int) error {
+	if count < 1 {
+		count = 1
+	}
+	a.cfg.SessionLogRetention = count
+	game.SetSessionLogLimits(0, count)
+	return config.Save(a.cfg)
+}
+
+// GetLogFormat returns the launcher log format: text or json
+func (a *App) GetLogFormat() string {
+	return a.cfg.LogFormat
+}
+
+// SetLogFormat sets the launcher log format: text or json
+// Records already in the log keep their format; GetLogs reads both
+func (a *App) SetLogFormat(format string) error {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format != logging.FormatText && format != logging.FormatJSON {
+		return ValidationError(fmt.Sprintf("Invalid log format: %s", format))
+	}
+	a.cfg.LogFormat = format
+	a.setupLogging()
+	return config.Save(a.cfg)
+}
+
+// GetLogLevels returns the launcher log level of every subsystem
+func (a *App) GetLogLevels() map[string]string {
+	levels := make(map[string]string, len(logging.Subsystems))
+	for _, subsystem := range logging.Subsystems {
+		levels[subsystem] = a.cfg.LogLevel
+		if level, ok := a.cfg.LogLevels[subsystem]; ok {
+			levels[subsystem] = level
+		}
+	}
+	return levels
+}
+
+// SetLogLevel sets the launcher log level of a subsystem: debug, info, warn or error
+// An empty subsystem sets the level of every subsystem without its own; an empty level clears a subsystem's own level
+func (a *App) SetLogLevel(subsystem string, level string) error {
+	subsystem = strings.ToLower(strings.TrimSpace(subsystem))
+	level = strings.ToLower(strings.TrimSpace(level))
+	if _, err := logging.ParseLevel(level); err != nil {
+		return ValidationError(fmt.Sprintf("Invalid log level: %s", level))
+	}
+
+	levels := make(map[string]string, len(a.cfg.LogLevels)+1)
+	for name, value := range a.cfg.LogLevels {
+		levels[name] = value
+	}
+	defaultLevel := a.cfg.LogLevel
+	switch {
+	case subsystem == "":
+		defaultLevel = level
+	case level == "":
+		delete(levels, subsystem)
+	default:
+		levels[subsystem] = level
+	}
+
+	if err := logging.SetLevels(defaultLevel, levels); err != nil {
+		return ValidationError(err.Error())
+	}
+	a.cfg.LogLevel = defaultLevel
+	a.cfg.LogLevels = levels
+	return config.Save(a.cfg)
+}
+
+// GetLogLimit returns the size in MB at which the launcher log is rotated
+func (a *App) GetLogLimit() int64 {
+	return a.cfg.LogLimitMB
+}
+
+// SetLogLimit sets the size in MB at which the launcher log is rotated
+func (a *App) SetLogLimit(limitMB int64) error {
+	if limitMB < 1 {
+		limitMB = 1
+	}
+	a.cfg.LogLimitMB = limitMB
+	a.setupLogging()
+	return config.Save(a.cfg)
+}
@@ -1,25 +1,37 @@
 package app
 
 import (
+	"HyPrism/internal/diagnostics"
 	"HyPrism/internal/env"
+	"HyPrism/internal/game"
 	"HyPrism/internal/java"
+	"HyPrism/internal/pwr"
 	"HyPrism/internal/pwr/butler"
+	"HyPrism/internal/support"
+	"HyPrism/internal/tasks"
+	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 )
 
 // DiagnosticReport contains system diagnostic information
 type DiagnosticReport struct {
-	Platform      PlatformInfo      `json:"platform"`
-	Connectivity  ConnectivityInfo  `json:"connectivity"`
-	GameStatus    GameStatusInfo    `json:"gameStatus"`
-	Dependencies  DependenciesInfo  `json:"dependencies"`
-	Timestamp     string            `json:"timestamp"`
+	Platform     PlatformInfo                 `json:"platform"`
+	Connectivity ConnectivityInfo             `json:"connectivity"`
+	GameStatus   GameStatusInfo               `json:"gameStatus"`
+	Dependencies DependenciesInfo             `json:"dependencies"`
+	Checks       []diagnostics.Check          `json:"checks"` // Disk, permission, Java, butler, library and instance checks
+	Instances    []diagnostics.InstanceStatus `json:"instances"`
+	Status       diagnostics.Status           `json:"status"` // Worst outcome of the checks
+	Timestamp    string                       `json:"timestamp"`
 }
 
 type PlatformInfo struct {
@@ -36,17 +48,22 @@ type ConnectivityInfo struct {
 }
 
 type GameStatusInfo struct {
-	Installed       bool   `json:"installed"`
-	Version         string `json:"version"`
-	ClientExists    bool   `json:"clientExists"`
+	Instance         string `json:"instance"` // ID of the selected instance
+	Installed        bool   `json:"installed"`
+	Version          string `json:"version"`
+	ClientExists     bool   `json:"clientExists"`
 	OnlineFixApplied bool   `json:"onlineFixApplied"`
 }
 
 type DependenciesInfo struct {
 	JavaInstalled   bool   `json:"javaInstalled"`
 	JavaPath        string `json:"javaPath"`
+	JavaVendor      string `json:"javaVendor"`
+	JavaVersion     string `json:"javaVersion"`
+	JavaArch        string `json:"javaArch"`
 	ButlerInstalled bool   `json:"butlerInstalled"`
 	ButlerPath      string `json:"butlerPath"`
+	ButlerVersion   string `json:"butlerVersion"`
 }
 
 // RunDiagnostics runs system diagnostics
@@ -65,11 +82,23 @@ func (a *App) RunDiagnostics() DiagnosticReport {
 	// Connectivity checks
 	report.Connectivity = checkConnectivity()
 
-	// Game status
-	report.GameStatus = checkGameStatus()
+	// Game status of the selected instance
+	report.GameStatus = checkGameStatus(env.FindInstance(a.GetVersionType(), a.GetSelectedVersion()))
+
+	// Deep checks, whose findings also fill in the dependencies
+	checks := diagnostics.Run(a.ctx)
+	report.Checks = checks.Checks
+	report.Instances = checks.Instances
+	report.Status = checks.Worst()
 
 	// Dependencies
 	report.Dependencies = checkDependencies()
+	if checks.Java != nil {
+		report.Dependencies.JavaVendor = checks.Java.Vendor
+		report.Dependencies.JavaVersion = checks.Java.Version
+		report.Dependencies.JavaArch = checks.Java.Arch
+	}
+	report.Dependencies.ButlerVersion = checks.Butler
 
 	return report
 }
@@ -80,8 +109,9 @@ func checkConnectivity() ConnectivityInfo {
 		Timeout: 10 * time.Second,
 	}
 
-	// Check Hytale patches
-	resp, err := client.Head("https://game-patches.hytale.com")
+	// Check Hytale patches on the mirror currently tried first
+	patchServer := pwr.PrimaryMirror()
+	resp, err := client.Head(patchServer)
 	if err == nil && resp.StatusCode < 500 {
 		info.HytalePatches = true
 	}
@@ -99,7 +129,11 @@ func checkConnectivity() ConnectivityInfo {
 	}
 
 	// DNS check
-	_, err = net.LookupHost("game-patches.hytale.com")
+	host := "game-patches.hytale.com"
+	if u, err := url.Parse(patchServer); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	_, err = net.LookupHost(host)
 	if err != nil {
 		info.Error = "DNS resolution failed: " + err.Error()
 	}
@@ -107,28 +141,22 @@ func checkConnectivity() ConnectivityInfo {
 	return info
 }
 
-func checkGameStatus() GameStatusInfo {
+// checkGameStatus reports the install state of the selected instance
+func checkGameStatus(inst *env.Instance) GameStatusInfo {
 	info := GameStatusInfo{}
-
-	// Check latest instance (version 0)
-	gameDir := env.GetInstanceGameDir("release", 0)
-
-	// Check if game is installed
-	clientName := "HytaleClient"
-	if runtime.GOOS == "windows" {
-		clientName += ".exe"
+	if inst == nil {
+		return info
 	}
+	info.Instance = inst.ID
 
-	clientPath := filepath.Join(gameDir, "Client", clientName)
-	if _, err := os.Stat(clientPath); err == nil {
-		info.Installed = true
+	gameDir := inst.GameDir()
+	if _, err := os.Stat(game.ClientPath(gameDir)); err == nil {
 		info.ClientExists = true
 	}
-
-	// Check if latest instance is installed
-	if env.IsVersionInstalled("release", 0) {
-		info.Version = "latest"
+	if inst.InstalledBuild > 0 {
+		info.Version = fmt.Sprintf("%s build %d", inst.Branch, inst.InstalledBuild)
 	}
+	info.Installed = info.ClientExists && inst.InstalledBuild > 0
 
 	// Check if online fix is applied (Windows only)
 	if runtime.GOOS == "windows" {
@@ -177,7 +205,16 @@ func (a *App) SaveDiagnosticReport() (string, error) {
 	filename := fmt.Sprintf("diagnostic_%s.txt", time.Now().Format("2006-01-02_15-04-05"))
 	filepath := filepath.Join(logsDir, filename)
 
-	content := fmt.Sprintf(`HyPrism Diagnostic Report
+	if err := os.WriteFile(filepath, []byte(report.Text()), 0644); err != nil {
+		return "", err
+	}
+
+	return filepath, nil
+}
+
+// Text formats the report for people
+func (r DiagnosticReport) Text() string {
+	text := fmt.Sprintf(`HyPrism Diagnostic Report
 Generated: %s
 
 === PLATFORM ===
@@ -192,6 +229,7 @@ itch.io (Butler): %v
 Error: %s
 
 === GAME STATUS ===
+Instance: %s
 Installed: %v
 Version: %s
 Client Exists: %v
@@ -200,21 +238,70 @@ Online Fix Applied: %v
 === DEPENDENCIES ===
 Java Installed: %v
 Java Path: %s
+Java Runtime: %s %s (%s)
 Butler Installed: %v
 Butler Path: %s
+Butler Version: %s
 `,
-		report.Timestamp,
-		report.Platform.OS, report.Platform.Arch, report.Platform.Version,
-		report.Connectivity.HytalePatches, report.Connectivity.GitHub, report.Connectivity.ItchIO, report.Connectivity.Error,
-		report.GameStatus.Installed, report.GameStatus.Version, report.GameStatus.ClientExists, report.GameStatus.OnlineFixApplied,
-		report.Dependencies.JavaInstalled, report.Dependencies.JavaPath, report.Dependencies.ButlerInstalled, report.Dependencies.ButlerPath,
+		r.Timestamp,
+		r.Platform.OS, r.Platform.Arch, r.Platform.Version,
+		r.Connectivity.HytalePatches, r.Connectivity.GitHub, r.Connectivity.ItchIO, r.Connectivity.Error,
+		r.GameStatus.Instance, r.GameStatus.Installed, r.GameStatus.Version, r.GameStatus.ClientExists, r.GameStatus.OnlineFixApplied,
+		r.Dependencies.JavaInstalled, r.Dependencies.JavaPath, r.Dependencies.JavaVendor, r.Dependencies.JavaVersion, r.Dependencies.JavaArch,
+		r.Dependencies.ButlerInstalled, r.Dependencies.ButlerPath, r.Dependencies.ButlerVersion,
 	)
 
-	if err := os.WriteFile(filepath, []byte(content), 0644); err != nil {
-		return "", err
+	var b strings.Builder
+	b.WriteString(text)
+	fmt.Fprintf(&b, "\n=== CHECKS (%s) ===\n", strings.ToUpper(string(r.Status)))
+	for _, c := range r.Checks {
+		fmt.Fprintf(&b, "[%s] %s: %s\n", strings.ToUpper(string(c.Status)), c.Title, c.Detail)
+		if c.Status != diagnostics.StatusPass && c.Fix != "" {
+			fmt.Fprintf(&b, "       Fix: %s\n", c.Fix)
+		}
 	}
+	return b.String()
+}
 
-	return filepath, nil
+// ExportSupportBundle writes a zip with the diagnostics, logs, crash reports, settings and
+// instance details to the logs folder and opens the folder. Personal details are redacted.
+func (a *App) ExportSupportBundle() (*support.Result, error) {
+	var result *support.Result
+	err := a.runTask(tasks.Options{
+		Kind:     "support",
+		Title:    "Export support bundle",
+		Priority: tasks.PriorityNormal,
+		Key:      "support",
+	}, func(ctx context.Context, progress tasks.ProgressFunc) error {
+		report := a.RunDiagnostics()
+		reportJSON, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		result, err = support.Write(ctx, support.Input{
+			Dir:             env.GetLogsDir(),
+			DiagnosticsJSON: reportJSON,
+			DiagnosticsText: report.Text(),
+			Config:          a.cfg,
+			LauncherLogs:    support.LauncherLogs(launcherLogPath(), launcherLogBackups),
+		}, progress)
+		return err
+	})
+	if err != nil {
+		wrappedErr := FileSystemError("exporting support bundle", err)
+		a.emitError(wrappedErr)
+		return nil, wrappedErr
+	}
+
+	if result == nil {
+		err := FileSystemError("exporting support bundle", fmt.Errorf("no bundle was written"))
+		a.emitError(err)
+		return nil, err
+	}
+	if err := openFolder(filepath.Dir(result.Path)); err != nil {
+		logger.Warn("Failed to open support bundle folder", "path", result.Path, "error", err)
+	}
+	return result, nil
 }
 
 // CrashReport represents a crash report
@@ -226,7 +313,7 @@ type CrashReport struct {
 
 // GetCrashReports returns available crash reports
 func (a *App) GetCrashReports() ([]CrashReport, error) {
-	crashDir := filepath.Join(env.GetDefaultAppDir(), "crashes")
+	crashDir := env.GetCrashesDir()
 	
 	entries, err := os.ReadDir(crashDir)
 	if err != nil {
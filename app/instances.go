@@ -0,0 +1,501 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"HyPrism/internal/config"
+	"HyPrism/internal/env"
+	"HyPrism/internal/game"
+	"HyPrism/internal/mods"
+	"HyPrism/internal/portable"
+	"HyPrism/internal/store"
+	"HyPrism/internal/tasks"
+)
+
+// ListInstances returns the descriptor of every instance, oldest first
+func (a *App) ListInstances() []*env.Instance {
+	return env.ListInstanceDescriptors()
+}
+
+// GetInstance returns the descriptor of one instance
+func (a *App) GetInstance(id string) (*env.Instance, error) {
+	inst, err := env.GetInstance(id)
+	if err != nil {
+		wrappedErr := GameError("Instance not found", err)
+		a.emitError(wrappedErr)
+		return nil, wrappedErr
+	}
+	return inst, nil
+}
+
+// UpdateInstanceInfo changes the display name, icon and notes of an instance
+func (a *App) UpdateInstanceInfo(id string, name string, icon string, notes string) (*env.Instance, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		err := ValidationError("Instance name cannot be empty")
+		a.emitError(err)
+		return nil, err
+	}
+
+	inst, err := env.UpdateInstance(id, func(inst *env.Instance) {
+		inst.Name = name
+		inst.Icon = icon
+		inst.Notes = notes
+	})
+	if err != nil {
+		wrappedErr := FileSystemError("saving instance", err)
+		a.emitError(wrappedErr)
+		return nil, wrappedErr
+	}
+	return inst, nil
+}
+
+// SetInstanceLaunchSettings changes the launch profile of an instance: extra arguments,
+// environment overrides, working directory and Java options
+func (a *App) SetInstanceLaunchSettings(id string, settings env.LaunchSettings) (*env.Instance, error) {
+	if err := settings.Validate(); err != nil {
+		wrappedErr := ValidationError(fmt.Sprintf("Invalid launch settings: %v", err))
+		a.emitError(wrappedErr)
+		return nil, wrappedErr
+	}
+
+	inst, err := env.UpdateInstance(id, func(inst *env.Instance) {
+		inst.Launch = settings
+	})
+	if err != nil {
+		wrappedErr := FileSystemError("saving instance", err)
+		a.emitError(wrappedErr)
+		return nil, wrappedErr
+	}
+	return inst, nil
+}
+
+// PreviewLaunchCommand returns the command line an instance would be started with,
+// including its launch profile, with tokens and other secrets redacted
+func (a *App) PreviewLaunchCommand(id string, fakeServer bool) (*game.LaunchCommand, error) {
+	nick := a.cfg.Nick
+	if nick == "" {
+		nick = "Player"
+	}
+	preview, err := game.PreviewLaunchCommand(nick, id, fakeServer)
+	if err != nil {
+		wrappedErr := GameError("Failed to build launch command", err)
+		a.emitError(wrappedErr)
+		return nil, wrappedErr
+	}
+	return preview, nil
+}
+
+// CreateInstance creates a named instance of a game build with its own UserData and Mods.
+// If the build is already installed in another instance, the game files are shared.
+func (a *App) CreateInstance(name string, branch string, version int) (*env.Instance, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		err := ValidationError("Instance name cannot be empty")
+		a.emitError(err)
+		return nil, err
+	}
+	if version < 0 {
+		err := ValidationError("Invalid game version")
+		a.emitError(err)
+		return nil, err
+	}
+
+	inst, err := env.CreateInstance(name, branch, version)
+	if err != nil {
+		wrappedErr := FileSystemError("creating instance", err)
+		a.emitError(wrappedErr)
+		return nil, wrappedErr
+	}
+	return inst, nil
+}
+
+// CloneInstance copies an instance's UserData, mods and settings into a new instance
+func (a *App) CloneInstance(id string, newName string) (*env.Instance, error) {
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		err := ValidationError("Instance name cannot be empty")
+		a.emitError(err)
+		return nil, err
+	}
+
+	var clone *env.Instance
+	err := a.runTask(tasks.Options{
+		Kind:     "clone",
+		Title:    fmt.Sprintf("Clone %s", id),
+		Priority: tasks.PriorityNormal,
+		Key:      "clone:" + id,
+		Group:    installGroup,
+	}, func(ctx context.Context, progress tasks.ProgressFunc) error {
+		progress("clone", 0, "Copying instance files...", "", "", 0, 0)
+		var err error
+		clone, err = env.CloneInstance(id, newName)
+		if err == nil {
+			progress("complete", 100, "Instance cloned", "", "", 0, 0)
+		}
+		return err
+	})
+	if err != nil {
+		wrappedErr := FileSystemError("cloning instance", err)
+		a.emitError(wrappedErr)
+		return nil, wrappedErr
+	}
+	return clone, nil
+}
+
+// RenameInstance changes the display name of an instance
+func (a *App) RenameInstance(id string, newName string) (*env.Instance, error) {
+	inst, err := env.RenameInstance(id, newName)
+	if err != nil {
+		wrappedErr := ValidationError(fmt.Sprintf("Failed to rename instance: %v", err))
+		a.emitError(wrappedErr)
+		return nil, wrappedErr
+	}
+	return inst, nil
+}
+
+// LaunchInstanceByID installs or updates the game build of an instance if needed and starts it
+func (a *App) LaunchInstanceByID(id string, playerName string, fakeServer bool) error {
+	if len(playerName) == 0 {
+		err := ValidationError("Please enter a nickname")
+		a.emitError(err)
+		return err
+	}
+
+	if len(playerName) > 16 {
+		err := ValidationError("Nickname is too long (max 16 characters)")
+		a.emitError(err)
+		return err
+	}
+
+	inst, err := env.GetInstance(id)
+	if err != nil {
+		wrappedErr := GameError("Instance not found", err)
+		a.emitError(wrappedErr)
+		return wrappedErr
+	}
+
+	// The build is installed into the instance holding the game files
+	err = a.runTask(tasks.Options{
+		Kind:     "install",
+		Title:    fmt.Sprintf("Install %s %s", inst.Branch, versionLabel(inst.Version)),
+		Priority: tasks.PriorityHigh,
+		Key:      fmt.Sprintf("install:%s:%d", inst.Branch, inst.Version),
+		Group:    installGroup,
+	}, func(ctx context.Context, progress tasks.ProgressFunc) error {
+		return game.EnsureInstalledVersionSpecific(ctx, inst.Branch, inst.Version, progress)
+	})
+	if err != nil {
+		wrappedErr := GameError("Failed to install or update game", err)
+		a.emitError(wrappedErr)
+		return wrappedErr
+	}
+
+	a.progressCallback("launch", 100, "Launching game...", "", "", 0, 0)
+
+	if err := game.LaunchInstanceByID(playerName, id, fakeServer); err != nil {
+		wrappedErr := GameError("Failed to launch game", err)
+		a.emitError(wrappedErr)
+		return wrappedErr
+	}
+	return nil
+}
+
+// GetInstanceModsByID returns the mods installed in the instance with the given ID
+func (a *App) GetInstanceModsByID(id string) ([]mods.Mod, error) {
+	return mods.GetInstalledModsByID(id)
+}
+
+// InstallModToInstanceByID downloads and installs a mod to the instance with the given ID
+func (a *App) InstallModToInstanceByID(modID int, id string) error {
+	cfMod, err := mods.GetModDetails(a.ctx, modID)
+	if err != nil {
+		return err
+	}
+
+	return a.runModTask(cfMod.Name, fmt.Sprintf("mod:%d:%s", modID, id), func(ctx context.Context, progress func(float64, string)) error {
+		return mods.DownloadModByID(ctx, *cfMod, id, progress)
+	})
+}
+
+// InstallModFileToInstanceByID downloads and installs a specific mod file version to the instance with the given ID
+func (a *App) InstallModFileToInstanceByID(modID int, fileID int, id string) error {
+	return a.runModTask(fmt.Sprintf("mod %d", modID), fmt.Sprintf("mod:%d:%s", modID, id), func(ctx context.Context, progress func(float64, string)) error {
+		return mods.DownloadModFileByID(ctx, modID, fileID, id, progress)
+	})
+}
+
+// UninstallInstanceModByID removes an installed mod from the instance with the given ID
+func (a *App) UninstallInstanceModByID(modID string, id string) error {
+	return mods.RemoveModByID(modID, id)
+}
+
+// ToggleInstanceModByID enables or disables a mod in the instance with the given ID
+func (a *App) ToggleInstanceModByID(modID string, enabled bool, id string) error {
+	return mods.ToggleModByID(modID, enabled, id)
+}
+
+// CheckInstanceModUpdatesByID checks for mod updates in the instance with the given ID
+func (a *App) CheckInstanceModUpdatesByID(id string) ([]mods.Mod, error) {
+	return mods.CheckForUpdatesByID(a.ctx, id)
+}
+
+// OpenInstanceModsFolderByID opens the mods folder of the instance with the given ID
+func (a *App) OpenInstanceModsFolderByID(id string) error {
+	modsDir, err := mods.GetModsDirByID(id)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(modsDir, 0755); err != nil {
+		return err
+	}
+	return openFolder(modsDir)
+}
+
+// ExportInstance writes an instance to a zip or tar.zst archive that can be imported elsewhere.
+// The game files are not included; the archive only references the build.
+func (a *App) ExportInstance(id string, path string, options portable.ExportOptions) (string, error) {
+	if strings.TrimSpace(path) == "" {
+		err := ValidationError("Please choose where to save the instance")
+		a.emitError(err)
+		return "", err
+	}
+
+	var written string
+	err := a.runTask(tasks.Options{
+		Kind:     "export",
+		Title:    fmt.Sprintf("Export %s", id),
+		Priority: tasks.PriorityNormal,
+		Key:      "export:" + id,
+	}, func(ctx context.Context, progress tasks.ProgressFunc) error {
+		var err error
+		written, err = portable.Export(ctx, id, path, options, progress)
+		return err
+	})
+	if err != nil {
+		wrappedErr := FileSystemError("exporting instance", err)
+		a.emitError(wrappedErr)
+		return "", wrappedErr
+	}
+	return written, nil
+}
+
+// ImportInstance recreates an instance from an exported archive, installs its game build
+// and downloads mods that were not bundled. An auto-update instance starts at the build it
+// was exported with and is offered updates from there, unless another instance already holds
+// that branch: the import then shares its game files and adopts the build installed there.
+// A failed import leaves nothing behind.
+func (a *App) ImportInstance(path string) (*env.Instance, error) {
+	var inst *env.Instance
+	err := a.runTask(tasks.Options{
+		Kind:     "import",
+		Title:    fmt.Sprintf("Import %s", filepath.Base(path)),
+		Priority: tasks.PriorityNormal,
+		Key:      "import:" + path,
+		Group:    installGroup,
+	}, func(ctx context.Context, progress tasks.ProgressFunc) error {
+		var manifest *portable.Manifest
+		var err error
+		inst, manifest, err = portable.Import(ctx, path, progress)
+		if err != nil {
+			return err
+		}
+		build := 0
+		if inst.AutoUpdate && inst.GameFrom == "" {
+			build = manifest.Build
+		} else if inst.AutoUpdate {
+			logger.Info("Imported instance shares installed game files", "instance", inst.ID, "gameFrom", inst.GameFrom, "exportedBuild", manifest.Build)
+		}
+		return game.EnsureInstalledBuild(ctx, inst.Branch, inst.Version, build, progress)
+	})
+	if err != nil {
+		if inst != nil {
+			if _, delErr := env.DeleteInstance(inst.ID, false); delErr != nil {
+				logger.Warn("Failed to remove partly imported instance", "instance", inst.ID, "error", delErr)
+			}
+		}
+		wrappedErr := GameError("Failed to import instance", err)
+		a.emitError(wrappedErr)
+		return nil, wrappedErr
+	}
+
+	// The descriptor now reports the build that was installed for it
+	if updated, err := env.GetInstance(inst.ID); err == nil {
+		inst = updated
+	}
+	return inst, nil
+}
+
+// DeleteInstance removes one instance. Game files other instances share are handed over to them.
+// With keepUserData the instance's UserData is kept in the app directory; its location is returned.
+func (a *App) DeleteInstance(id string, keepUserData bool) (string, error) {
+	var kept string
+	err := a.runTask(tasks.Options{
+		Kind:     "delete",
+		Title:    fmt.Sprintf("Delete %s", id),
+		Priority: tasks.PriorityNormal,
+		Key:      "delete:" + id,
+		Group:    installGroup,
+	}, func(ctx context.Context, progress tasks.ProgressFunc) error {
+		if err := checkInstanceNotRunning(id); err != nil {
+			return err
+		}
+		progress("delete", 0, "Deleting instance...", "", "", 0, 0)
+		var err error
+		kept, err = env.DeleteInstance(id, keepUserData)
+		return err
+	})
+	if err != nil {
+		wrappedErr := FileSystemError("deleting instance", err)
+		a.emitError(wrappedErr)
+		return "", wrappedErr
+	}
+
+	// The instance may have been the last user of some stored game files
+	go store.CollectGarbage()
+	return kept, nil
+}
+
+// MoveInstance moves an instance's files to another directory, for example on an external drive.
+// An interrupted move is resumed by calling it again with the same destination.
+func (a *App) MoveInstance(id string, destDir string) (*env.Instance, error) {
+	if strings.TrimSpace(destDir) == "" {
+		err := ValidationError("Please choose where to move the instance")
+		a.emitError(err)
+		return nil, err
+	}
+
+	inst, err := a.moveInstance(id, destDir)
+	if err != nil {
+		wrappedErr := FileSystemError("moving instance", err)
+		a.emitError(wrappedErr)
+		return nil, wrappedErr
+	}
+	return inst, nil
+}
+
+// moveInstance queues a move of an instance and waits for it
+func (a *App) moveInstance(id string, destDir string) (*env.Instance, error) {
+	var inst *env.Instance
+	err := a.runTask(tasks.Options{
+		Kind:     "move",
+		Title:    fmt.Sprintf("Move %s", id),
+		Priority: tasks.PriorityNormal,
+		Key:      "move:" + id,
+		Group:    installGroup,
+	}, func(ctx context.Context, progress tasks.ProgressFunc) error {
+		if err := checkInstanceNotRunning(id); err != nil {
+			return err
+		}
+		var err error
+		inst, err = env.MoveInstance(ctx, id, destDir, func(copied, total int64, currentFile string) {
+			value := 0.0
+			if total > 0 {
+				value = float64(copied) / float64(total) * 100
+			}
+			progress("move", value, "Moving instance...", currentFile, "", copied, total)
+		})
+		return err
+	})
+	return inst, err
+}
+
+// checkInstanceNotRunning fails while the game runs from an instance's files, either in the
+// instance itself or in another instance sharing its game files
+func checkInstanceNotRunning(id string) error {
+	if sessions := game.SessionsUsingInstance(id); len(sessions) > 0 {
+		return fmt.Errorf("close the game running in %s first", sessions[0].InstanceID)
+	}
+	return nil
+}
+
+// resumeInstanceMoves finishes moves that were interrupted when the launcher closed
+func (a *App) resumeInstanceMoves() {
+	for _, move := range env.PendingMoves() {
+		if _, err := a.moveInstance(move.ID, filepath.Dir(move.To)); err != nil {
+			logger.Warn("Failed to resume instance move", "instance", move.ID, "error", err)
+		}
+	}
+}
+
+// GetInstanceSizes returns the disk usage of every instance split into game, UserData, mods and saves
+func (a *App) GetInstanceSizes() []env.InstanceSize {
+	return env.GetInstanceSizes()
+}
+
+// PlanInstanceDirectoryChange reports what moving or copying the instances to path would take,
+// so the user can choose before anything is touched. An empty path means the default directory.
+func (a *App) PlanInstanceDirectoryChange(path string, mode string) (*env.RelocationPlan, error) {
+	plan, err := env.PlanRelocation(path, env.RelocationMode(mode))
+	if err != nil {
+		wrappedErr := FileSystemError("checking the new instances directory", err)
+		a.emitError(wrappedErr)
+		return nil, wrappedErr
+	}
+	return plan, nil
+}
+
+// ChangeInstanceDirectory switches the instances directory to path and moves or copies
+// existing instances there. mode is "move", "copy" or "none".
+func (a *App) ChangeInstanceDirectory(path string, mode string) error {
+	if err := a.relocateInstances(path, env.RelocationMode(mode)); err != nil {
+		wrappedErr := FileSystemError("changing the instances directory", err)
+		a.emitError(wrappedErr)
+		return wrappedErr
+	}
+	return nil
+}
+
+// relocateInstances queues a change of the instances directory and waits for it.
+// The old copies are removed only after the new directory has been saved.
+func (a *App) relocateInstances(path string, mode env.RelocationMode) error {
+	if game.IsGameRunning() {
+		return fmt.Errorf("close the game before changing the instances directory")
+	}
+
+	return a.runTask(tasks.Options{
+		Kind:     "relocate",
+		Title:    "Change instances directory",
+		Priority: tasks.PriorityNormal,
+		Key:      "relocate",
+		Group:    installGroup,
+	}, func(ctx context.Context, progress tasks.ProgressFunc) error {
+		relocation, err := env.RelocateInstances(ctx, path, mode, func(copied, total int64, currentFile string) {
+			value := 0.0
+			if total > 0 {
+				value = float64(copied) / float64(total) * 100
+			}
+			progress("relocate", value, "Moving instances...", currentFile, "", copied, total)
+		})
+		if err != nil {
+			return err
+		}
+
+		previous := a.cfg.CustomInstanceDir
+		a.cfg.CustomInstanceDir = path
+		if err := config.Save(a.cfg); err != nil {
+			a.cfg.CustomInstanceDir = previous
+			relocation.Rollback()
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		// Folders from older launcher versions in the new directory get a descriptor too
+		env.MigrateInstances(a.cfg.VersionType)
+
+		if err := relocation.Finish(); err != nil {
+			logger.Warn("Failed to finish changing the instances directory", "error", err)
+		}
+		if mode == env.RelocateCopy {
+			// Copies are full files; link them with the store again where the volume allows
+			if _, err := game.DeduplicateInstances(ctx, progress); err != nil {
+				logger.Warn("Failed to share copied game files", "error", err)
+			}
+		}
+		go store.CollectGarbage()
+		return nil
+	})
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"HyPrism/internal/tasks"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// maxConcurrentTasks is how many queued jobs may run at once; installs are
+// additionally serialized through the "install" group
+const maxConcurrentTasks = 3
+
+// installGroup serializes jobs that write to game instances
+const installGroup = "install"
+
+// newTaskQueue creates the queue every long-running operation goes through
+func (a *App) newTaskQueue(ctx context.Context) *tasks.Queue {
+	return tasks.NewQueue(ctx, maxConcurrentTasks, func(job tasks.Job) {
+		// One event per job for views tracking a single operation, and one for the queue as a whole
+		wailsRuntime.EventsEmit(a.ctx, "task:"+job.ID, job)
+		wailsRuntime.EventsEmit(a.ctx, "task:update", job)
+	})
+}
+
+// runTask queues an operation and waits for it to finish.
+// Progress is also sent as "progress-update" so existing views keep working.
+func (a *App) runTask(opts tasks.Options, run func(ctx context.Context, progress tasks.ProgressFunc) error) error {
+	return a.tasks.Run(a.ctx, opts, func(ctx context.Context, progress tasks.ProgressFunc) error {
+		return run(ctx, func(stage string, value float64, message string, currentFile string, speed string, downloaded, total int64) {
+			progress(stage, value, message, currentFile, speed, downloaded, total)
+			a.progressCallback(stage, value, message, currentFile, speed, downloaded, total)
+		})
+	})
+}
+
+// ListTasks returns every queued, running and recently finished task
+func (a *App) ListTasks() []tasks.Job {
+	return a.tasks.List()
+}
+
+// GetTask returns the current state of a task
+func (a *App) GetTask(id string) (tasks.Job, error) {
+	return a.tasks.Get(id)
+}
+
+// PauseTask stops a task and keeps its partial downloads so it can be resumed
+func (a *App) PauseTask(id string) error {
+	return a.tasks.Pause(id)
+}
+
+// ResumeTask puts a paused task back in the queue
+func (a *App) ResumeTask(id string) error {
+	return a.tasks.Resume(id)
+}
+
+// CancelTask stops a task for good
+func (a *App) CancelTask(id string) error {
+	return a.tasks.Cancel(id)
+}
+
+// SetTaskPriority changes the priority of a task that has not finished yet
+func (a *App) SetTaskPriority(id string, priority int) error {
+	return a.tasks.SetPriority(id, priority)
+}
+
+// ClearFinishedTasks removes completed, failed and cancelled tasks from the list
+func (a *App) ClearFinishedTasks() {
+	a.tasks.ClearFinished()
+}
+
+// versionLabel names an instance version in task titles
+func versionLabel(version int) string {
+	if version == 0 {
+		return "latest"
+	}
+	return fmt.Sprintf("v%d", version)
+}
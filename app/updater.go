@@ -1,28 +1,34 @@
 package app
 
 import (
+	"HyPrism/internal/logging"
+	"HyPrism/internal/tasks"
 	"HyPrism/internal/util"
 	"HyPrism/updater"
+	"context"
 	"fmt"
 	"os"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
+// updaterLogger writes launcher update records to the launcher log
+var updaterLogger = logging.For(logging.Updater)
+
 // CheckUpdate checks for launcher updates
 func (a *App) CheckUpdate() (*updater.Asset, error) {
-	fmt.Println("Checking for launcher updates...")
+	updaterLogger.Info("Checking for launcher updates")
 
 	asset, newVersion, err := updater.CheckUpdate(a.ctx, AppVersion)
 	if err != nil {
-		fmt.Printf("Update check failed: %v\n", err)
+		updaterLogger.Warn("Update check failed", "error", err)
 		return nil, nil
 	}
 
 	if asset != nil {
-		fmt.Printf("Update available: %s\n", newVersion)
+		updaterLogger.Info("Update available", "version", newVersion)
 	} else {
-		fmt.Println("No update available")
+		updaterLogger.Info("No update available")
 	}
 
 	return asset, nil
@@ -30,73 +36,83 @@ func (a *App) CheckUpdate() (*updater.Asset, error) {
 
 // Update downloads and applies a launcher update
 func (a *App) Update() error {
-	fmt.Println("Starting launcher update process...")
+	updaterLogger.Info("Starting launcher update")
 
 	asset, newVersion, err := updater.CheckUpdate(a.ctx, AppVersion)
 	if err != nil {
-		fmt.Printf("Update check failed: %v\n", err)
+		updaterLogger.Error("Update check failed", "error", err)
 		return WrapError(ErrorTypeNetwork, "Failed to check for updates", err)
 	}
 
 	if asset == nil {
-		fmt.Println("No update available")
+		updaterLogger.Info("No update available")
 		return nil
 	}
 
-	fmt.Printf("Downloading update from: %s\n", asset.URL)
-
-	tmp, err := updater.DownloadUpdate(a.ctx, asset.URL, func(stage string, progress float64, message string, currentFile string, speed string, downloaded int64, total int64) {
-		fmt.Printf("[%s] %s: %.1f%% (%d/%d bytes) at %s\n", stage, message, progress, downloaded, total, speed)
-		runtime.EventsEmit(a.ctx, "update:progress", stage, progress, message, currentFile, speed, downloaded, total)
+	updaterLogger.Info("Downloading update", "version", newVersion, "url", asset.URL)
+
+	var tmp string
+	err = a.tasks.Run(a.ctx, tasks.Options{
+		Kind:     "update",
+		Title:    fmt.Sprintf("Download launcher %s", newVersion),
+		Priority: tasks.PriorityHigh,
+		Key:      "update",
+	}, func(ctx context.Context, taskProgress tasks.ProgressFunc) error {
+		var err error
+		tmp, err = updater.DownloadUpdate(ctx, asset.URL, func(stage string, progress float64, message string, currentFile string, speed string, downloaded int64, total int64) {
+			updaterLogger.Debug(message, "stage", stage, "progress", progress, "downloaded", downloaded, "total", total, "speed", speed)
+			taskProgress(stage, progress, message, currentFile, speed, downloaded, total)
+			runtime.EventsEmit(a.ctx, "update:progress", stage, progress, message, currentFile, speed, downloaded, total)
+		})
+		return err
 	})
 
 	if err != nil {
-		fmt.Printf("Download failed: %v\n", err)
+		updaterLogger.Error("Update download failed", "error", err)
 		return NetworkError("downloading launcher update", err)
 	}
 
-	fmt.Printf("Download complete: %s\n", tmp)
-
 	// Verify checksum if provided
 	if asset.Sha256 != "" {
-		fmt.Println("Verifying download checksum...")
+		updaterLogger.Debug("Verifying update checksum")
 		if err := util.VerifySHA256(tmp, asset.Sha256); err != nil {
-			fmt.Printf("Verification failed: %v\n", err)
+			updaterLogger.Error("Update verification failed", "error", err)
 			os.Remove(tmp)
 			return WrapError(ErrorTypeValidation, "Update file verification failed", err)
 		}
-		fmt.Println("Checksum verified successfully")
+		updaterLogger.Info("Update checksum verified")
 	} else {
-		fmt.Println("Warning: No checksum provided, skipping verification")
+		updaterLogger.Warn("No checksum provided, skipping verification")
 	}
 
-	fmt.Println("Applying update...")
+	updaterLogger.Info("Applying update")
 
 	if err := updater.Apply(tmp); err != nil {
-		fmt.Printf("Failed to start update helper: %v\n", err)
+		updaterLogger.Error("Failed to start update helper", "error", err)
 		return FileSystemError("starting updater", err)
 	}
 
-	fmt.Printf("Update helper started successfully, exiting launcher (updating to version %s)...\n", newVersion)
+	updaterLogger.Info("Update helper started, exiting launcher", "version", newVersion)
+	logging.Close()
 	os.Exit(0)
 	return nil
 }
 
 // checkUpdateSilently checks for updates without user interaction
 func (a *App) checkUpdateSilently() {
-	fmt.Println("Running silent update check...")
+	updaterLogger.Debug("Running silent update check")
 
 	asset, newVersion, err := updater.CheckUpdate(a.ctx, AppVersion)
 	if err != nil {
-		fmt.Printf("Silent update check failed (this is normal if offline): %v\n", err)
+		updaterLogger.Info("Silent update check failed (this is normal if offline)", "error", err)
 		return
 	}
 
 	if asset == nil {
-		fmt.Println("No update available (silent check)")
+		updaterLogger.Debug("No update available")
 		return
 	}
 
-	fmt.Printf("Update available: %s (notifying frontend)\n", newVersion)
+	updaterLogger.Info("Update available, notifying frontend", "version", newVersion)
 	runtime.EventsEmit(a.ctx, "update:available", asset)
 }
@@ -2,6 +2,11 @@ package app
 
 import (
 	"HyPrism/internal/env"
+	"HyPrism/internal/game"
+	"HyPrism/internal/pwr"
+	"HyPrism/internal/store"
+	"HyPrism/internal/tasks"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -75,18 +80,39 @@ func (a *App) RepairInstallation() error {
 		}
 	}
 
-	// Also clean PWR downloads to force re-download
-	cacheDir := env.GetCacheDir()
-	cacheEntries, _ := os.ReadDir(cacheDir)
-	for _, entry := range cacheEntries {
-		if strings.HasSuffix(entry.Name(), ".pwr") {
-			os.Remove(filepath.Join(cacheDir, entry.Name()))
-		}
+	// Drop cached patches that no longer match their recorded hash; intact ones are kept
+	if removed := pwr.VerifyCache(); removed > 0 {
+		logger.Info("Removed damaged patches from the cache", "count", removed)
 	}
 
 	return nil
 }
 
+// GetCacheUsage returns the patches kept in the download cache and the space they use
+func (a *App) GetCacheUsage() pwr.CacheUsage {
+	return pwr.GetCacheUsage()
+}
+
+// ClearCache deletes every cached patch and leftover partial download.
+// It runs in the install group, so it never removes a patch an install is downloading or applying.
+func (a *App) ClearCache() error {
+	err := a.runTask(tasks.Options{
+		Kind:     "cache",
+		Title:    "Clear download cache",
+		Priority: tasks.PriorityNormal,
+		Key:      "clear-cache",
+		Group:    installGroup,
+	}, func(ctx context.Context, progress tasks.ProgressFunc) error {
+		return pwr.ClearCache()
+	})
+	if err != nil {
+		wrappedErr := FileSystemError("clearing the download cache", err)
+		a.emitError(wrappedErr)
+		return wrappedErr
+	}
+	return nil
+}
+
 // DeleteGame deletes the game installation
 func (a *App) DeleteGame() error {
 	homeDir := env.GetDefaultAppDir()
@@ -130,3 +156,41 @@ func (a *App) GetPlatformInfo() map[string]string {
 		"arch": runtime.GOARCH,
 	}
 }
+
+// GetStoreUsage returns how much space the shared game file store uses and saves
+func (a *App) GetStoreUsage() store.Usage {
+	return store.GetUsage()
+}
+
+// CollectStoreGarbage removes stored game files that no instance uses anymore
+func (a *App) CollectStoreGarbage() (*store.GCResult, error) {
+	result, err := store.CollectGarbage()
+	if err != nil {
+		wrappedErr := FileSystemError("cleaning the game file store", err)
+		a.emitError(wrappedErr)
+		return nil, wrappedErr
+	}
+	return result, nil
+}
+
+// DeduplicateInstances shares identical game files between existing instances
+func (a *App) DeduplicateInstances() (*store.IngestResult, error) {
+	var result *store.IngestResult
+	err := a.runTask(tasks.Options{
+		Kind:     "dedupe",
+		Title:    "Share game files between instances",
+		Priority: tasks.PriorityLow,
+		Key:      "dedupe",
+		Group:    installGroup,
+	}, func(ctx context.Context, progress tasks.ProgressFunc) error {
+		var err error
+		result, err = game.DeduplicateInstances(ctx, progress)
+		return err
+	})
+	if err != nil {
+		wrappedErr := FileSystemError("sharing game files", err)
+		a.emitError(wrappedErr)
+		return nil, wrappedErr
+	}
+	return result, nil
+}
@@ -3,11 +3,24 @@
 import {mods} from '../models';
 import {updater} from '../models';
 import {app} from '../models';
+import {env} from '../models';
+import {store} from '../models';
+import {portable} from '../models';
+import {support} from '../models';
+import {pwr} from '../models';
 import {config} from '../models';
 import {news} from '../models';
+import {tasks} from '../models';
+import {game} from '../models';
+
+export function CancelTask(arg1:string):Promise<void>;
+
+export function ChangeInstanceDirectory(arg1:string,arg2:string):Promise<void>;
 
 export function CheckInstanceModUpdates(arg1:string,arg2:number):Promise<Array<mods.Mod>>;
 
+export function CheckInstanceModUpdatesByID(arg1:string):Promise<Array<mods.Mod>>;
+
 export function CheckLatestNeedsUpdate(arg1:string):Promise<boolean>;
 
 export function CheckModUpdates():Promise<Array<mods.Mod>>;
@@ -16,18 +29,40 @@ export function CheckUpdate():Promise<updater.Asset>;
 
 export function CheckVersionAvailability():Promise<app.VersionCheckInfo>;
 
+export function ClearCache():Promise<void>;
+
+export function ClearFinishedTasks():Promise<void>;
+
+export function CloneInstance(arg1:string,arg2:string):Promise<env.Instance>;
+
+export function CollectStoreGarbage():Promise<store.GCResult>;
+
+export function CreateInstance(arg1:string,arg2:string,arg3:number):Promise<env.Instance>;
+
+export function DeduplicateInstances():Promise<store.IngestResult>;
+
 export function DeleteGame():Promise<void>;
 
+export function DeleteInstance(arg1:string,arg2:boolean):Promise<string>;
+
 export function DownloadAndLaunch(arg1:string,arg2:boolean):Promise<void>;
 
 export function DownloadVersion(arg1:string,arg2:string):Promise<void>;
 
 export function ExitGame():Promise<void>;
 
+export function ExportInstance(arg1:string,arg2:string,arg3:portable.ExportOptions):Promise<string>;
+
+export function ExportSupportBundle():Promise<support.Result>;
+
 export function GetAutoUpdateLatest():Promise<boolean>;
 
 export function GetAvailableVersions():Promise<Record<string, number>>;
 
+export function GetBandwidthLimit():Promise<number>;
+
+export function GetCacheUsage():Promise<pwr.CacheUsage>;
+
 export function GetConfig():Promise<config.Config>;
 
 export function GetCrashReports():Promise<Array<app.CrashReport>>;
@@ -36,6 +71,8 @@ export function GetCurrentVersion():Promise<string>;
 
 export function GetCustomInstanceDir():Promise<string>;
 
+export function GetDownloadConnections():Promise<number>;
+
 export function GetGameLogs():Promise<string>;
 
 export function GetGamePath():Promise<string>;
@@ -46,11 +83,25 @@ export function GetInstalledVersions():Promise<Array<app.InstalledVersion>>;
 
 export function GetInstalledVersionsForBranch(arg1:string):Promise<Array<number>>;
 
+export function GetInstance(arg1:string):Promise<env.Instance>;
+
 export function GetInstanceInstalledMods(arg1:string,arg2:number):Promise<Array<mods.Mod>>;
 
+export function GetInstanceModsByID(arg1:string):Promise<Array<mods.Mod>>;
+
+export function GetInstanceSizes():Promise<Array<env.InstanceSize>>;
+
+export function GetKeptGenerations():Promise<number>;
+
 export function GetLauncherVersion():Promise<string>;
 
-export function GetLogs():Promise<string>;
+export function GetLogFormat():Promise<string>;
+
+export function GetLogLevels():Promise<Record<string, string>>;
+
+export function GetLogLimit():Promise<number>;
+
+export function GetLogs(arg1:string,arg2:string):Promise<string>;
 
 export function GetModCategories():Promise<Array<mods.ModCategory>>;
 
@@ -64,42 +115,100 @@ export function GetNews(arg1:number):Promise<Array<news.NewsItem>>;
 
 export function GetNick():Promise<string>;
 
+export function GetPatchCacheLimit():Promise<number>;
+
+export function GetPatchMirrorStatus():Promise<Array<pwr.MirrorStatus>>;
+
+export function GetPatchMirrors():Promise<Array<string>>;
+
 export function GetPlatformInfo():Promise<Record<string, string>>;
 
 export function GetSelectedVersion():Promise<number>;
 
+export function GetSessionLogLimit():Promise<number>;
+
+export function GetSessionLogRetention():Promise<number>;
+
+export function GetSessionLogs(arg1:string,arg2:string):Promise<string>;
+
+export function GetStoreUsage():Promise<store.Usage>;
+
+export function GetTask(arg1:string):Promise<tasks.Job>;
+
+export function GetVersionIndex(arg1:string):Promise<pwr.BranchIndex>;
+
 export function GetVersionList(arg1:string):Promise<Array<number>>;
 
 export function GetVersionType():Promise<string>;
 
 export function GetVersions():Promise<string|string>;
 
+export function HealInstance(arg1:string,arg2:number):Promise<pwr.HealResult>;
+
+export function ImportInstance(arg1:string):Promise<env.Instance>;
+
 export function InstallMod(arg1:number):Promise<void>;
 
 export function InstallModFile(arg1:number,arg2:number):Promise<void>;
 
 export function InstallModFileToInstance(arg1:number,arg2:number,arg3:string,arg4:number):Promise<void>;
 
+export function InstallModFileToInstanceByID(arg1:number,arg2:number,arg3:string):Promise<void>;
+
 export function InstallModToInstance(arg1:number,arg2:string,arg3:number):Promise<void>;
 
+export function InstallModToInstanceByID(arg1:number,arg2:string):Promise<void>;
+
 export function IsGameInstalled():Promise<boolean>;
 
 export function IsGameRunning():Promise<boolean>;
 
 export function IsVersionInstalled(arg1:string,arg2:number):Promise<boolean>;
 
+export function KillSession(arg1:string):Promise<void>;
+
+export function LaunchInstanceByID(arg1:string,arg2:string,arg3:boolean):Promise<void>;
+
+export function ListInstanceGenerations(arg1:string,arg2:number):Promise<Array<game.Generation>>;
+
+export function ListInstances():Promise<Array<env.Instance>>;
+
+export function ListRunningSessions():Promise<Array<game.Process>>;
+
+export function ListSessions(arg1:string):Promise<Array<game.Session>>;
+
+export function ListTasks():Promise<Array<tasks.Job>>;
+
+export function MoveInstance(arg1:string,arg2:string):Promise<env.Instance>;
+
 export function OpenFolder():Promise<void>;
 
 export function OpenGameFolder():Promise<void>;
 
 export function OpenInstanceModsFolder(arg1:string,arg2:number):Promise<void>;
 
+export function OpenInstanceModsFolderByID(arg1:string):Promise<void>;
+
 export function OpenModsFolder():Promise<void>;
 
+export function PauseTask(arg1:string):Promise<void>;
+
+export function PlanInstanceDirectoryChange(arg1:string,arg2:string):Promise<env.RelocationPlan>;
+
+export function PreviewLaunchCommand(arg1:string,arg2:boolean):Promise<game.LaunchCommand>;
+
 export function QuickLaunch():Promise<void>;
 
+export function RefreshVersionIndex(arg1:string):Promise<void>;
+
+export function RenameInstance(arg1:string,arg2:string):Promise<env.Instance>;
+
 export function RepairInstallation():Promise<void>;
 
+export function ResumeTask(arg1:string):Promise<void>;
+
+export function RollbackInstance(arg1:string,arg2:number,arg3:number):Promise<number>;
+
 export function RunDiagnostics():Promise<app.DiagnosticReport>;
 
 export function SaveConfig():Promise<void>;
@@ -112,24 +221,56 @@ export function SelectInstanceDirectory():Promise<string>;
 
 export function SetAutoUpdateLatest(arg1:boolean):Promise<void>;
 
+export function SetBandwidthLimit(arg1:number):Promise<void>;
+
 export function SetCustomInstanceDir(arg1:string):Promise<void>;
 
+export function SetDownloadConnections(arg1:number):Promise<void>;
+
+export function SetInstanceLaunchSettings(arg1:string,arg2:env.LaunchSettings):Promise<env.Instance>;
+
+export function SetKeptGenerations(arg1:number):Promise<void>;
+
+export function SetLogFormat(arg1:string):Promise<void>;
+
+export function SetLogLevel(arg1:string,arg2:string):Promise<void>;
+
+export function SetLogLimit(arg1:number):Promise<void>;
+
 export function SetMusicEnabled(arg1:boolean):Promise<void>;
 
 export function SetNick(arg1:string):Promise<void>;
 
+export function SetPatchCacheLimit(arg1:number):Promise<void>;
+
+export function SetPatchMirrors(arg1:Array<string>):Promise<void>;
+
 export function SetSelectedVersion(arg1:number):Promise<void>;
 
+export function SetSessionLogLimit(arg1:number):Promise<void>;
+
+export function SetSessionLogRetention(arg1:number):Promise<void>;
+
+export function SetTaskPriority(arg1:string,arg2:number):Promise<void>;
+
 export function SetVersionType(arg1:string):Promise<void>;
 
 export function SwitchVersion(arg1:number):Promise<void>;
 
 export function ToggleInstanceMod(arg1:string,arg2:boolean,arg3:string,arg4:number):Promise<void>;
 
+export function ToggleInstanceModByID(arg1:string,arg2:boolean,arg3:string):Promise<void>;
+
 export function ToggleMod(arg1:string,arg2:boolean):Promise<void>;
 
 export function UninstallInstanceMod(arg1:string,arg2:string,arg3:number):Promise<void>;
 
+export function UninstallInstanceModByID(arg1:string,arg2:string):Promise<void>;
+
 export function UninstallMod(arg1:string):Promise<void>;
 
 export function Update():Promise<void>;
+
+export function UpdateInstanceInfo(arg1:string,arg2:string,arg3:string,arg4:string):Promise<env.Instance>;
+
+export function VerifyInstance(arg1:string,arg2:number):Promise<pwr.VerifyResult>;
@@ -2,10 +2,22 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function CancelTask(arg1) {
+  return window['go']['app']['App']['CancelTask'](arg1);
+}
+
+export function ChangeInstanceDirectory(arg1, arg2) {
+  return window['go']['app']['App']['ChangeInstanceDirectory'](arg1, arg2);
+}
+
 export function CheckInstanceModUpdates(arg1, arg2) {
   return window['go']['app']['App']['CheckInstanceModUpdates'](arg1, arg2);
 }
 
+export function CheckInstanceModUpdatesByID(arg1) {
+  return window['go']['app']['App']['CheckInstanceModUpdatesByID'](arg1);
+}
+
 export function CheckLatestNeedsUpdate(arg1) {
   return window['go']['app']['App']['CheckLatestNeedsUpdate'](arg1);
 }
@@ -22,10 +34,38 @@ export function CheckVersionAvailability() {
   return window['go']['app']['App']['CheckVersionAvailability']();
 }
 
+export function ClearCache() {
+  return window['go']['app']['App']['ClearCache']();
+}
+
+export function ClearFinishedTasks() {
+  return window['go']['app']['App']['ClearFinishedTasks']();
+}
+
+export function CloneInstance(arg1, arg2) {
+  return window['go']['app']['App']['CloneInstance'](arg1, arg2);
+}
+
+export function CollectStoreGarbage() {
+  return window['go']['app']['App']['CollectStoreGarbage']();
+}
+
+export function CreateInstance(arg1, arg2, arg3) {
+  return window['go']['app']['App']['CreateInstance'](arg1, arg2, arg3);
+}
+
+export function DeduplicateInstances() {
+  return window['go']['app']['App']['DeduplicateInstances']();
+}
+
 export function DeleteGame() {
   return window['go']['app']['App']['DeleteGame']();
 }
 
+export function DeleteInstance(arg1, arg2) {
+  return window['go']['app']['App']['DeleteInstance'](arg1, arg2);
+}
+
 export function DownloadAndLaunch(arg1, arg2) {
   return window['go']['app']['App']['DownloadAndLaunch'](arg1, arg2);
 }
@@ -38,6 +78,14 @@ export function ExitGame() {
   return window['go']['app']['App']['ExitGame']();
 }
 
+export function ExportInstance(arg1, arg2, arg3) {
+  return window['go']['app']['App']['ExportInstance'](arg1, arg2, arg3);
+}
+
+export function ExportSupportBundle() {
+  return window['go']['app']['App']['ExportSupportBundle']();
+}
+
 export function GetAutoUpdateLatest() {
   return window['go']['app']['App']['GetAutoUpdateLatest']();
 }
@@ -46,6 +94,14 @@ export function GetAvailableVersions() {
   return window['go']['app']['App']['GetAvailableVersions']();
 }
 
+export function GetBandwidthLimit() {
+  return window['go']['app']['App']['GetBandwidthLimit']();
+}
+
+export function GetCacheUsage() {
+  return window['go']['app']['App']['GetCacheUsage']();
+}
+
 export function GetConfig() {
   return window['go']['app']['App']['GetConfig']();
 }
@@ -62,6 +118,10 @@ export function GetCustomInstanceDir() {
   return window['go']['app']['App']['GetCustomInstanceDir']();
 }
 
+export function GetDownloadConnections() {
+  return window['go']['app']['App']['GetDownloadConnections']();
+}
+
 export function GetGameLogs() {
   return window['go']['app']['App']['GetGameLogs']();
 }
@@ -82,16 +142,44 @@ export function GetInstalledVersionsForBranch(arg1) {
   return window['go']['app']['App']['GetInstalledVersionsForBranch'](arg1);
 }
 
+export function GetInstance(arg1) {
+  return window['go']['app']['App']['GetInstance'](arg1);
+}
+
 export function GetInstanceInstalledMods(arg1, arg2) {
   return window['go']['app']['App']['GetInstanceInstalledMods'](arg1, arg2);
 }
 
+export function GetInstanceModsByID(arg1) {
+  return window['go']['app']['App']['GetInstanceModsByID'](arg1);
+}
+
+export function GetInstanceSizes() {
+  return window['go']['app']['App']['GetInstanceSizes']();
+}
+
+export function GetKeptGenerations() {
+  return window['go']['app']['App']['GetKeptGenerations']();
+}
+
 export function GetLauncherVersion() {
   return window['go']['app']['App']['GetLauncherVersion']();
 }
 
-export function GetLogs() {
-  return window['go']['app']['App']['GetLogs']();
+export function GetLogFormat() {
+  return window['go']['app']['App']['GetLogFormat']();
+}
+
+export function GetLogLevels() {
+  return window['go']['app']['App']['GetLogLevels']();
+}
+
+export function GetLogLimit() {
+  return window['go']['app']['App']['GetLogLimit']();
+}
+
+export function GetLogs(arg1, arg2) {
+  return window['go']['app']['App']['GetLogs'](arg1, arg2);
 }
 
 export function GetModCategories() {
@@ -118,6 +206,18 @@ export function GetNick() {
   return window['go']['app']['App']['GetNick']();
 }
 
+export function GetPatchCacheLimit() {
+  return window['go']['app']['App']['GetPatchCacheLimit']();
+}
+
+export function GetPatchMirrorStatus() {
+  return window['go']['app']['App']['GetPatchMirrorStatus']();
+}
+
+export function GetPatchMirrors() {
+  return window['go']['app']['App']['GetPatchMirrors']();
+}
+
 export function GetPlatformInfo() {
   return window['go']['app']['App']['GetPlatformInfo']();
 }
@@ -126,6 +226,30 @@ export function GetSelectedVersion() {
   return window['go']['app']['App']['GetSelectedVersion']();
 }
 
+export function GetSessionLogLimit() {
+  return window['go']['app']['App']['GetSessionLogLimit']();
+}
+
+export function GetSessionLogRetention() {
+  return window['go']['app']['App']['GetSessionLogRetention']();
+}
+
+export function GetSessionLogs(arg1, arg2) {
+  return window['go']['app']['App']['GetSessionLogs'](arg1, arg2);
+}
+
+export function GetStoreUsage() {
+  return window['go']['app']['App']['GetStoreUsage']();
+}
+
+export function GetTask(arg1) {
+  return window['go']['app']['App']['GetTask'](arg1);
+}
+
+export function GetVersionIndex(arg1) {
+  return window['go']['app']['App']['GetVersionIndex'](arg1);
+}
+
 export function GetVersionList(arg1) {
   return window['go']['app']['App']['GetVersionList'](arg1);
 }
@@ -138,6 +262,14 @@ export function GetVersions() {
   return window['go']['app']['App']['GetVersions']();
 }
 
+export function HealInstance(arg1, arg2) {
+  return window['go']['app']['App']['HealInstance'](arg1, arg2);
+}
+
+export function ImportInstance(arg1) {
+  return window['go']['app']['App']['ImportInstance'](arg1);
+}
+
 export function InstallMod(arg1) {
   return window['go']['app']['App']['InstallMod'](arg1);
 }
@@ -150,10 +282,18 @@ export function InstallModFileToInstance(arg1, arg2, arg3, arg4) {
   return window['go']['app']['App']['InstallModFileToInstance'](arg1, arg2, arg3, arg4);
 }
 
+export function InstallModFileToInstanceByID(arg1, arg2, arg3) {
+  return window['go']['app']['App']['InstallModFileToInstanceByID'](arg1, arg2, arg3);
+}
+
 export function InstallModToInstance(arg1, arg2, arg3) {
   return window['go']['app']['App']['InstallModToInstance'](arg1, arg2, arg3);
 }
 
+export function InstallModToInstanceByID(arg1, arg2) {
+  return window['go']['app']['App']['InstallModToInstanceByID'](arg1, arg2);
+}
+
 export function IsGameInstalled() {
   return window['go']['app']['App']['IsGameInstalled']();
 }
@@ -166,6 +306,38 @@ export function IsVersionInstalled(arg1, arg2) {
   return window['go']['app']['App']['IsVersionInstalled'](arg1, arg2);
 }
 
+export function KillSession(arg1) {
+  return window['go']['app']['App']['KillSession'](arg1);
+}
+
+export function LaunchInstanceByID(arg1, arg2, arg3) {
+  return window['go']['app']['App']['LaunchInstanceByID'](arg1, arg2, arg3);
+}
+
+export function ListInstanceGenerations(arg1, arg2) {
+  return window['go']['app']['App']['ListInstanceGenerations'](arg1, arg2);
+}
+
+export function ListInstances() {
+  return window['go']['app']['App']['ListInstances']();
+}
+
+export function ListRunningSessions() {
+  return window['go']['app']['App']['ListRunningSessions']();
+}
+
+export function ListSessions(arg1) {
+  return window['go']['app']['App']['ListSessions'](arg1);
+}
+
+export function ListTasks() {
+  return window['go']['app']['App']['ListTasks']();
+}
+
+export function MoveInstance(arg1, arg2) {
+  return window['go']['app']['App']['MoveInstance'](arg1, arg2);
+}
+
 export function OpenFolder() {
   return window['go']['app']['App']['OpenFolder']();
 }
@@ -178,18 +350,50 @@ export function OpenInstanceModsFolder(arg1, arg2) {
   return window['go']['app']['App']['OpenInstanceModsFolder'](arg1, arg2);
 }
 
+export function OpenInstanceModsFolderByID(arg1) {
+  return window['go']['app']['App']['OpenInstanceModsFolderByID'](arg1);
+}
+
 export function OpenModsFolder() {
   return window['go']['app']['App']['OpenModsFolder']();
 }
 
+export function PauseTask(arg1) {
+  return window['go']['app']['App']['PauseTask'](arg1);
+}
+
+export function PlanInstanceDirectoryChange(arg1, arg2) {
+  return window['go']['app']['App']['PlanInstanceDirectoryChange'](arg1, arg2);
+}
+
+export function PreviewLaunchCommand(arg1, arg2) {
+  return window['go']['app']['App']['PreviewLaunchCommand'](arg1, arg2);
+}
+
 export function QuickLaunch() {
   return window['go']['app']['App']['QuickLaunch']();
 }
 
+export function RefreshVersionIndex(arg1) {
+  return window['go']['app']['App']['RefreshVersionIndex'](arg1);
+}
+
+export function RenameInstance(arg1, arg2) {
+  return window['go']['app']['App']['RenameInstance'](arg1, arg2);
+}
+
 export function RepairInstallation() {
   return window['go']['app']['App']['RepairInstallation']();
 }
 
+export function ResumeTask(arg1) {
+  return window['go']['app']['App']['ResumeTask'](arg1);
+}
+
+export function RollbackInstance(arg1, arg2, arg3) {
+  return window['go']['app']['App']['RollbackInstance'](arg1, arg2, arg3);
+}
+
 export function RunDiagnostics() {
   return window['go']['app']['App']['RunDiagnostics']();
 }
@@ -214,10 +418,38 @@ export function SetAutoUpdateLatest(arg1) {
   return window['go']['app']['App']['SetAutoUpdateLatest'](arg1);
 }
 
+export function SetBandwidthLimit(arg1) {
+  return window['go']['app']['App']['SetBandwidthLimit'](arg1);
+}
+
 export function SetCustomInstanceDir(arg1) {
   return window['go']['app']['App']['SetCustomInstanceDir'](arg1);
 }
 
+export function SetDownloadConnections(arg1) {
+  return window['go']['app']['App']['SetDownloadConnections'](arg1);
+}
+
+export function SetInstanceLaunchSettings(arg1, arg2) {
+  return window['go']['app']['App']['SetInstanceLaunchSettings'](arg1, arg2);
+}
+
+export function SetKeptGenerations(arg1) {
+  return window['go']['app']['App']['SetKeptGenerations'](arg1);
+}
+
+export function SetLogFormat(arg1) {
+  return window['go']['app']['App']['SetLogFormat'](arg1);
+}
+
+export function SetLogLevel(arg1, arg2) {
+  return window['go']['app']['App']['SetLogLevel'](arg1, arg2);
+}
+
+export function SetLogLimit(arg1) {
+  return window['go']['app']['App']['SetLogLimit'](arg1);
+}
+
 export function SetMusicEnabled(arg1) {
   return window['go']['app']['App']['SetMusicEnabled'](arg1);
 }
@@ -226,10 +458,30 @@ export function SetNick(arg1) {
   return window['go']['app']['App']['SetNick'](arg1);
 }
 
+export function SetPatchCacheLimit(arg1) {
+  return window['go']['app']['App']['SetPatchCacheLimit'](arg1);
+}
+
+export function SetPatchMirrors(arg1) {
+  return window['go']['app']['App']['SetPatchMirrors'](arg1);
+}
+
 export function SetSelectedVersion(arg1) {
   return window['go']['app']['App']['SetSelectedVersion'](arg1);
 }
 
+export function SetSessionLogLimit(arg1) {
+  return window['go']['app']['App']['SetSessionLogLimit'](arg1);
+}
+
+export function SetSessionLogRetention(arg1) {
+  return window['go']['app']['App']['SetSessionLogRetention'](arg1);
+}
+
+export function SetTaskPriority(arg1, arg2) {
+  return window['go']['app']['App']['SetTaskPriority'](arg1, arg2);
+}
+
 export function SetVersionType(arg1) {
   return window['go']['app']['App']['SetVersionType'](arg1);
 }
@@ -242,6 +494,10 @@ export function ToggleInstanceMod(arg1, arg2, arg3, arg4) {
   return window['go']['app']['App']['ToggleInstanceMod'](arg1, arg2, arg3, arg4);
 }
 
+export function ToggleInstanceModByID(arg1, arg2, arg3) {
+  return window['go']['app']['App']['ToggleInstanceModByID'](arg1, arg2, arg3);
+}
+
 export function ToggleMod(arg1, arg2) {
   return window['go']['app']['App']['ToggleMod'](arg1, arg2);
 }
@@ -250,6 +506,10 @@ export function UninstallInstanceMod(arg1, arg2, arg3) {
   return window['go']['app']['App']['UninstallInstanceMod'](arg1, arg2, arg3);
 }
 
+export function UninstallInstanceModByID(arg1, arg2) {
+  return window['go']['app']['App']['UninstallInstanceModByID'](arg1, arg2);
+}
+
 export function UninstallMod(arg1) {
   return window['go']['app']['App']['UninstallMod'](arg1);
 }
@@ -257,3 +517,11 @@ export function UninstallMod(arg1) {
 export function Update() {
   return window['go']['app']['App']['Update']();
 }
+
+export function UpdateInstanceInfo(arg1, arg2, arg3, arg4) {
+  return window['go']['app']['App']['UpdateInstanceInfo'](arg1, arg2, arg3, arg4);
+}
+
+export function VerifyInstance(arg1, arg2) {
+  return window['go']['app']['App']['VerifyInstance'](arg1, arg2);
+}
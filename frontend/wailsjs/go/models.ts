@@ -37,8 +37,12 @@ export namespace app {
 	export class DependenciesInfo {
 	    javaInstalled: boolean;
 	    javaPath: string;
+	    javaVendor: string;
+	    javaVersion: string;
+	    javaArch: string;
 	    butlerInstalled: boolean;
 	    butlerPath: string;
+	    butlerVersion: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new DependenciesInfo(source);
@@ -48,11 +52,16 @@ export namespace app {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.javaInstalled = source["javaInstalled"];
 	        this.javaPath = source["javaPath"];
+	        this.javaVendor = source["javaVendor"];
+	        this.javaVersion = source["javaVersion"];
+	        this.javaArch = source["javaArch"];
 	        this.butlerInstalled = source["butlerInstalled"];
 	        this.butlerPath = source["butlerPath"];
+	        this.butlerVersion = source["butlerVersion"];
 	    }
 	}
 	export class GameStatusInfo {
+	    instance: string;
 	    installed: boolean;
 	    version: string;
 	    clientExists: boolean;
@@ -64,6 +73,7 @@ export namespace app {
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.instance = source["instance"];
 	        this.installed = source["installed"];
 	        this.version = source["version"];
 	        this.clientExists = source["clientExists"];
@@ -91,6 +101,9 @@ export namespace app {
 	    connectivity: ConnectivityInfo;
 	    gameStatus: GameStatusInfo;
 	    dependencies: DependenciesInfo;
+	    checks: diagnostics.Check[];
+	    instances: diagnostics.InstanceStatus[];
+	    status: string;
 	    timestamp: string;
 	
 	    static createFrom(source: any = {}) {
@@ -103,6 +116,9 @@ export namespace app {
 	        this.connectivity = this.convertValues(source["connectivity"], ConnectivityInfo);
 	        this.gameStatus = this.convertValues(source["gameStatus"], GameStatusInfo);
 	        this.dependencies = this.convertValues(source["dependencies"], DependenciesInfo);
+	        this.checks = this.convertValues(source["checks"], diagnostics.Check);
+	        this.instances = this.convertValues(source["instances"], diagnostics.InstanceStatus);
+	        this.status = source["status"];
 	        this.timestamp = source["timestamp"];
 	    }
 	
@@ -169,6 +185,17 @@ export namespace config {
 	    selectedVersion: number;
 	    customInstanceDir: string;
 	    autoUpdateLatest: boolean;
+	    keptGenerations: number;
+	    downloadConnections: number;
+	    bandwidthLimitKB: number;
+	    patchMirrors: string[];
+	    patchCacheLimitMB: number;
+	    sessionLogLimitMB: number;
+	    sessionLogRetention: number;
+	    logFormat: string;
+	    logLevel: string;
+	    logLevels: Record<string, string>;
+	    logLimitMB: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new Config(source);
@@ -183,6 +210,348 @@ export namespace config {
 	        this.selectedVersion = source["selectedVersion"];
 	        this.customInstanceDir = source["customInstanceDir"];
 	        this.autoUpdateLatest = source["autoUpdateLatest"];
+	        this.keptGenerations = source["keptGenerations"];
+	        this.downloadConnections = source["downloadConnections"];
+	        this.bandwidthLimitKB = source["bandwidthLimitKB"];
+	        this.patchMirrors = source["patchMirrors"];
+	        this.patchCacheLimitMB = source["patchCacheLimitMB"];
+	        this.sessionLogLimitMB = source["sessionLogLimitMB"];
+	        this.sessionLogRetention = source["sessionLogRetention"];
+	        this.logFormat = source["logFormat"];
+	        this.logLevel = source["logLevel"];
+	        this.logLevels = source["logLevels"];
+	        this.logLimitMB = source["logLimitMB"];
+	    }
+	}
+
+}
+
+export namespace diagnostics {
+	
+	export class Check {
+	    id: string;
+	    category: string;
+	    title: string;
+	    status: string;
+	    detail: string;
+	    fix?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Check(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.category = source["category"];
+	        this.title = source["title"];
+	        this.status = source["status"];
+	        this.detail = source["detail"];
+	        this.fix = source["fix"];
+	    }
+	}
+	export class InstanceStatus {
+	    id: string;
+	    name: string;
+	    branch: string;
+	    installedBuild: number;
+	    gameFrom?: string;
+	    clientPath: string;
+	    clientExists: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new InstanceStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.branch = source["branch"];
+	        this.installedBuild = source["installedBuild"];
+	        this.gameFrom = source["gameFrom"];
+	        this.clientPath = source["clientPath"];
+	        this.clientExists = source["clientExists"];
+	    }
+	}
+
+}
+
+export namespace env {
+	
+	export class LaunchWrapper {
+	    command: string;
+	    args?: string[];
+	    disabled?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new LaunchWrapper(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.command = source["command"];
+	        this.args = source["args"];
+	        this.disabled = source["disabled"];
+	    }
+	}
+	export class LaunchSettings {
+	    extraArgs?: string[];
+	    env?: Record<string, string>;
+	    workingDir?: string;
+	    javaOptions?: string[];
+	    minHeapMB?: number;
+	    maxHeapMB?: number;
+	    wrappers?: LaunchWrapper[];
+	    allowConcurrent?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new LaunchSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.extraArgs = source["extraArgs"];
+	        this.env = source["env"];
+	        this.workingDir = source["workingDir"];
+	        this.javaOptions = source["javaOptions"];
+	        this.minHeapMB = source["minHeapMB"];
+	        this.maxHeapMB = source["maxHeapMB"];
+	        this.wrappers = this.convertValues(source["wrappers"], LaunchWrapper);
+	        this.allowConcurrent = source["allowConcurrent"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Instance {
+	    id: string;
+	    name: string;
+	    branch: string;
+	    version: number;
+	    autoUpdate: boolean;
+	    installedBuild: number;
+	    skipBuild?: number;
+	    gameFrom?: string;
+	    location?: string;
+	    icon?: string;
+	    notes?: string;
+	    createdAt: string;
+	    lastPlayedAt?: string;
+	    launch: LaunchSettings;
+	
+	    static createFrom(source: any = {}) {
+	        return new Instance(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.branch = source["branch"];
+	        this.version = source["version"];
+	        this.autoUpdate = source["autoUpdate"];
+	        this.installedBuild = source["installedBuild"];
+	        this.skipBuild = source["skipBuild"];
+	        this.gameFrom = source["gameFrom"];
+	        this.location = source["location"];
+	        this.icon = source["icon"];
+	        this.notes = source["notes"];
+	        this.createdAt = source["createdAt"];
+	        this.lastPlayedAt = source["lastPlayedAt"];
+	        this.launch = this.convertValues(source["launch"], LaunchSettings);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class InstanceSize {
+	    id: string;
+	    game: number;
+	    generations: number;
+	    userData: number;
+	    mods: number;
+	    saves: number;
+	    total: number;
+	    sharedGame: boolean;
+	    computedAt: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new InstanceSize(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.game = source["game"];
+	        this.generations = source["generations"];
+	        this.userData = source["userData"];
+	        this.mods = source["mods"];
+	        this.saves = source["saves"];
+	        this.total = source["total"];
+	        this.sharedGame = source["sharedGame"];
+	        this.computedAt = source["computedAt"];
+	    }
+	}
+	
+	
+	export class RelocationPlan {
+	    from: string;
+	    to: string;
+	    mode: string;
+	    instances: string[];
+	    bytes: number;
+	    neededBytes: number;
+	    freeBytes: number;
+	    sameVolume: boolean;
+	    conflicts?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new RelocationPlan(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.mode = source["mode"];
+	        this.instances = source["instances"];
+	        this.bytes = source["bytes"];
+	        this.neededBytes = source["neededBytes"];
+	        this.freeBytes = source["freeBytes"];
+	        this.sameVolume = source["sameVolume"];
+	        this.conflicts = source["conflicts"];
+	    }
+	}
+
+}
+
+export namespace game {
+	
+	export class Generation {
+	    build: number;
+	    archivedAt: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Generation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.build = source["build"];
+	        this.archivedAt = source["archivedAt"];
+	    }
+	}
+	export class LaunchCommand {
+	    path: string;
+	    args: string[];
+	    env: string[];
+	    dir: string;
+	    line: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new LaunchCommand(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.args = source["args"];
+	        this.env = source["env"];
+	        this.dir = source["dir"];
+	        this.line = source["line"];
+	    }
+	}
+	export class Process {
+	    id: string;
+	    instanceId: string;
+	    pid: number;
+	    clientPid?: number;
+	    command: string;
+	    startedAt: string;
+	    running: boolean;
+	    exitCode: number;
+	    signal?: string;
+	    error?: string;
+	    exitedAt?: string;
+	    durationMs: number;
+	    stopped?: boolean;
+	    logPath?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Process(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.instanceId = source["instanceId"];
+	        this.pid = source["pid"];
+	        this.clientPid = source["clientPid"];
+	        this.command = source["command"];
+	        this.startedAt = source["startedAt"];
+	        this.running = source["running"];
+	        this.exitCode = source["exitCode"];
+	        this.signal = source["signal"];
+	        this.error = source["error"];
+	        this.exitedAt = source["exitedAt"];
+	        this.durationMs = source["durationMs"];
+	        this.stopped = source["stopped"];
+	        this.logPath = source["logPath"];
+	    }
+	}
+	export class Session {
+	    id: string;
+	    instanceId: string;
+	    startedAt: string;
+	    updatedAt: string;
+	    size: number;
+	    parts: number;
+	    running: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Session(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.instanceId = source["instanceId"];
+	        this.startedAt = source["startedAt"];
+	        this.updatedAt = source["updatedAt"];
+	        this.size = source["size"];
+	        this.parts = source["parts"];
+	        this.running = source["running"];
 	    }
 	}
 
@@ -511,6 +880,345 @@ export namespace news {
 
 }
 
+export namespace portable {
+	
+	export class ExportOptions {
+	    format: string;
+	    settings: boolean;
+	    worlds: boolean;
+	    mods: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExportOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.format = source["format"];
+	        this.settings = source["settings"];
+	        this.worlds = source["worlds"];
+	        this.mods = source["mods"];
+	    }
+	}
+
+}
+
+export namespace pwr {
+	
+	export class BuildInfo {
+	    version: number;
+	    size: number;
+	    hasPatch: boolean;
+	    patchSize?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BuildInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.size = source["size"];
+	        this.hasPatch = source["hasPatch"];
+	        this.patchSize = source["patchSize"];
+	    }
+	}
+	export class BranchIndex {
+	    latest: number;
+	    builds: BuildInfo[];
+	    // Go type: time
+	    checkedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new BranchIndex(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.latest = source["latest"];
+	        this.builds = this.convertValues(source["builds"], BuildInfo);
+	        this.checkedAt = this.convertValues(source["checkedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class CacheEntry {
+	    key: string;
+	    url: string;
+	    size: number;
+	    sha256: string;
+	    // Go type: time
+	    addedAt: any;
+	    // Go type: time
+	    lastUsed: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new CacheEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.url = source["url"];
+	        this.size = source["size"];
+	        this.sha256 = source["sha256"];
+	        this.addedAt = this.convertValues(source["addedAt"], null);
+	        this.lastUsed = this.convertValues(source["lastUsed"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CacheUsage {
+	    entries: CacheEntry[];
+	    totalSize: number;
+	    budget: number;
+	    dir: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CacheUsage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.entries = this.convertValues(source["entries"], CacheEntry);
+	        this.totalSize = source["totalSize"];
+	        this.budget = source["budget"];
+	        this.dir = source["dir"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HealResult {
+	    build: number;
+	    repaired: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new HealResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.build = source["build"];
+	        this.repaired = source["repaired"];
+	    }
+	}
+	export class MirrorStatus {
+	    url: string;
+	    healthy: boolean;
+	    failures: number;
+	    successes: number;
+	    lastError?: string;
+	    demotedUntil?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new MirrorStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.url = source["url"];
+	        this.healthy = source["healthy"];
+	        this.failures = source["failures"];
+	        this.successes = source["successes"];
+	        this.lastError = source["lastError"];
+	        this.demotedUntil = source["demotedUntil"];
+	    }
+	}
+	export class VerifyResult {
+	    build: number;
+	    intact: boolean;
+	    wounded: string[];
+	    summary: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new VerifyResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.build = source["build"];
+	        this.intact = source["intact"];
+	        this.wounded = source["wounded"];
+	        this.summary = source["summary"];
+	    }
+	}
+
+}
+
+export namespace store {
+	
+	export class GCResult {
+	    removed: number;
+	    freedBytes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new GCResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.removed = source["removed"];
+	        this.freedBytes = source["freedBytes"];
+	    }
+	}
+	export class IngestResult {
+	    files: number;
+	    linked: number;
+	    added: number;
+	    savedBytes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new IngestResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.files = source["files"];
+	        this.linked = source["linked"];
+	        this.added = source["added"];
+	        this.savedBytes = source["savedBytes"];
+	    }
+	}
+	export class Usage {
+	    blobs: number;
+	    size: number;
+	    savedBytes: number;
+	    dir: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Usage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.blobs = source["blobs"];
+	        this.size = source["size"];
+	        this.savedBytes = source["savedBytes"];
+	        this.dir = source["dir"];
+	    }
+	}
+
+}
+
+export namespace support {
+	
+	export class Result {
+	    path: string;
+	    size: number;
+	    files: number;
+	    skipped?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Result(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.size = source["size"];
+	        this.files = source["files"];
+	        this.skipped = source["skipped"];
+	    }
+	}
+
+}
+
+export namespace tasks {
+	
+	export class Job {
+	    id: string;
+	    kind: string;
+	    title: string;
+	    priority: number;
+	    state: string;
+	    stage: string;
+	    progress: number;
+	    message: string;
+	    currentFile: string;
+	    speed: string;
+	    downloaded: number;
+	    total: number;
+	    error?: string;
+	    createdAt: string;
+	    startedAt?: string;
+	    finishedAt?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Job(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.kind = source["kind"];
+	        this.title = source["title"];
+	        this.priority = source["priority"];
+	        this.state = source["state"];
+	        this.stage = source["stage"];
+	        this.progress = source["progress"];
+	        this.message = source["message"];
+	        this.currentFile = source["currentFile"];
+	        this.speed = source["speed"];
+	        this.downloaded = source["downloaded"];
+	        this.total = source["total"];
+	        this.error = source["error"];
+	        this.createdAt = source["createdAt"];
+	        this.startedAt = source["startedAt"];
+	        this.finishedAt = source["finishedAt"];
+	    }
+	}
+
+}
+
 export namespace updater {
 	
 	export class Asset {
@@ -42,10 +42,11 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
-	var cfg Config
-	if err := toml.Unmarshal(data, &cfg); err != nil {
+	// Start from defaults so settings missing from older config files keep sane values
+	cfg := Default()
+	if err := toml.Unmarshal(data, cfg); err != nil {
 		return nil, err
 	}
 
-	return &cfg, nil
+	return cfg, nil
 }
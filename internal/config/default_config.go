@@ -2,24 +2,49 @@ package config
 
 // Config represents the launcher configuration
 type Config struct {
-	Version           string `toml:"version" json:"version"`
-	Nick              string `toml:"nick" json:"nick"`
-	MusicEnabled      bool   `toml:"music_enabled" json:"musicEnabled"`
-	VersionType       string `toml:"version_type" json:"versionType"`
-	SelectedVersion   int    `toml:"selected_version" json:"selectedVersion"`
-	CustomInstanceDir string `toml:"custom_instance_dir" json:"customInstanceDir"` // Custom path for instances
-	AutoUpdateLatest  bool   `toml:"auto_update_latest" json:"autoUpdateLatest"`   // Auto-update latest instance
+	Version             string            `toml:"version" json:"version"`
+	Nick                string            `toml:"nick" json:"nick"`
+	MusicEnabled        bool              `toml:"music_enabled" json:"musicEnabled"`
+	VersionType         string            `toml:"version_type" json:"versionType"`
+	SelectedVersion     int               `toml:"selected_version" json:"selectedVersion"`
+	CustomInstanceDir   string            `toml:"custom_instance_dir" json:"customInstanceDir"`     // Custom path for instances
+	AutoUpdateLatest    bool              `toml:"auto_update_latest" json:"autoUpdateLatest"`       // Auto-update latest instance
+	KeptGenerations     int               `toml:"kept_generations" json:"keptGenerations"`          // Previous builds kept per instance for rollback
+	DownloadConnections int               `toml:"download_connections" json:"downloadConnections"`  // Parallel connections per patch download
+	BandwidthLimitKB    int64             `toml:"bandwidth_limit_kb" json:"bandwidthLimitKB"`       // Download cap in KB/s, 0 means unlimited
+	PatchMirrors        []string          `toml:"patch_mirrors" json:"patchMirrors"`                // Patch base URLs, tried in order
+	PatchCacheLimitMB   int64             `toml:"patch_cache_limit_mb" json:"patchCacheLimitMB"`    // Size budget of the patch cache
+	SessionLogLimitMB   int64             `toml:"session_log_limit_mb" json:"sessionLogLimitMB"`    // Size of a game session log before it is rotated
+	SessionLogRetention int               `toml:"session_log_retention" json:"sessionLogRetention"` // Game session logs kept per instance
+	LogFormat           string            `toml:"log_format" json:"logFormat"`                      // Launcher log format: text or json
+	LogLevel            string            `toml:"log_level" json:"logLevel"`                        // Launcher log level of subsystems not in LogLevels
+	LogLevels           map[string]string `toml:"log_levels" json:"logLevels"`                      // Per-subsystem levels: pwr, mods, java, updater, game
+	LogLimitMB          int64             `toml:"log_limit_mb" json:"logLimitMB"`                   // Size of the launcher log before it is rotated
 }
 
+// DefaultPatchMirror is the official Hytale patch server
+const DefaultPatchMirror = "https://game-patches.hytale.com/patches"
+
 // Default returns the default configuration
 func Default() *Config {
 	return &Config{
-		Version:           "1.0.0",
-		Nick:              "HyPrism",
-		MusicEnabled:      true,
-		VersionType:       "release",
-		SelectedVersion:   0,  // 0 means use latest
-		CustomInstanceDir: "", // Empty means use default
-		AutoUpdateLatest:  true,
+		Version:             "1.0.0",
+		Nick:                "HyPrism",
+		MusicEnabled:        true,
+		VersionType:         "release",
+		SelectedVersion:     0,  // 0 means use latest
+		CustomInstanceDir:   "", // Empty means use default
+		AutoUpdateLatest:    true,
+		KeptGenerations:     2,
+		DownloadConnections: 4,
+		BandwidthLimitKB:    0,
+		PatchMirrors:        []string{DefaultPatchMirror},
+		PatchCacheLimitMB:   8192,
+		SessionLogLimitMB:   10,
+		SessionLogRetention: 20,
+		LogFormat:           "text",
+		LogLevel:            "info",
+		LogLevels:           map[string]string{},
+		LogLimitMB:          5,
 	}
 }
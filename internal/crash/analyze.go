@@ -0,0 +1,172 @@
+package crash
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Finding is a known cause recognized in a crash, with what to do about it
+type Finding struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Detail   string `json:"detail"`
+	Fix      string `json:"fix"`
+	Evidence string `json:"evidence,omitempty"` // The line that matched
+}
+
+// rule recognizes one known cause in the session log or JVM error log
+type rule struct {
+	id      string
+	title   string
+	pattern *regexp.Regexp
+	// explain returns the detail and fix for a match; match holds the submatches of pattern
+	explain func(r *Report, match []string) (detail string, fix string)
+}
+
+// rules are checked in order; each one adds at most one finding
+var rules = []rule{
+	{
+		id:      "missing-library",
+		title:   "SDL or another native library is missing",
+		pattern: regexp.MustCompile(`(?i)(lib(?:SDL3[\w.-]*))[^\n]*cannot open shared object file|error while loading shared libraries: (lib[\w.+-]+)`),
+		explain: func(r *Report, match []string) (string, string) {
+			lib := firstNonEmpty(match[1:]...)
+			return fmt.Sprintf("The client could not load %s.", lib),
+				"Install SDL3 and SDL3_image from your distribution, or verify the instance so the libraries shipped in Client/ are restored. Do not remove the Client directory from LD_LIBRARY_PATH in the launch profile."
+		},
+	},
+	{
+		id:      "out-of-memory",
+		title:   "The game ran out of memory",
+		pattern: regexp.MustCompile(`java\.lang\.OutOfMemoryError(?::\s*([^\n]+))?`),
+		explain: func(r *Report, match []string) (string, string) {
+			detail := "The Java heap was exhausted."
+			if match[1] != "" {
+				detail = fmt.Sprintf("The JVM reported: %s.", strings.TrimSpace(match[1]))
+			}
+			fix := "Raise the maximum heap in the instance's launch settings, for example to 4096 MB."
+			if r.Profile.MaxHeapMB > 0 {
+				fix = fmt.Sprintf("Raise the maximum heap in the instance's launch settings above the current %d MB, or disable large mods.", r.Profile.MaxHeapMB)
+			}
+			return detail, fix
+		},
+	},
+	{
+		id:      "mod-class",
+		title:   "A mod failed to load",
+		pattern: regexp.MustCompile(`(ClassNotFoundException|NoClassDefFoundError|NoSuchMethodError|NoSuchFieldError|IncompatibleClassChangeError)[:\s]+([\w.$/]+)`),
+		explain: func(r *Report, match []string) (string, string) {
+			class := strings.ReplaceAll(match[2], "/", ".")
+			detail := fmt.Sprintf("%s for %s.", match[1], class)
+			if mod := modForClass(r, class); mod != "" {
+				return detail + fmt.Sprintf(" The class appears to belong to %s.", mod),
+					fmt.Sprintf("Update or disable %s, then start the game again.", mod)
+			}
+			if len(r.Mods) == 0 {
+				return detail, "Verify the instance's game files; a game class is missing."
+			}
+			return detail, "A mod is built for another game version. Check the enabled mods for updates, or disable recently added mods one at a time."
+		},
+	},
+	{
+		id:      "native-crash",
+		title:   "The game crashed in native code",
+		pattern: regexp.MustCompile(`(?i)(EXCEPTION_ACCESS_VIOLATION|SIGSEGV|SIGBUS)[^\n]*`),
+		explain: func(r *Report, match []string) (string, string) {
+			return "The process accessed invalid memory, usually inside a graphics driver or a native library.",
+				"Update your graphics drivers. If you use wrappers such as mangohud, try launching without them."
+		},
+	},
+	{
+		id:      "graphics",
+		title:   "The graphics device could not be initialized",
+		pattern: regexp.MustCompile(`(?i)(failed to create (?:an? )?(?:opengl|vulkan|gl) (?:context|device|instance)|no suitable (?:graphics|gpu|video) device|couldn't find matching glx visual)`),
+		explain: func(r *Report, match []string) (string, string) {
+			return "The client could not create a rendering context.",
+				"Update your graphics drivers. On Linux with Wayland, try setting SDL_VIDEODRIVER=x11 in the instance's launch environment."
+		},
+	},
+	{
+		id:      "disk-full",
+		title:   "The disk is full",
+		pattern: regexp.MustCompile(`(?i)no space left on device|there is not enough space on the disk`),
+		explain: func(r *Report, match []string) (string, string) {
+			return "The game could not write its files.", "Free some disk space on the drive holding the instance."
+		},
+	},
+}
+
+// Analyze matches a report against known crash signatures
+func Analyze(r *Report) []Finding {
+	text := r.SessionLog + "\n" + r.JVMError
+	findings := []Finding{}
+	for _, rule := range rules {
+		match := rule.pattern.FindStringSubmatch(text)
+		if match == nil {
+			continue
+		}
+		detail, fix := rule.explain(r, match)
+		findings = append(findings, Finding{
+			ID:       rule.id,
+			Title:    rule.title,
+			Detail:   detail,
+			Fix:      fix,
+			Evidence: evidenceLine(text, match[0]),
+		})
+	}
+
+	if len(findings) == 0 && r.Exit.Signal == "killed" {
+		findings = append(findings, Finding{
+			ID:     "killed",
+			Title:  "The game was killed",
+			Detail: "The process was ended with SIGKILL, often by the system when memory runs out.",
+			Fix:    "Close other programs or lower the maximum heap in the instance's launch settings so the system is not out of memory.",
+		})
+	}
+	return findings
+}
+
+// modForClass returns the name of the enabled mod whose name or ID appears in a class name
+func modForClass(r *Report, class string) string {
+	lower := strings.ToLower(strings.ReplaceAll(class, "_", ""))
+	for _, m := range r.Mods {
+		for _, key := range []string{m.ID, m.Name, strings.TrimSuffix(m.File, ".jar")} {
+			key = strings.ToLower(strings.NewReplacer(" ", "", "-", "", "_", "").Replace(key))
+			if len(key) >= 4 && strings.Contains(lower, key) {
+				return m.Name
+			}
+		}
+	}
+	return ""
+}
+
+// evidenceLine returns the whole line of text containing match, shortened for display
+func evidenceLine(text string, match string) string {
+	i := strings.Index(text, match)
+	if i < 0 {
+		return match
+	}
+	start := strings.LastIndexByte(text[:i], '\n') + 1
+	end := strings.IndexByte(text[i:], '\n')
+	if end < 0 {
+		end = len(text)
+	} else {
+		end += i
+	}
+	line := strings.TrimSpace(text[start:end])
+	if len(line) > 300 {
+		line = line[:300] + "..."
+	}
+	return line
+}
+
+// firstNonEmpty returns the first non-empty value
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package crash
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"HyPrism/internal/env"
+)
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name       string
+		report     Report
+		want       []string // Finding IDs in order
+		wantDetail string   // Part of the first finding's detail or fix
+		evidence   string   // The first finding's evidence
+	}{
+		{
+			name:   "clean exit log",
+			report: Report{SessionLog: "Loading world\nSaving world\n"},
+			want:   []string{},
+		},
+		{
+			name:       "missing SDL",
+			report:     Report{SessionLog: "start\nerror while loading shared libraries: libSDL3.so.0: cannot open shared object file\n"},
+			want:       []string{"missing-library"},
+			wantDetail: "libSDL3.so.0",
+			evidence:   "error while loading shared libraries: libSDL3.so.0: cannot open shared object file",
+		},
+		{
+			name: "out of memory with a heap set",
+			report: Report{
+				SessionLog: "Exception in thread \"main\" java.lang.OutOfMemoryError: Java heap space\n",
+				Profile:    env.LaunchSettings{MaxHeapMB: 2048},
+			},
+			want:       []string{"out-of-memory"},
+			wantDetail: "above the current 2048 MB",
+		},
+		{
+			name: "class of an installed mod",
+			report: Report{
+				SessionLog: "java.lang.NoClassDefFoundError: com/example/betterchests/ChestBlock\n",
+				Mods:       []ModInfo{{ID: "better-chests", Name: "Better Chests"}},
+			},
+			want:       []string{"mod-class"},
+			wantDetail: "Better Chests",
+		},
+		{
+			name:       "missing game class without mods",
+			report:     Report{SessionLog: "java.lang.ClassNotFoundException: com.hypixel.Foo\n"},
+			want:       []string{"mod-class"},
+			wantDetail: "Verify the instance",
+		},
+		{
+			name:   "native crash in the JVM error log",
+			report: Report{JVMError: "# A fatal error has been detected\n#  SIGSEGV (0xb) at pc=0x0000\n"},
+			want:   []string{"native-crash"},
+		},
+		{
+			name:   "several causes keep rule order",
+			report: Report{SessionLog: "No space left on device\njava.lang.OutOfMemoryError\n"},
+			want:   []string{"out-of-memory", "disk-full"},
+		},
+		{
+			name:   "killed without another cause",
+			report: Report{Exit: Exit{Code: -1, Signal: "killed"}},
+			want:   []string{"killed"},
+		},
+		{
+			name:   "killed after a known cause",
+			report: Report{SessionLog: "java.lang.OutOfMemoryError\n", Exit: Exit{Code: -1, Signal: "killed"}},
+			want:   []string{"out-of-memory"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := Analyze(&tt.report)
+			ids := []string{}
+			for _, f := range findings {
+				ids = append(ids, f.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Fatalf("findings %v, want %v", ids, tt.want)
+			}
+			if tt.wantDetail != "" && !strings.Contains(findings[0].Detail+" "+findings[0].Fix, tt.wantDetail) {
+				t.Errorf("finding %+v does not mention %q", findings[0], tt.wantDetail)
+			}
+			if tt.evidence != "" && findings[0].Evidence != tt.evidence {
+				t.Errorf("evidence %q, want %q", findings[0].Evidence, tt.evidence)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package crash
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"HyPrism/internal/env"
+	"HyPrism/internal/mods"
+)
+
+// maxJVMErrorBytes is how much of a JVM fatal error log is copied into a report
+const maxJVMErrorBytes = 64 * 1024
+
+// LauncherVersion is recorded in every report; set by the app at startup
+var LauncherVersion = "dev"
+
+// Exit describes how a game session ended
+type Exit struct {
+	Code       int    `json:"code"`             // -1 when the client was ended by a signal
+	Signal     string `json:"signal,omitempty"` // Signal that ended the client, if any
+	DurationMs int64  `json:"durationMs"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Input is what the launcher knows about a session that ended abnormally
+type Input struct {
+	InstanceID string
+	SessionID  string
+	StartedAt  time.Time
+	Exit       Exit
+	Command    string             // Command line with secrets redacted
+	Profile    env.LaunchSettings // Launch profile with secrets redacted
+	SessionLog string             // End of the session log
+	WorkingDir string             // Where the client ran; JVMs write hs_err files there
+}
+
+// Platform describes the machine the game crashed on
+type Platform struct {
+	OS              string `json:"os"`
+	Arch            string `json:"arch"`
+	Distribution    string `json:"distribution,omitempty"` // Linux only, from os-release
+	Kernel          string `json:"kernel,omitempty"`       // Linux only
+	CPUs            int    `json:"cpus"`
+	LauncherVersion string `json:"launcherVersion"`
+}
+
+// ModInfo is an enabled mod at the time of the crash
+type ModInfo struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	File    string `json:"file,omitempty"`
+}
+
+// Report is a crash report as written to the crashes directory
+type Report struct {
+	ID           string             `json:"id"`
+	CreatedAt    string             `json:"createdAt"` // ISO 8601 format
+	InstanceID   string             `json:"instanceId"`
+	InstanceName string             `json:"instanceName"`
+	Branch       string             `json:"branch"`
+	Build        int                `json:"build"`
+	SessionID    string             `json:"sessionId"`
+	Exit         Exit               `json:"exit"`
+	Platform     Platform           `json:"platform"`
+	Command      string             `json:"command"`
+	Profile      env.LaunchSettings `json:"profile"`
+	Mods         []ModInfo          `json:"mods"`
+	JVMErrorFile string             `json:"jvmErrorFile,omitempty"`
+	JVMError     string             `json:"jvmError,omitempty"`
+	SessionLog   string             `json:"sessionLog"`
+	Findings     []Finding          `json:"findings"`
+	Path         string             `json:"path"` // File the report was written to
+}
+
+// Build gathers everything known about a crashed session and analyzes it
+func Build(in Input) *Report {
+	now := time.Now()
+	r := &Report{
+		ID:         fmt.Sprintf("crash-%s-%s", now.Format("2006-01-02_15-04-05"), in.InstanceID),
+		CreatedAt:  now.Format(time.RFC3339),
+		InstanceID: in.InstanceID,
+		SessionID:  in.SessionID,
+		Exit:       in.Exit,
+		Platform:   currentPlatform(),
+		Command:    in.Command,
+		Profile:    in.Profile,
+		SessionLog: in.SessionLog,
+		Mods:       []ModInfo{},
+	}
+
+	inst, err := env.GetInstance(in.InstanceID)
+	if err == nil {
+		r.InstanceName = inst.Name
+		r.Branch = inst.Branch
+		r.Build = inst.InstalledBuild
+	}
+
+	if installed, err := mods.GetInstalledModsByID(in.InstanceID); err == nil {
+		for _, m := range installed {
+			if m.Enabled {
+				r.Mods = append(r.Mods, ModInfo{ID: m.ID, Name: m.Name, Version: m.Version, File: filepath.Base(m.FilePath)})
+			}
+		}
+	}
+
+	if inst != nil {
+		if path := findJVMError(jvmErrorDirs(inst, in.WorkingDir), in.StartedAt); path != "" {
+			r.JVMErrorFile = path
+			if data, err := os.ReadFile(path); err == nil {
+				if len(data) > maxJVMErrorBytes {
+					data = data[:maxJVMErrorBytes]
+				}
+				r.JVMError = string(data)
+			}
+		}
+	}
+
+	r.Findings = Analyze(r)
+	return r
+}
+
+// Write saves a report to the crashes directory as text, with the analysis first
+func Write(r *Report) (string, error) {
+	dir := env.GetCrashesDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, r.ID+".txt")
+	r.Path = path
+	if err := os.WriteFile(path, []byte(r.Text()), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Text formats a report for people
+func (r *Report) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "HyPrism Crash Report\nGenerated: %s\n\n", r.CreatedAt)
+
+	b.WriteString("=== ANALYSIS ===\n")
+	if len(r.Findings) == 0 {
+		b.WriteString("No known cause was recognized. Check the session log below.\n")
+	}
+	for _, f := range r.Findings {
+		fmt.Fprintf(&b, "* %s\n  %s\n  Fix: %s\n", f.Title, f.Detail, f.Fix)
+		if f.Evidence != "" {
+			fmt.Fprintf(&b, "  Evidence: %s\n", f.Evidence)
+		}
+	}
+
+	b.WriteString("\n=== SESSION ===\n")
+	fmt.Fprintf(&b, "Instance: %s (%s)\n", r.InstanceID, r.InstanceName)
+	fmt.Fprintf(&b, "Branch: %s\nInstalled Build: %d\n", r.Branch, r.Build)
+	fmt.Fprintf(&b, "Session: %s\n", r.SessionID)
+	if r.Exit.Signal != "" {
+		fmt.Fprintf(&b, "Exit: ended by %s\n", r.Exit.Signal)
+	} else {
+		fmt.Fprintf(&b, "Exit: code %d\n", r.Exit.Code)
+	}
+	fmt.Fprintf(&b, "Ran For: %s\n", time.Duration(r.Exit.DurationMs)*time.Millisecond)
+	if r.Exit.Error != "" {
+		fmt.Fprintf(&b, "Error: %s\n", r.Exit.Error)
+	}
+
+	b.WriteString("\n=== PLATFORM ===\n")
+	fmt.Fprintf(&b, "OS: %s\nArch: %s\n", r.Platform.OS, r.Platform.Arch)
+	if r.Platform.Distribution != "" {
+		fmt.Fprintf(&b, "Distribution: %s\n", r.Platform.Distribution)
+	}
+	if r.Platform.Kernel != "" {
+		fmt.Fprintf(&b, "Kernel: %s\n", r.Platform.Kernel)
+	}
+	fmt.Fprintf(&b, "CPUs: %d\nLauncher Version: %s\n", r.Platform.CPUs, r.Platform.LauncherVersion)
+
+	b.WriteString("\n=== LAUNCH ===\n")
+	fmt.Fprintf(&b, "Command: %s\n", r.Command)
+	if profile, err := json.MarshalIndent(r.Profile, "", "  "); err == nil {
+		fmt.Fprintf(&b, "Profile: %s\n", profile)
+	}
+
+	fmt.Fprintf(&b, "\n=== ENABLED MODS (%d) ===\n", len(r.Mods))
+	for _, m := range r.Mods {
+		fmt.Fprintf(&b, "%s %s (%s)\n", m.Name, m.Version, m.File)
+	}
+
+	if r.JVMErrorFile != "" {
+		fmt.Fprintf(&b, "\n=== JVM ERROR (%s) ===\n%s\n", r.JVMErrorFile, r.JVMError)
+	}
+
+	fmt.Fprintf(&b, "\n=== SESSION LOG (end) ===\n%s\n", r.SessionLog)
+	return b.String()
+}
+
+// currentPlatform describes this machine
+func currentPlatform() Platform {
+	p := Platform{
+		OS:              runtime.GOOS,
+		Arch:            runtime.GOARCH,
+		CPUs:            runtime.NumCPU(),
+		LauncherVersion: LauncherVersion,
+	}
+	if runtime.GOOS == "linux" {
+		p.Distribution = osReleaseName()
+		if data, err := os.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+			p.Kernel = strings.TrimSpace(string(data))
+		}
+	}
+	return p
+}
+
+// osReleaseName returns PRETTY_NAME from os-release, or ""
+func osReleaseName() string {
+	for _, path := range []string{"/etc/os-release", "/usr/lib/os-release"} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if value, ok := strings.CutPrefix(line, "PRETTY_NAME="); ok {
+				return strings.Trim(value, `"'`)
+			}
+		}
+	}
+	return ""
+}
+
+// jvmErrorDirs returns the directories a JVM may have written its fatal error log to
+func jvmErrorDirs(inst *env.Instance, workingDir string) []string {
+	gameDir := inst.GameDir()
+	dirs := []string{
+		inst.Dir(),
+		inst.UserDataDir(),
+		gameDir,
+		filepath.Join(gameDir, "Client"),
+		filepath.Join(gameDir, "Server"),
+	}
+	if workingDir != "" {
+		dirs = append(dirs, workingDir)
+	}
+	return dirs
+}
+
+// findJVMError returns the newest hs_err_pid*.log in dirs written after since, or ""
+func findJVMError(dirs []string, since time.Time) string {
+	type candidate struct {
+		path    string
+		modTime time.Time
+	}
+	var found []candidate
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		matches, _ := filepath.Glob(filepath.Join(dir, "hs_err_pid*.log"))
+		for _, path := range matches {
+			info, err := os.Stat(path)
+			if err != nil || info.ModTime().Before(since) {
+				continue
+			}
+			found = append(found, candidate{path, info.ModTime()})
+		}
+	}
+	if len(found) == 0 {
+		return ""
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].modTime.After(found[j].modTime) })
+	return found[0].path
+}
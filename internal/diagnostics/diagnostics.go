@@ -0,0 +1,333 @@
+// Package diagnostics checks the machine and the launcher's files for problems that keep the game
+// from installing or starting. Every check passes, warns or fails, and says what to do about it.
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"HyPrism/internal/env"
+	"HyPrism/internal/game"
+	"HyPrism/internal/java"
+	"HyPrism/internal/pwr/butler"
+)
+
+// Status is the outcome of a check
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+)
+
+// Check is the outcome of one diagnostic check
+type Check struct {
+	ID       string `json:"id"`
+	Category string `json:"category"` // disk, permissions, java, butler, libraries or instance
+	Title    string `json:"title"`
+	Status   Status `json:"status"`
+	Detail   string `json:"detail"`
+	Fix      string `json:"fix,omitempty"` // What to do when the check does not pass
+}
+
+// Report holds the checks and what they found out
+type Report struct {
+	Checks    []Check           `json:"checks"`
+	Java      *java.RuntimeInfo `json:"java,omitempty"`
+	Butler    string            `json:"butler,omitempty"` // What butler version printed
+	Instances []InstanceStatus  `json:"instances"`
+}
+
+// InstanceStatus is the install state of one instance
+type InstanceStatus struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Branch         string `json:"branch"`
+	InstalledBuild int    `json:"installedBuild"`
+	GameFrom       string `json:"gameFrom,omitempty"`
+	ClientPath     string `json:"clientPath"`
+	ClientExists   bool   `json:"clientExists"`
+}
+
+// Free space below which the disk checks warn and fail; an install needs a few GB
+const (
+	warnFreeBytes = 5 * 1024 * 1024 * 1024
+	failFreeBytes = 1 * 1024 * 1024 * 1024
+)
+
+// Run runs every check
+func Run(ctx context.Context) *Report {
+	r := &Report{}
+	instances := env.ListInstanceDescriptors()
+
+	r.Checks = append(r.Checks, diskChecks(instances)...)
+	r.Checks = append(r.Checks, permissionChecks(instances)...)
+	r.Checks = append(r.Checks, r.javaCheck(ctx))
+	r.Checks = append(r.Checks, r.butlerCheck(ctx))
+	r.Checks = append(r.Checks, libraryChecks(instances)...)
+	for _, inst := range instances {
+		status := instanceStatus(inst)
+		r.Instances = append(r.Instances, status)
+		r.Checks = append(r.Checks, instanceCheck(status))
+	}
+	return r
+}
+
+// Worst returns the most severe status among the checks
+func (r *Report) Worst() Status {
+	worst := StatusPass
+	for _, c := range r.Checks {
+		switch {
+		case c.Status == StatusFail:
+			return StatusFail
+		case c.Status == StatusWarn:
+			worst = StatusWarn
+		}
+	}
+	return worst
+}
+
+// diskChecks reports the free space on the volumes of the app directory, the instances directory
+// and instances that were moved elsewhere
+func diskChecks(instances []*env.Instance) []Check {
+	type volume struct{ id, title, path string }
+	volumes := []volume{
+		{"disk-app", "Free space for the launcher", env.GetDefaultAppDir()},
+		{"disk-instances", "Free space for instances", env.GetInstancesDir()},
+	}
+	for _, inst := range instances {
+		if inst.Location != "" {
+			volumes = append(volumes, volume{"disk-instance-" + inst.ID, fmt.Sprintf("Free space for %s", inst.Name), inst.Location})
+		}
+	}
+
+	var checks []Check
+	seen := make(map[string]bool)
+	for _, v := range volumes {
+		if seen[v.path] {
+			continue
+		}
+		seen[v.path] = true
+
+		c := Check{ID: v.id, Category: "disk", Title: v.title}
+		free, err := env.DiskFree(v.path)
+		switch {
+		case err != nil:
+			c.Status = StatusWarn
+			c.Detail = fmt.Sprintf("Could not read the free space of %s: %v", v.path, err)
+		case free < failFreeBytes:
+			c.Status = StatusFail
+			c.Detail = fmt.Sprintf("Only %s free on the volume holding %s.", formatBytes(free), v.path)
+			c.Fix = "Free up disk space or move instances to another drive in the settings; installs and updates need several GB."
+		case free < warnFreeBytes:
+			c.Status = StatusWarn
+			c.Detail = fmt.Sprintf("%s free on the volume holding %s.", formatBytes(free), v.path)
+			c.Fix = "Updates may not fit. Free up disk space, clear the patch cache, or lower the number of kept builds."
+		default:
+			c.Status = StatusPass
+			c.Detail = fmt.Sprintf("%s free on the volume holding %s.", formatBytes(free), v.path)
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+// permissionChecks probes that every directory the launcher writes to can be written
+func permissionChecks(instances []*env.Instance) []Check {
+	type dir struct{ id, title, path string }
+	dirs := []dir{
+		{"write-app", "App directory", env.GetDefaultAppDir()},
+		{"write-cache", "Cache directory", env.GetCacheDir()},
+		{"write-logs", "Logs directory", env.GetLogsDir()},
+		{"write-crashes", "Crash reports directory", env.GetCrashesDir()},
+		{"write-jre", "Java directory", env.GetJREDir()},
+		{"write-butler", "Butler directory", env.GetButlerDir()},
+		{"write-userdata", "User data directory", env.GetUserDataDir()},
+		{"write-instances", "Instances directory", env.GetInstancesDir()},
+	}
+	for _, inst := range instances {
+		dirs = append(dirs, dir{"write-instance-" + inst.ID, fmt.Sprintf("Instance %s", inst.Name), inst.Dir()})
+	}
+
+	var checks []Check
+	for _, d := range dirs {
+		c := Check{ID: d.id, Category: "permissions", Title: d.title + " is writable"}
+		probed, err := probeWrite(d.path)
+		switch {
+		case err != nil:
+			c.Status = StatusFail
+			c.Detail = fmt.Sprintf("Cannot write to %s: %v", probed, err)
+			c.Fix = fmt.Sprintf("Give your user write access to %s. Check that the drive is not read-only and that no antivirus blocks the launcher.", probed)
+		case probed != d.path:
+			c.Status = StatusPass
+			c.Detail = fmt.Sprintf("%s does not exist yet; %s is writable.", d.path, probed)
+		default:
+			c.Status = StatusPass
+			c.Detail = d.path
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+// probeWrite creates and removes a file in path, or in its nearest existing parent when path does
+// not exist yet. It returns the directory that was probed.
+func probeWrite(path string) (string, error) {
+	dir := path
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	f, err := os.CreateTemp(dir, ".hyprism-write-*")
+	if err != nil {
+		return dir, err
+	}
+	name := f.Name()
+	_, err = f.Write([]byte("probe"))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if removeErr := os.Remove(name); err == nil {
+		err = removeErr
+	}
+	return dir, err
+}
+
+// javaCheck runs the bundled Java runtime and reads what it reports
+func (r *Report) javaCheck(ctx context.Context) Check {
+	c := Check{ID: "java", Category: "java", Title: "Java runtime"}
+	path, err := java.GetJavaExec()
+	if err != nil {
+		c.Status = StatusFail
+		c.Detail = err.Error()
+		c.Fix = "Install or update the game; the launcher downloads Java along with it."
+		return c
+	}
+
+	info, err := java.Probe(ctx, path)
+	if err != nil {
+		c.Status = StatusFail
+		c.Detail = err.Error()
+		c.Fix = fmt.Sprintf("The runtime is damaged or blocked. Delete %s so the launcher downloads it again, and check that no antivirus quarantines it.", env.GetJREDir())
+		return c
+	}
+	r.Java = info
+
+	c.Detail = fmt.Sprintf("%s %s (%s) at %s", info.Vendor, info.Version, info.Arch, path)
+	if info.Arch != "" && normalizeArch(info.Arch) != runtime.GOARCH {
+		c.Status = StatusWarn
+		c.Fix = fmt.Sprintf("The runtime is built for %s but this system is %s, so it runs emulated or not at all. Delete %s so the launcher downloads the right one.", info.Arch, runtime.GOARCH, env.GetJREDir())
+		return c
+	}
+	c.Status = StatusPass
+	return c
+}
+
+// butlerCheck runs butler, which applies game patches
+func (r *Report) butlerCheck(ctx context.Context) Check {
+	c := Check{ID: "butler", Category: "butler", Title: "Butler patch tool"}
+	path, err := butler.GetButlerPath()
+	if err == nil {
+		_, err = os.Stat(path)
+	}
+	if err != nil {
+		c.Status = StatusWarn
+		c.Detail = "Butler is not installed."
+		c.Fix = "It is downloaded with the next install or update. If that fails, check that broth.itch.zone is reachable."
+		return c
+	}
+
+	version, err := butler.Version(ctx)
+	if err != nil {
+		c.Status = StatusFail
+		c.Detail = err.Error()
+		c.Fix = fmt.Sprintf("Delete %s so the launcher downloads Butler again, and check that no antivirus quarantines it.", env.GetButlerDir())
+		return c
+	}
+	r.Butler = version
+	c.Status = StatusPass
+	c.Detail = fmt.Sprintf("%s at %s", version, path)
+	return c
+}
+
+// instanceStatus reads the install state of an instance
+func instanceStatus(inst *env.Instance) InstanceStatus {
+	status := InstanceStatus{
+		ID:             inst.ID,
+		Name:           inst.Name,
+		Branch:         inst.Branch,
+		InstalledBuild: inst.InstalledBuild,
+		GameFrom:       inst.GameFrom,
+		ClientPath:     game.ClientPath(inst.GameDir()),
+	}
+	if _, err := os.Stat(status.ClientPath); err == nil {
+		status.ClientExists = true
+	}
+	return status
+}
+
+// instanceCheck judges the install state of an instance
+func instanceCheck(s InstanceStatus) Check {
+	c := Check{ID: "instance-" + s.ID, Category: "instance", Title: fmt.Sprintf("Instance %s", s.Name)}
+	switch {
+	case s.GameFrom != "" && !s.ClientExists:
+		c.Status = StatusFail
+		c.Detail = fmt.Sprintf("Shares the game files of %s, but they are missing.", s.GameFrom)
+		c.Fix = fmt.Sprintf("Install or repair %s, or reinstall this instance so it has its own game files.", s.GameFrom)
+	case s.InstalledBuild > 0 && s.ClientExists:
+		c.Status = StatusPass
+		c.Detail = fmt.Sprintf("%s build %d installed.", s.Branch, s.InstalledBuild)
+	case s.InstalledBuild > 0:
+		c.Status = StatusFail
+		c.Detail = fmt.Sprintf("%s build %d is recorded, but the client is missing at %s.", s.Branch, s.InstalledBuild, s.ClientPath)
+		c.Fix = "Verify the instance to restore missing files, or reinstall it. An antivirus may have removed the client."
+	case s.ClientExists:
+		c.Status = StatusWarn
+		c.Detail = "Game files are present, but the installed build is not recorded."
+		c.Fix = "Verify the instance so the launcher can recognize the build, or reinstall it."
+	default:
+		c.Status = StatusWarn
+		c.Detail = "Not installed."
+		c.Fix = "Install the game for this instance before playing."
+	}
+	return c
+}
+
+// normalizeArch maps a Java os.arch name to its GOARCH name
+func normalizeArch(arch string) string {
+	switch strings.ToLower(arch) {
+	case "x86_64", "amd64":
+		return "amd64"
+	case "aarch64", "arm64":
+		return "arm64"
+	case "x86", "i386", "i686":
+		return "386"
+	}
+	return strings.ToLower(arch)
+}
+
+// formatBytes formats a size for people
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
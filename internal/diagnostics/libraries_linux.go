@@ -0,0 +1,224 @@
+package diagnostics
+
+import (
+	"debug/elf"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"HyPrism/internal/env"
+	"HyPrism/internal/game"
+)
+
+// maxLibraryObjects bounds how many shared objects one client check opens
+const maxLibraryObjects = 1000
+
+// libraryChecks resolves the shared libraries of every installed client the way the dynamic
+// loader does, so libraries the system lacks are found before the game fails to start
+func libraryChecks(instances []*env.Instance) []Check {
+	var checks []Check
+	seen := make(map[string]bool)
+	for _, inst := range instances {
+		owner := inst.GameOwner()
+		if seen[owner] {
+			continue
+		}
+		seen[owner] = true
+
+		client := game.ClientPath(inst.GameDir())
+		if _, err := os.Stat(client); err != nil {
+			// The instance check reports clients that are missing
+			continue
+		}
+		checks = append(checks, libraryCheck(inst, client))
+	}
+	return checks
+}
+
+// libraryCheck resolves the libraries of one client
+func libraryCheck(inst *env.Instance, client string) Check {
+	c := Check{ID: "libraries-" + inst.GameOwner(), Category: "libraries", Title: fmt.Sprintf("Libraries of %s", inst.Name)}
+	r := &resolver{libraryPath: launchLibraryPath(inst, client), systemDirs: systemLibraryDirs(), resolved: make(map[string]string)}
+	missing, err := r.missing(client)
+	switch {
+	case err != nil:
+		c.Status = StatusWarn
+		c.Detail = fmt.Sprintf("Could not read %s: %v", client, err)
+		c.Fix = "Verify the instance; the client executable may be damaged."
+	case len(missing) > 0:
+		c.Status = StatusFail
+		c.Detail = "Missing: " + strings.Join(missing, ", ")
+		c.Fix = "Install the missing libraries with your distribution's package manager, or verify the instance so the libraries shipped in Client/ are restored. Do not remove the Client directory from LD_LIBRARY_PATH in the launch profile."
+	default:
+		c.Status = StatusPass
+		c.Detail = fmt.Sprintf("All %d shared libraries resolved.", len(r.resolved))
+	}
+	return c
+}
+
+// launchLibraryPath returns LD_LIBRARY_PATH as the client is started with it
+func launchLibraryPath(inst *env.Instance, client string) []string {
+	path := filepath.Dir(client)
+	if existing := os.Getenv("LD_LIBRARY_PATH"); existing != "" {
+		path += ":" + existing
+	}
+	if override, ok := inst.Launch.Env["LD_LIBRARY_PATH"]; ok {
+		path = override
+	}
+	return splitPath(path)
+}
+
+// resolver finds shared objects like ld.so: DT_RPATH, LD_LIBRARY_PATH, DT_RUNPATH, then the system directories
+type resolver struct {
+	libraryPath []string
+	systemDirs  []string
+	resolved    map[string]string // Library name to the file it resolved to
+}
+
+// missing returns the libraries needed by the executable at path, directly or through its
+// libraries, that cannot be found. Each is followed by the object that needs it.
+func (r *resolver) missing(path string) ([]string, error) {
+	exe, err := elf.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	class, machine := exe.Class, exe.Machine
+	exe.Close()
+
+	var missing []string
+	reported := make(map[string]bool)
+	queue := []string{path}
+	for len(queue) > 0 && len(r.resolved) < maxLibraryObjects {
+		object := queue[0]
+		queue = queue[1:]
+
+		f, err := elf.Open(object)
+		if err != nil {
+			continue
+		}
+		needed, _ := f.ImportedLibraries()
+		rpath, _ := f.DynString(elf.DT_RPATH)
+		runpath, _ := f.DynString(elf.DT_RUNPATH)
+		f.Close()
+
+		origin := filepath.Dir(object)
+		var dirs []string
+		if len(runpath) == 0 {
+			dirs = append(dirs, expandOrigin(rpath, origin)...)
+		}
+		dirs = append(dirs, r.libraryPath...)
+		dirs = append(dirs, expandOrigin(runpath, origin)...)
+		dirs = append(dirs, r.systemDirs...)
+
+		for _, name := range needed {
+			if _, ok := r.resolved[name]; ok {
+				continue
+			}
+			found := findLibrary(name, origin, dirs, class, machine)
+			if found == "" {
+				if !reported[name] {
+					reported[name] = true
+					missing = append(missing, fmt.Sprintf("%s (needed by %s)", name, filepath.Base(object)))
+				}
+				continue
+			}
+			r.resolved[name] = found
+			queue = append(queue, found)
+		}
+	}
+	return missing, nil
+}
+
+// findLibrary returns the first file named name in dirs that matches the executable's ELF class and machine
+func findLibrary(name string, origin string, dirs []string, class elf.Class, machine elf.Machine) string {
+	if strings.Contains(name, "/") {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(origin, name)
+		}
+		if compatible(name, class, machine) {
+			return name
+		}
+		return ""
+	}
+	for _, dir := range dirs {
+		candidate := filepath.Join(dir, name)
+		if compatible(candidate, class, machine) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// compatible reports whether path is a shared object the executable can load
+func compatible(path string, class elf.Class, machine elf.Machine) bool {
+	f, err := elf.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	return f.Class == class && f.Machine == machine
+}
+
+// expandOrigin splits DT_RPATH or DT_RUNPATH entries and replaces $ORIGIN
+func expandOrigin(entries []string, origin string) []string {
+	var dirs []string
+	for _, entry := range entries {
+		for _, dir := range splitPath(entry) {
+			dir = strings.ReplaceAll(dir, "${ORIGIN}", origin)
+			dirs = append(dirs, strings.ReplaceAll(dir, "$ORIGIN", origin))
+		}
+	}
+	return dirs
+}
+
+// systemLibraryDirs returns the directories of /etc/ld.so.conf followed by the loader's defaults
+func systemLibraryDirs() []string {
+	dirs := readLdSoConf("/etc/ld.so.conf", 0)
+	for _, triple := range []string{"x86_64-linux-gnu", "aarch64-linux-gnu"} {
+		dirs = append(dirs, "/lib/"+triple, "/usr/lib/"+triple)
+	}
+	return append(dirs, "/lib64", "/usr/lib64", "/lib", "/usr/lib")
+}
+
+// readLdSoConf reads the directories of an ld.so.conf file and the files it includes
+func readLdSoConf(path string, depth int) []string {
+	data, err := os.ReadFile(path)
+	if err != nil || depth > 4 {
+		return nil
+	}
+	var dirs []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if pattern, ok := strings.CutPrefix(line, "include "); ok {
+			pattern = strings.TrimSpace(pattern)
+			if !filepath.IsAbs(pattern) {
+				pattern = filepath.Join(filepath.Dir(path), pattern)
+			}
+			matches, _ := filepath.Glob(pattern)
+			for _, match := range matches {
+				dirs = append(dirs, readLdSoConf(match, depth+1)...)
+			}
+			continue
+		}
+		dirs = append(dirs, line)
+	}
+	return dirs
+}
+
+// splitPath splits a colon-separated search path, skipping empty entries
+func splitPath(path string) []string {
+	var dirs []string
+	for _, dir := range strings.Split(path, ":") {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
@@ -0,0 +1,134 @@
+package diagnostics
+
+import (
+	"debug/elf"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"HyPrism/internal/util"
+)
+
+// dynamicExecutable returns a system executable with shared libraries, and the path of its libc
+func dynamicExecutable(t *testing.T) (string, string) {
+	t.Helper()
+	for _, path := range []string{"/bin/sh", "/usr/bin/env", "/bin/ls"} {
+		f, err := elf.Open(path)
+		if err != nil {
+			continue
+		}
+		needed, _ := f.ImportedLibraries()
+		class, machine := f.Class, f.Machine
+		f.Close()
+		for _, name := range needed {
+			if name != "libc.so.6" {
+				continue
+			}
+			if libc := findLibrary(name, filepath.Dir(path), systemLibraryDirs(), class, machine); libc != "" {
+				return path, libc
+			}
+		}
+	}
+	t.Skip("no dynamically linked system executable")
+	return "", ""
+}
+
+func TestResolverMissing(t *testing.T) {
+	exe, libc := dynamicExecutable(t)
+	private := t.TempDir()
+	if err := util.CopyFile(libc, filepath.Join(private, "libc.so.6")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		libraryPath []string
+		systemDirs  []string
+		wantMissing string // A library reported as missing, "" for none
+		wantLibc    string // Where libc.so.6 resolves to
+	}{
+		{name: "system directories", systemDirs: systemLibraryDirs(), wantLibc: libc},
+		{name: "library path comes first", libraryPath: []string{private}, systemDirs: systemLibraryDirs(), wantLibc: filepath.Join(private, "libc.so.6")},
+		{name: "nothing to search", wantMissing: "libc.so.6 (needed by " + filepath.Base(exe) + ")"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &resolver{libraryPath: tt.libraryPath, systemDirs: tt.systemDirs, resolved: make(map[string]string)}
+			missing, err := r.missing(exe)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantMissing == "" && len(missing) > 0 {
+				t.Errorf("missing %v", missing)
+			}
+			if tt.wantMissing != "" && !contains(missing, tt.wantMissing) {
+				t.Errorf("missing %v, want %q among them", missing, tt.wantMissing)
+			}
+			if got := r.resolved["libc.so.6"]; got != tt.wantLibc {
+				t.Errorf("libc.so.6 resolved to %q, want %q", got, tt.wantLibc)
+			}
+		})
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestFindLibraryRejectsOtherArchitectures(t *testing.T) {
+	_, libc := dynamicExecutable(t)
+	f, err := elf.Open(libc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	class, machine := f.Class, f.Machine
+	f.Close()
+
+	dir := filepath.Dir(libc)
+	if got := findLibrary("libc.so.6", dir, []string{dir}, class, machine); got != libc {
+		t.Errorf("matching library resolved to %q", got)
+	}
+	other := elf.ELFCLASS32
+	if class == elf.ELFCLASS32 {
+		other = elf.ELFCLASS64
+	}
+	if got := findLibrary("libc.so.6", dir, []string{dir}, other, machine); got != "" {
+		t.Errorf("library of another class resolved to %q", got)
+	}
+}
+
+func TestExpandOrigin(t *testing.T) {
+	got := expandOrigin([]string{"$ORIGIN/lib:${ORIGIN}/../shared", "/opt/lib::"}, "/game/Client")
+	want := []string{"/game/Client/lib", "/game/Client/../shared", "/opt/lib"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expandOrigin = %v, want %v", got, want)
+	}
+}
+
+func TestReadLdSoConf(t *testing.T) {
+	dir := t.TempDir()
+	conf := filepath.Join(dir, "ld.so.conf")
+	os.MkdirAll(filepath.Join(dir, "ld.so.conf.d"), 0755)
+	os.WriteFile(conf, []byte("# comment\ninclude ld.so.conf.d/*.conf\n/usr/local/lib  # trailing\n\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "ld.so.conf.d", "a.conf"), []byte("/opt/a\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "ld.so.conf.d", "b.conf"), []byte("/opt/b\ninclude "+conf+"\n"), 0644)
+
+	// The include loop stops at the depth limit
+	got := readLdSoConf(conf, 0)
+	if len(got) < 3 || !reflect.DeepEqual(got[:3], []string{"/opt/a", "/opt/b", "/opt/a"}) {
+		t.Errorf("readLdSoConf = %v", got)
+	}
+	if last := got[len(got)-1]; last != "/usr/local/lib" {
+		t.Errorf("last directory %q, want /usr/local/lib", last)
+	}
+	if strings.Contains(strings.Join(got, " "), "#") {
+		t.Errorf("comments were kept: %v", got)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package diagnostics
+
+import "HyPrism/internal/env"
+
+// libraryChecks only applies on Linux, where the client loads system libraries
+func libraryChecks(instances []*env.Instance) []Check {
+	return nil
+}
@@ -1,7 +1,6 @@
 package env
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
 )
@@ -28,13 +27,10 @@ func CleanupIncompleteDownloads() error {
 		return err
 	}
 
-	// Check for incomplete game in latest instance
-	gameDir := GetInstanceGameDir("release", 0)
-	if err := cleanIncompleteGame(gameDir); err != nil {
-		return err
-	}
+	// Interrupted game installs are journaled per instance and
+	// recovered by game.RecoverInterruptedInstalls
 
-	fmt.Printf("Cleanup completed in %s\n", appDir)
+	logger.Info("Cleanup completed", "dir", appDir)
 	return nil
 }
 
@@ -55,7 +51,7 @@ func cleanDirectory(dir string, extensions []string) error {
 		for _, ext := range extensions {
 			if filepath.Ext(entry.Name()) == ext {
 				filePath := filepath.Join(dir, entry.Name())
-				fmt.Printf("Removing incomplete file: %s\n", filePath)
+				logger.Info("Removing incomplete file", "path", filePath)
 				os.Remove(filePath)
 				break
 			}
@@ -64,21 +60,3 @@ func cleanDirectory(dir string, extensions []string) error {
 
 	return nil
 }
-
-func cleanIncompleteGame(gameDir string) error {
-	markerFile := filepath.Join(gameDir, ".installing")
-	
-	if _, err := os.Stat(markerFile); err == nil {
-		// Installation was incomplete, clean up
-		fmt.Println("Found incomplete game installation, cleaning up...")
-		
-		// Remove the Client folder if it exists
-		clientDir := filepath.Join(gameDir, "Client")
-		os.RemoveAll(clientDir)
-		
-		// Remove marker
-		os.Remove(markerFile)
-	}
-
-	return nil
-}
@@ -1,12 +1,17 @@
 package env
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
+
+	"HyPrism/internal/logging"
 )
 
+// logger writes instance and directory records to the launcher log
+var logger = logging.For(logging.Launcher)
+
 // GetDefaultAppDir returns the default application directory
 func GetDefaultAppDir() string {
 	var baseDir string
@@ -68,6 +73,11 @@ func GetLogsDir() string {
 	return filepath.Join(GetDefaultAppDir(), "logs")
 }
 
+// GetCrashesDir returns the directory crash reports are written to
+func GetCrashesDir() string {
+	return filepath.Join(GetDefaultAppDir(), "crashes")
+}
+
 // GetJREDir returns the JRE directory
 func GetJREDir() string {
 	return filepath.Join(GetDefaultAppDir(), "jre")
@@ -102,16 +112,13 @@ func GetInstancesDir() string {
 }
 
 // GetInstanceDir returns the directory for a specific instance
-// Format: instances/{branch}-v{version} or instances/latest for auto-updating
+// The instance is looked up through its instance.json; version 0 is the auto-updating
+// instance of the branch. If none exists yet, the directory a new one would get is returned.
 func GetInstanceDir(branch string, version int) string {
-	var instanceName string
-	if version == 0 {
-		// Version 0 means "latest" auto-updating instance
-		instanceName = "latest"
-	} else {
-		instanceName = fmt.Sprintf("%s-v%d", branch, version)
+	if inst := FindInstance(branch, version); inst != nil {
+		return inst.Dir()
 	}
-	return filepath.Join(GetInstancesDir(), instanceName)
+	return filepath.Join(GetInstancesDir(), defaultInstanceID(branch, version))
 }
 
 // GetInstanceGameDir returns the game directory for an instance
@@ -134,15 +141,60 @@ func GetInstanceUserDataDir(branch string, version int) string {
 	return filepath.Join(GetInstanceDir(branch, version), "UserData")
 }
 
-// CreateInstanceFolders creates all necessary folders for an instance
+// GetInstanceBuild returns the game build recorded in an instance's descriptor
+// Returns 0 if the instance does not exist or has nothing installed
+func GetInstanceBuild(branch string, version int) int {
+	if inst := FindInstance(branch, version); inst != nil {
+		return inst.InstalledBuild
+	}
+	return 0
+}
+
+// SetInstanceBuild records the game build installed in an instance
+// A descriptor is created from branch and version if the instance has none yet.
+// Installing the skipped build or a newer one lifts the skip.
+func SetInstanceBuild(id string, branch string, version int, build int) error {
+	if _, err := UpdateInstance(id, func(inst *Instance) {
+		inst.InstalledBuild = build
+		if build >= inst.SkipBuild {
+			inst.SkipBuild = 0
+		}
+	}); err == nil {
+		return nil
+	}
+
+	inst := NewInstance(id, "", branch, version)
+	inst.InstalledBuild = build
+	return SaveInstance(inst)
+}
+
+// SkipInstanceBuild keeps automatic updates of an instance from installing build, or any
+// older one, again; used after rolling back from a broken build
+func SkipInstanceBuild(id string, build int) error {
+	_, err := UpdateInstance(id, func(inst *Instance) {
+		inst.SkipBuild = build
+	})
+	return err
+}
+
+// CreateInstanceFolders creates the descriptor and all necessary folders for an instance
 func CreateInstanceFolders(branch string, version int) error {
+	inst, err := EnsureInstance(branch, version)
+	if err != nil {
+		return err
+	}
+	return createInstanceDirs(inst.Dir())
+}
+
+// createInstanceDirs creates the standard folders inside an instance directory
+func createInstanceDirs(dir string) error {
 	folders := []string{
-		GetInstanceDir(branch, version),
-		GetInstanceGameDir(branch, version),
-		GetInstanceModsDir(branch, version),
-		GetInstanceSavesDir(branch, version),
-		GetInstanceUserDataDir(branch, version),
-		filepath.Join(GetInstanceUserDataDir(branch, version), "Mods"),
+		dir,
+		filepath.Join(dir, "game"),
+		filepath.Join(dir, "mods"),
+		filepath.Join(dir, "saves"),
+		filepath.Join(dir, "UserData"),
+		filepath.Join(dir, "UserData", "Mods"),
 	}
 
 	for _, folder := range folders {
@@ -180,75 +232,52 @@ func IsVersionInstalled(branch string, version int) bool {
 	instanceDir := GetInstanceDir(branch, version)
 	gameDir := GetInstanceGameDir(branch, version)
 	
-	fmt.Printf("[DEBUG] IsVersionInstalled: Checking %s v%d\n", branch, version)
-	fmt.Printf("[DEBUG] Instance dir: %s\n", instanceDir)
-	fmt.Printf("[DEBUG] Game dir: %s\n", gameDir)
-	
 	// First check if instance directory exists
 	if _, err := os.Stat(instanceDir); os.IsNotExist(err) {
-		fmt.Printf("[DEBUG] Instance directory does not exist\n")
 		return false
 	}
 	
 	// Check if game directory exists
 	if _, err := os.Stat(gameDir); os.IsNotExist(err) {
-		fmt.Printf("[DEBUG] Game directory does not exist\n")
 		return false
 	}
 	
 	// Check if Client folder exists with content (simplest check that works)
 	clientDir := filepath.Join(gameDir, "Client")
 	if entries, err := os.ReadDir(clientDir); err == nil && len(entries) > 0 {
-		fmt.Printf("[DEBUG] Client folder found with %d entries - game is installed\n", len(entries))
 		return true
 	}
 	
 	// If no Client folder, check if game directory has content (at least a few files/folders)
 	if entries, err := os.ReadDir(gameDir); err == nil && len(entries) >= 2 {
-		fmt.Printf("[DEBUG] Game dir has %d entries - considering installed\n", len(entries))
 		return true
 	}
 	
-	fmt.Printf("[DEBUG] No valid game installation found\n")
 	return false
 }
 
 // GetInstalledVersions returns all installed versions for a specific branch
-// This verifies that each version directory actually has game files
+// This verifies that each instance actually has game files
 func GetInstalledVersions(branch string) []int {
-	instancesDir := GetInstancesDir()
-	prefix := branch + "-v"
-	
-	entries, err := os.ReadDir(instancesDir)
-	if err != nil {
-		return []int{}
-	}
-	
-	var versions []int
-	
-	// Check for 'latest' directory (version 0) - verify it's actually installed
-	for _, entry := range entries {
-		if entry.IsDir() && entry.Name() == "latest" {
-			// Verify the game is actually installed in "latest"
-			if IsVersionInstalled(branch, 0) {
-				versions = append(versions, 0)
-			}
-			break
+	versions := []int{}
+	seen := make(map[int]bool)
+
+	for _, inst := range ListInstanceDescriptors() {
+		if NormalizeBranch(inst.Branch) != NormalizeBranch(branch) {
+			continue
 		}
-	}
-	
-	// Check for versioned instances (release-v4, pre-release-v8, etc.)
-	for _, entry := range entries {
-		if entry.IsDir() && len(entry.Name()) > len(prefix) && entry.Name()[:len(prefix)] == prefix {
-			versionStr := entry.Name()[len(prefix):]
-			var v int
-			if _, err := fmt.Sscanf(versionStr, "%d", &v); err == nil && v > 0 {
-				// Verify the game is actually installed in this version
-				if IsVersionInstalled(branch, v) {
-					versions = append(versions, v)
-				}
-			}
+		v := inst.Version
+		if inst.AutoUpdate {
+			v = 0
+		}
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		if IsVersionInstalled(branch, v) {
+			versions = append(versions, v)
 		}
 	}
+	sort.Ints(versions)
 	return versions
 }
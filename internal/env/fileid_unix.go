@@ -0,0 +1,23 @@
+//go:build !windows
+
+package env
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID identifies the inode behind a path
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+// linkedFileID returns the identity of a regular file that has more than one hard link
+func linkedFileID(path string, info os.FileInfo) (fileID, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || st.Nlink <= 1 {
+		return fileID{}, false
+	}
+	return fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true
+}
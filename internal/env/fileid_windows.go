@@ -0,0 +1,34 @@
+//go:build windows
+
+package env
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID identifies the file behind a path
+type fileID struct {
+	volume uint32
+	index  uint64
+}
+
+// linkedFileID returns the identity of a regular file that has more than one hard link
+func linkedFileID(path string, info os.FileInfo) (fileID, bool) {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return fileID{}, false
+	}
+	h, err := syscall.CreateFile(p, 0, syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return fileID{}, false
+	}
+	defer syscall.CloseHandle(h)
+
+	var data syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(h, &data); err != nil || data.NumberOfLinks <= 1 {
+		return fileID{}, false
+	}
+	return fileID{volume: data.VolumeSerialNumber, index: uint64(data.FileIndexHigh)<<32 | uint64(data.FileIndexLow)}, true
+}
@@ -0,0 +1,414 @@
+package env
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// InstanceFileName is the descriptor stored in every instance directory
+const InstanceFileName = "instance.json"
+
+// legacyLatestDir is the directory the auto-updating instance used before descriptors existed
+const legacyLatestDir = "latest"
+
+// Instance describes one game instance. It is stored as instance.json in the
+// instance directory, and the directory name is the instance ID.
+type Instance struct {
+	ID             string         `json:"id"`
+	Name           string         `json:"name"`
+	Branch         string         `json:"branch"`              // release or pre-release
+	Version        int            `json:"version"`             // Pinned build; 0 when the instance follows the latest build
+	AutoUpdate     bool           `json:"autoUpdate"`          // Follows the newest build of its branch
+	InstalledBuild int            `json:"installedBuild"`      // Build currently in game/, 0 if not installed
+	SkipBuild      int            `json:"skipBuild,omitempty"` // Build rolled back from; automatic updates wait for a newer one
+	GameFrom       string         `json:"gameFrom,omitempty"`  // Instance whose game files are used; empty when it has its own
+	Location       string         `json:"location,omitempty"`  // Where the files live when moved out of the instances directory
+	Icon           string         `json:"icon,omitempty"`
+	Notes          string         `json:"notes,omitempty"`
+	CreatedAt      string         `json:"createdAt"`              // ISO 8601 format
+	LastPlayedAt   string         `json:"lastPlayedAt,omitempty"` // ISO 8601 format
+	Launch         LaunchSettings `json:"launch"`
+}
+
+// Dir returns the directory holding the instance's files
+func (i *Instance) Dir() string {
+	if i.Location != "" {
+		return i.Location
+	}
+	return i.DescriptorDir()
+}
+
+// DescriptorDir returns the directory in the instances directory that holds instance.json.
+// It is the same as Dir unless the instance was moved elsewhere.
+func (i *Instance) DescriptorDir() string {
+	return filepath.Join(GetInstancesDir(), i.ID)
+}
+
+// GameOwner returns the ID of the instance that holds this instance's game files
+func (i *Instance) GameOwner() string {
+	if i.GameFrom != "" {
+		return i.GameFrom
+	}
+	return i.ID
+}
+
+// GameDir returns the game files the instance runs, which may be shared with other instances
+func (i *Instance) GameDir() string {
+	if i.GameFrom != "" {
+		if owner, err := GetInstance(i.GameFrom); err == nil {
+			return owner.GameDir()
+		}
+	}
+	return filepath.Join(i.Dir(), "game")
+}
+
+// UserDataDir returns the UserData directory of the instance, which is never shared
+func (i *Instance) UserDataDir() string {
+	return filepath.Join(i.Dir(), "UserData")
+}
+
+// LogsDir returns the directory holding the logs of the instance's game sessions
+func (i *Instance) LogsDir() string {
+	return filepath.Join(i.Dir(), "logs")
+}
+
+// Matches reports whether the instance owns the game files addressed by branch and version
+// Version 0 addresses the auto-updating instance of a branch
+func (i *Instance) Matches(branch string, version int) bool {
+	if i.GameFrom != "" || NormalizeBranch(i.Branch) != NormalizeBranch(branch) {
+		return false
+	}
+	if version == 0 {
+		return i.AutoUpdate
+	}
+	return !i.AutoUpdate && i.Version == version
+}
+
+// NormalizeBranch maps the spellings of a branch used across the launcher to one form
+func NormalizeBranch(branch string) string {
+	switch strings.ToLower(strings.TrimSpace(branch)) {
+	case "pre-release", "prerelease":
+		return "pre-release"
+	default:
+		return "release"
+	}
+}
+
+// instanceRegistry caches the descriptors found in the instances directory
+type instanceRegistry struct {
+	mu        sync.Mutex
+	loadedFor string // Instances directory the cache was built from
+	instances map[string]*Instance
+}
+
+var registry = &instanceRegistry{}
+
+// loadLocked (re)reads descriptors when the instances directory changed. r.mu must be held.
+func (r *instanceRegistry) loadLocked() {
+	dir := GetInstancesDir()
+	if r.instances != nil && r.loadedFor == dir {
+		return
+	}
+	r.loadedFor = dir
+	r.instances = make(map[string]*Instance)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		// Dot directories are work areas such as imports in progress
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		inst, err := ReadInstanceDescriptor(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		r.instances[inst.ID] = inst
+	}
+}
+
+// copyLocked returns a copy of a cached descriptor. Instances sharing game files
+// report the build of the instance that holds them. r.mu must be held.
+func (r *instanceRegistry) copyLocked(inst *Instance) *Instance {
+	c := *inst
+	if owner, ok := r.instances[c.GameFrom]; ok && c.GameFrom != "" {
+		c.InstalledBuild = owner.InstalledBuild
+	}
+	return &c
+}
+
+// ReloadInstances drops cached descriptors so the next lookup reads them from disk
+func ReloadInstances() {
+	registry.mu.Lock()
+	registry.instances = nil
+	registry.mu.Unlock()
+}
+
+// ReadInstanceDescriptor reads instance.json from an instance directory
+// The ID always follows the directory name, so moved or renamed folders stay consistent.
+func ReadInstanceDescriptor(dir string) (*Instance, error) {
+	data, err := os.ReadFile(filepath.Join(dir, InstanceFileName))
+	if err != nil {
+		return nil, err
+	}
+	var inst Instance
+	if err := json.Unmarshal(data, &inst); err != nil {
+		return nil, fmt.Errorf("invalid %s in %s: %w", InstanceFileName, dir, err)
+	}
+	inst.ID = filepath.Base(dir)
+	inst.Branch = NormalizeBranch(inst.Branch)
+	return &inst, nil
+}
+
+// writeDescriptor writes instance.json atomically
+func writeDescriptor(inst *Instance) error {
+	return writeDescriptorTo(inst.DescriptorDir(), inst)
+}
+
+// writeDescriptorTo writes instance.json atomically into dir
+func writeDescriptorTo(dir string, inst *Instance) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(inst, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, InstanceFileName)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// ListInstanceDescriptors returns every instance, oldest first
+func ListInstanceDescriptors() []*Instance {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.loadLocked()
+
+	instances := make([]*Instance, 0, len(registry.instances))
+	for _, inst := range registry.instances {
+		instances = append(instances, registry.copyLocked(inst))
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		if instances[i].CreatedAt != instances[j].CreatedAt {
+			return instances[i].CreatedAt < instances[j].CreatedAt
+		}
+		return instances[i].ID < instances[j].ID
+	})
+	return instances
+}
+
+// GetInstance returns the instance with the given ID
+func GetInstance(id string) (*Instance, error) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.loadLocked()
+
+	inst, ok := registry.instances[id]
+	if !ok {
+		return nil, fmt.Errorf("instance %q not found", id)
+	}
+	return registry.copyLocked(inst), nil
+}
+
+// FindInstance returns the instance holding the game files for branch and version, or nil if there is none
+// Instances that share another instance's game files are never returned. When several match, the oldest one wins.
+func FindInstance(branch string, version int) *Instance {
+	for _, inst := range ListInstanceDescriptors() {
+		if inst.Matches(branch, version) {
+			return inst
+		}
+	}
+	return nil
+}
+
+// SaveInstance writes an instance's descriptor
+func SaveInstance(inst *Instance) error {
+	inst.Branch = NormalizeBranch(inst.Branch)
+	if err := writeDescriptor(inst); err != nil {
+		return fmt.Errorf("failed to save instance %s: %w", inst.ID, err)
+	}
+
+	registry.mu.Lock()
+	registry.loadLocked()
+	c := *inst
+	registry.instances[inst.ID] = &c
+	registry.mu.Unlock()
+	return nil
+}
+
+// UpdateInstance loads an instance, applies fn and saves it
+func UpdateInstance(id string, fn func(inst *Instance)) (*Instance, error) {
+	inst, err := GetInstance(id)
+	if err != nil {
+		return nil, err
+	}
+	fn(inst)
+	if err := SaveInstance(inst); err != nil {
+		return nil, err
+	}
+	return inst, nil
+}
+
+// forgetInstance drops a deleted instance from the cache
+func forgetInstance(id string) {
+	registry.mu.Lock()
+	delete(registry.instances, id)
+	registry.mu.Unlock()
+}
+
+// defaultInstanceName returns the display name of a new instance
+func defaultInstanceName(branch string, version int) string {
+	label := "Release"
+	if NormalizeBranch(branch) == "pre-release" {
+		label = "Pre-release"
+	}
+	if version == 0 {
+		return label + " (latest)"
+	}
+	return fmt.Sprintf("%s v%d", label, version)
+}
+
+// defaultInstanceID returns the directory name for a new instance of branch and version
+func defaultInstanceID(branch string, version int) string {
+	if version == 0 {
+		return NormalizeBranch(branch) + "-latest"
+	}
+	return fmt.Sprintf("%s-v%d", NormalizeBranch(branch), version)
+}
+
+// UniqueInstanceID returns base, or base with a numeric suffix if that directory is taken
+func UniqueInstanceID(base string) string {
+	id := base
+	for n := 2; ; n++ {
+		if _, err := os.Stat(filepath.Join(GetInstancesDir(), id)); os.IsNotExist(err) {
+			return id
+		}
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// NewInstance builds a descriptor for a new instance without saving it
+func NewInstance(id string, name string, branch string, version int) *Instance {
+	if name == "" {
+		name = defaultInstanceName(branch, version)
+	}
+	return &Instance{
+		ID:         id,
+		Name:       name,
+		Branch:     NormalizeBranch(branch),
+		Version:    version,
+		AutoUpdate: version == 0,
+		CreatedAt:  time.Now().Format(time.RFC3339),
+	}
+}
+
+// EnsureInstance returns the instance addressed by branch and version, creating it if needed
+func EnsureInstance(branch string, version int) (*Instance, error) {
+	if inst := FindInstance(branch, version); inst != nil {
+		return inst, nil
+	}
+
+	inst := NewInstance(UniqueInstanceID(defaultInstanceID(branch, version)), "", branch, version)
+	if err := SaveInstance(inst); err != nil {
+		return nil, err
+	}
+	logger.Info("Created instance", "instance", inst.ID, "name", inst.Name)
+	return inst, nil
+}
+
+// MarkInstancePlayed records that an instance was just launched
+func MarkInstancePlayed(id string) error {
+	_, err := UpdateInstance(id, func(inst *Instance) {
+		inst.LastPlayedAt = time.Now().Format(time.RFC3339)
+	})
+	return err
+}
+
+// MigrateInstances writes descriptors for instance directories created before instance.json existed.
+// The old shared "latest" directory becomes the auto-updating instance of latestBranch.
+func MigrateInstances(latestBranch string) {
+	dir := GetInstancesDir()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		instanceDir := filepath.Join(dir, entry.Name())
+		if _, err := os.Stat(filepath.Join(instanceDir, InstanceFileName)); err == nil {
+			continue
+		}
+
+		inst := legacyInstance(entry.Name(), latestBranch)
+		if inst == nil {
+			continue
+		}
+
+		if info, err := entry.Info(); err == nil {
+			inst.CreatedAt = info.ModTime().Format(time.RFC3339)
+		}
+		inst.InstalledBuild = readLegacyBuild(instanceDir)
+		if inst.InstalledBuild == 0 && !inst.AutoUpdate {
+			// Pinned instances were always installed at their own version
+			if _, err := os.Stat(filepath.Join(instanceDir, "game", "Client")); err == nil {
+				inst.InstalledBuild = inst.Version
+			}
+		}
+
+		if err := writeDescriptor(inst); err != nil {
+			logger.Warn("Failed to migrate instance", "dir", entry.Name(), "error", err)
+			continue
+		}
+		logger.Info("Migrated instance", "dir", entry.Name(), "file", InstanceFileName, "name", inst.Name)
+	}
+
+	ReloadInstances()
+}
+
+// legacyInstance builds a descriptor from an old-style directory name, or nil if it is not an instance
+func legacyInstance(name string, latestBranch string) *Instance {
+	if name == legacyLatestDir {
+		return NewInstance(name, "", latestBranch, 0)
+	}
+	for _, branch := range []string{"pre-release", "release"} {
+		prefix := branch + "-v"
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		version, err := strconv.Atoi(name[len(prefix):])
+		if err != nil || version <= 0 {
+			return nil
+		}
+		return NewInstance(name, "", branch, version)
+	}
+	return nil
+}
+
+// readLegacyBuild reads the version.txt marker older launchers kept in each instance
+func readLegacyBuild(instanceDir string) int {
+	data, err := os.ReadFile(filepath.Join(instanceDir, "version.txt"))
+	if err != nil {
+		return 0
+	}
+	build, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || build < 0 {
+		return 0
+	}
+	return build
+}
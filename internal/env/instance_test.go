@@ -0,0 +1,100 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useTempAppDir points the app directory at a fresh temporary directory
+func useTempAppDir(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("LOCALAPPDATA", dir)
+	SetCustomInstanceDir("")
+	ReloadInstances()
+	t.Cleanup(ReloadInstances)
+}
+
+func TestReadLegacyBuild(t *testing.T) {
+	tests := []struct {
+		name   string
+		marker *string // nil leaves version.txt out
+		want   int
+	}{
+		{name: "no marker", want: 0},
+		{name: "build number", marker: ptr("7"), want: 7},
+		{name: "surrounding whitespace", marker: ptr(" 12\n"), want: 12},
+		{name: "not a number", marker: ptr("latest"), want: 0},
+		{name: "negative", marker: ptr("-3"), want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.marker != nil {
+				os.WriteFile(filepath.Join(dir, "version.txt"), []byte(*tt.marker), 0644)
+			}
+			if got := readLegacyBuild(dir); got != tt.want {
+				t.Errorf("readLegacyBuild = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string { return &s }
+
+func TestMigrateInstances(t *testing.T) {
+	tests := []struct {
+		dir        string
+		marker     string // Content of version.txt, if any
+		client     bool   // The directory has game/Client
+		want       bool   // A descriptor is written
+		branch     string
+		version    int
+		autoUpdate bool
+		installed  int
+	}{
+		{dir: "latest", marker: "9", want: true, branch: "pre-release", autoUpdate: true, installed: 9},
+		{dir: "release-v5", client: true, want: true, branch: "release", version: 5, installed: 5},
+		{dir: "release-v6", want: true, branch: "release", version: 6},
+		{dir: "pre-release-v3", marker: "4", want: true, branch: "pre-release", version: 3, installed: 4},
+		{dir: "release-vX"},
+		{dir: "screenshots"},
+	}
+
+	useTempAppDir(t)
+	for _, tt := range tests {
+		dir := filepath.Join(GetInstancesDir(), tt.dir)
+		os.MkdirAll(dir, 0755)
+		if tt.marker != "" {
+			os.WriteFile(filepath.Join(dir, "version.txt"), []byte(tt.marker), 0644)
+		}
+		if tt.client {
+			os.MkdirAll(filepath.Join(dir, "game", "Client"), 0755)
+		}
+	}
+
+	MigrateInstances("pre-release")
+
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			_, err := os.Stat(filepath.Join(GetInstancesDir(), tt.dir, InstanceFileName))
+			if (err == nil) != tt.want {
+				t.Fatalf("descriptor written = %v, want %v", err == nil, tt.want)
+			}
+			if !tt.want {
+				return
+			}
+			inst, err := GetInstance(tt.dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if inst.Branch != tt.branch || inst.Version != tt.version || inst.AutoUpdate != tt.autoUpdate || inst.InstalledBuild != tt.installed {
+				t.Errorf("migrated to %s v%d auto=%v build %d, want %s v%d auto=%v build %d",
+					inst.Branch, inst.Version, inst.AutoUpdate, inst.InstalledBuild,
+					tt.branch, tt.version, tt.autoUpdate, tt.installed)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package env
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// LaunchSettings is the launch profile of an instance: options applied when the game is started
+type LaunchSettings struct {
+	ExtraArgs   []string          `json:"extraArgs,omitempty"`   // Appended to the client command line
+	Env         map[string]string `json:"env,omitempty"`         // Environment overrides; an empty value removes the variable
+	WorkingDir  string            `json:"workingDir,omitempty"`  // Directory the client starts in; relative paths are inside the instance
+	JavaOptions []string          `json:"javaOptions,omitempty"` // JVM options such as -XX:+UseG1GC, applied after the heap sizes
+	MinHeapMB   int               `json:"minHeapMB,omitempty"`   // -Xms, 0 leaves the JVM default
+	MaxHeapMB   int               `json:"maxHeapMB,omitempty"`   // -Xmx, 0 leaves the JVM default
+	Wrappers    []LaunchWrapper   `json:"wrappers,omitempty"`    // Linux only: commands placed in front of the client, outermost first
+	// AllowConcurrent lets a second session of the instance start while one is running.
+	// Sessions then share the instance's UserData, so it is off by default.
+	AllowConcurrent bool `json:"allowConcurrent,omitempty"`
+}
+
+// LaunchWrapper is a command the client is started through, such as gamemoderun or mangohud
+type LaunchWrapper struct {
+	Command  string   `json:"command"`            // Name looked up on PATH, or an absolute path
+	Args     []string `json:"args,omitempty"`     // Arguments placed between the wrapper and what it runs
+	Disabled bool     `json:"disabled,omitempty"` // Kept in the profile but skipped at launch
+}
+
+// ActiveWrappers returns the wrappers that are applied at launch
+func (s LaunchSettings) ActiveWrappers() []LaunchWrapper {
+	var active []LaunchWrapper
+	for _, w := range s.Wrappers {
+		if !w.Disabled {
+			active = append(active, w)
+		}
+	}
+	return active
+}
+
+// Validate reports the first setting that cannot be applied to a launch
+func (s LaunchSettings) Validate() error {
+	for key, value := range s.Env {
+		if key == "" || strings.ContainsAny(key, "=\x00") {
+			return fmt.Errorf("invalid environment variable name %q", key)
+		}
+		if strings.ContainsRune(value, 0) {
+			return fmt.Errorf("environment variable %s contains a NUL character", key)
+		}
+	}
+	for _, arg := range s.ExtraArgs {
+		if strings.ContainsRune(arg, 0) {
+			return fmt.Errorf("argument %q contains a NUL character", arg)
+		}
+	}
+	// JVMs split JAVA_TOOL_OPTIONS on whitespace, so options cannot contain any
+	for _, opt := range s.JavaOptions {
+		if !strings.HasPrefix(opt, "-") || strings.ContainsAny(opt, " \t\r\n") {
+			return fmt.Errorf("invalid Java option %q", opt)
+		}
+	}
+	for _, w := range s.Wrappers {
+		if strings.TrimSpace(w.Command) == "" {
+			return fmt.Errorf("wrapper command cannot be empty")
+		}
+		if strings.ContainsAny(w.Command, "\x00\r\n") {
+			return fmt.Errorf("invalid wrapper command %q", w.Command)
+		}
+	}
+	if s.MinHeapMB < 0 || s.MaxHeapMB < 0 {
+		return fmt.Errorf("heap sizes cannot be negative")
+	}
+	if s.MinHeapMB > 0 && s.MaxHeapMB > 0 && s.MinHeapMB > s.MaxHeapMB {
+		return fmt.Errorf("minimum heap (%d MB) is larger than maximum heap (%d MB)", s.MinHeapMB, s.MaxHeapMB)
+	}
+	return nil
+}
+
+// JVMOptions returns the heap sizes followed by the extra Java options, in the order the JVM applies them
+func (s LaunchSettings) JVMOptions() []string {
+	var opts []string
+	if s.MinHeapMB > 0 {
+		opts = append(opts, fmt.Sprintf("-Xms%dm", s.MinHeapMB))
+	}
+	if s.MaxHeapMB > 0 {
+		opts = append(opts, fmt.Sprintf("-Xmx%dm", s.MaxHeapMB))
+	}
+	return append(opts, s.JavaOptions...)
+}
+
+// LaunchWorkingDir returns the directory the instance's client starts in, or fallback when the profile sets none
+func (i *Instance) LaunchWorkingDir(fallback string) string {
+	dir := strings.TrimSpace(i.Launch.WorkingDir)
+	if dir == "" {
+		return fallback
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(i.Dir(), dir)
+	}
+	return filepath.Clean(dir)
+}
@@ -0,0 +1,220 @@
+package env
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"HyPrism/internal/util"
+)
+
+// instanceIDFromName turns a display name into a directory-safe instance ID
+func instanceIDFromName(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+	id := strings.TrimRight(b.String(), "-")
+	if len(id) > 40 {
+		id = strings.TrimRight(id[:40], "-")
+	}
+	if id == "" {
+		id = "instance"
+	}
+	return id
+}
+
+// CreateInstance creates a named instance of branch and version.
+// If another instance already holds that build, the new one shares its game files
+// and only gets its own UserData and Mods.
+func CreateInstance(name string, branch string, version int) (*Instance, error) {
+	inst := NewInstance(UniqueInstanceID(instanceIDFromName(name)), name, branch, version)
+	if owner := FindInstance(branch, version); owner != nil {
+		inst.GameFrom = owner.ID
+	}
+
+	if err := createInstanceDirs(inst.Dir()); err != nil {
+		return nil, fmt.Errorf("failed to create instance directory: %w", err)
+	}
+	if inst.GameFrom != "" {
+		// Shared instances never hold game files of their own
+		os.Remove(filepath.Join(inst.Dir(), "game"))
+	}
+	if err := SaveInstance(inst); err != nil {
+		os.RemoveAll(inst.Dir())
+		return nil, err
+	}
+
+	if inst.GameFrom != "" {
+		logger.Info("Created instance", "instance", inst.ID, "name", inst.Name, "gameFrom", inst.GameFrom)
+	} else {
+		logger.Info("Created instance", "instance", inst.ID, "name", inst.Name)
+	}
+	return inst, nil
+}
+
+// CloneInstance copies an instance's UserData, mods, saves and settings into a new instance.
+// The clone shares the game files of the original.
+func CloneInstance(id string, newName string) (*Instance, error) {
+	src, err := GetInstance(id)
+	if err != nil {
+		return nil, err
+	}
+
+	clone := NewInstance(UniqueInstanceID(instanceIDFromName(newName)), newName, src.Branch, src.Version)
+	clone.AutoUpdate = src.AutoUpdate
+	clone.GameFrom = src.GameOwner()
+	clone.Icon = src.Icon
+	clone.Notes = src.Notes
+	clone.Launch = src.Launch
+
+	if err := os.MkdirAll(clone.Dir(), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create instance directory: %w", err)
+	}
+	for _, name := range []string{"UserData", "mods", "saves"} {
+		from := filepath.Join(src.Dir(), name)
+		if _, err := os.Stat(from); err != nil {
+			continue
+		}
+		if err := util.CopyDir(from, filepath.Join(clone.Dir(), name)); err != nil {
+			os.RemoveAll(clone.Dir())
+			return nil, fmt.Errorf("failed to copy %s: %w", name, err)
+		}
+	}
+	if err := createInstanceDirs(clone.Dir()); err != nil {
+		os.RemoveAll(clone.Dir())
+		return nil, err
+	}
+	os.Remove(filepath.Join(clone.Dir(), "game"))
+
+	// Mod manifests record absolute paths, so point them at the clone's copies
+	manifest := filepath.Join(clone.UserDataDir(), "Mods", "manifest.json")
+	if data, err := os.ReadFile(manifest); err == nil {
+		data = []byte(strings.ReplaceAll(string(data), jsonPath(src.Dir()), jsonPath(clone.Dir())))
+		os.WriteFile(manifest, data, 0644)
+	}
+
+	if err := SaveInstance(clone); err != nil {
+		os.RemoveAll(clone.Dir())
+		return nil, err
+	}
+	logger.Info("Cloned instance", "from", src.ID, "instance", clone.ID, "name", clone.Name)
+	return clone, nil
+}
+
+// jsonPath returns a path as it appears inside a JSON string
+func jsonPath(path string) string {
+	return strings.ReplaceAll(path, `\`, `\\`)
+}
+
+// RenameInstance changes the display name of an instance
+// The ID and directory stay the same so running games and references keep working.
+func RenameInstance(id string, newName string) (*Instance, error) {
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return nil, fmt.Errorf("instance name cannot be empty")
+	}
+	return UpdateInstance(id, func(inst *Instance) {
+		inst.Name = newName
+	})
+}
+
+// DeleteInstance removes an instance. If other instances share its game files, they are
+// handed to the oldest of them first. With keepUserData the UserData directory is moved to
+// the kept folder of the app directory, and that location is returned.
+func DeleteInstance(id string, keepUserData bool) (string, error) {
+	inst, err := GetInstance(id)
+	if err != nil {
+		return "", err
+	}
+
+	if err := handOverGame(inst); err != nil {
+		return "", err
+	}
+
+	kept := ""
+	if keepUserData {
+		if _, err := os.Stat(inst.UserDataDir()); err == nil {
+			kept = filepath.Join(GetDefaultAppDir(), "kept", fmt.Sprintf("%s-%s", inst.ID, time.Now().Format("20060102-150405")))
+			if err := moveTree(context.Background(), inst.UserDataDir(), filepath.Join(kept, "UserData"), nil); err != nil {
+				return "", fmt.Errorf("failed to keep UserData: %w", err)
+			}
+			if data, err := json.MarshalIndent(inst, "", "  "); err == nil {
+				os.WriteFile(filepath.Join(kept, InstanceFileName), data, 0644)
+			}
+		}
+	}
+
+	if err := os.RemoveAll(inst.Dir()); err != nil {
+		return kept, fmt.Errorf("failed to delete instance files: %w", err)
+	}
+	if inst.DescriptorDir() != inst.Dir() {
+		if err := os.RemoveAll(inst.DescriptorDir()); err != nil {
+			return kept, fmt.Errorf("failed to delete instance descriptor: %w", err)
+		}
+	}
+	forgetInstance(id)
+	InvalidateInstanceSizes("")
+
+	if kept != "" {
+		logger.Info("Deleted instance", "instance", inst.ID, "name", inst.Name, "keptUserData", kept)
+	} else {
+		logger.Info("Deleted instance", "instance", inst.ID, "name", inst.Name)
+	}
+	return kept, nil
+}
+
+// handOverGame moves the game files of an instance that is about to be deleted to the
+// oldest instance sharing them, and points the other sharers at that one
+func handOverGame(inst *Instance) error {
+	var sharers []*Instance
+	for _, other := range ListInstanceDescriptors() {
+		if other.GameFrom == inst.ID {
+			sharers = append(sharers, other)
+		}
+	}
+	if len(sharers) == 0 {
+		return nil
+	}
+
+	heir := sharers[0]
+	for _, name := range []string{"game", "generations"} {
+		from := filepath.Join(inst.Dir(), name)
+		if _, err := os.Stat(from); err != nil {
+			continue
+		}
+		to := filepath.Join(heir.Dir(), name)
+		os.RemoveAll(to)
+		if err := moveTree(context.Background(), from, to, nil); err != nil {
+			return fmt.Errorf("failed to hand game files to %s: %w", heir.ID, err)
+		}
+	}
+
+	if _, err := UpdateInstance(heir.ID, func(i *Instance) {
+		i.GameFrom = ""
+		i.InstalledBuild = inst.InstalledBuild
+	}); err != nil {
+		return err
+	}
+	for _, other := range sharers[1:] {
+		if _, err := UpdateInstance(other.ID, func(i *Instance) {
+			i.GameFrom = heir.ID
+		}); err != nil {
+			return err
+		}
+	}
+	logger.Info("Game files handed over", "from", inst.ID, "to", heir.ID)
+	return nil
+}
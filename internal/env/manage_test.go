@@ -0,0 +1,135 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreateInstanceSharesGame(t *testing.T) {
+	useTempAppDir(t)
+
+	tests := []struct {
+		name     string
+		branch   string
+		version  int
+		gameFrom string // ID of the instance expected to own the game files, "" for none
+	}{
+		{name: "Main", branch: "release", version: 0},
+		{name: "Second", branch: "release", version: 0, gameFrom: "main"},
+		{name: "Pinned", branch: "release", version: 5},
+		{name: "Pinned Again", branch: "release", version: 5, gameFrom: "pinned"},
+		{name: "Other Branch", branch: "pre-release", version: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inst, err := CreateInstance(tt.name, tt.branch, tt.version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if inst.GameFrom != tt.gameFrom {
+				t.Fatalf("GameFrom = %q, want %q", inst.GameFrom, tt.gameFrom)
+			}
+			_, err = os.Stat(filepath.Join(inst.Dir(), "game"))
+			if hasOwn := err == nil; hasOwn != (tt.gameFrom == "") {
+				t.Errorf("own game directory = %v, want %v", hasOwn, tt.gameFrom == "")
+			}
+			if tt.gameFrom != "" && inst.GameDir() != filepath.Join(GetInstancesDir(), tt.gameFrom, "game") {
+				t.Errorf("GameDir = %s, want the game of %s", inst.GameDir(), tt.gameFrom)
+			}
+			if owner := FindInstance(tt.branch, tt.version); owner == nil || owner.GameOwner() != inst.GameOwner() {
+				t.Errorf("FindInstance does not resolve to the owner of %s", inst.ID)
+			}
+		})
+	}
+}
+
+func TestCloneInstance(t *testing.T) {
+	useTempAppDir(t)
+	owner, err := CreateInstance("Main", "release", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared, err := CreateInstance("Shared", "release", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mods := filepath.Join(shared.UserDataDir(), "Mods")
+	os.MkdirAll(mods, 0755)
+	os.WriteFile(filepath.Join(mods, "manifest.json"), []byte(`[{"filePath": "`+jsonPath(filepath.Join(shared.Dir(), "UserData", "Mods", "a.jar"))+`"}]`), 0644)
+	os.WriteFile(filepath.Join(shared.UserDataDir(), "options.json"), []byte("{}"), 0644)
+
+	tests := []struct {
+		name string
+		src  *Instance
+	}{
+		{name: "Copy Of Main", src: owner},
+		{name: "Copy Of Shared", src: shared},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clone, err := CloneInstance(tt.src.ID, tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Clones of a sharing instance share with the owner, never with another sharer
+			if clone.GameFrom != owner.ID {
+				t.Errorf("GameFrom = %q, want %q", clone.GameFrom, owner.ID)
+			}
+			if _, err := os.Stat(filepath.Join(clone.Dir(), "game")); err == nil {
+				t.Error("the clone got a game directory of its own")
+			}
+			if tt.src != shared {
+				return
+			}
+			if _, err := os.Stat(filepath.Join(clone.UserDataDir(), "options.json")); err != nil {
+				t.Error("UserData was not copied")
+			}
+			data, _ := os.ReadFile(filepath.Join(clone.UserDataDir(), "Mods", "manifest.json"))
+			if strings.Contains(string(data), jsonPath(shared.Dir())) || !strings.Contains(string(data), jsonPath(clone.Dir())) {
+				t.Errorf("mod manifest still points at the source: %s", data)
+			}
+		})
+	}
+}
+
+func TestDeleteInstanceHandsOverGame(t *testing.T) {
+	useTempAppDir(t)
+	owner, err := CreateInstance("Main", "release", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetInstanceBuild(owner.ID, "release", 0, 7); err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(filepath.Join(owner.GameDir(), "Client"), 0755)
+	os.WriteFile(filepath.Join(owner.GameDir(), "Client", "client"), []byte("7"), 0755)
+	heir, _ := CreateInstance("Second", "release", 0)
+	other, _ := CreateInstance("Third", "release", 0)
+	if heir.GameFrom != owner.ID || other.GameFrom != owner.ID {
+		t.Fatalf("instances do not share the game of %s", owner.ID)
+	}
+
+	if _, err := DeleteInstance(owner.ID, false); err != nil {
+		t.Fatal(err)
+	}
+
+	heir, err = GetInstance(heir.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if heir.GameFrom != "" || heir.InstalledBuild != 7 {
+		t.Errorf("heir GameFrom = %q, build %d; want its own game at build 7", heir.GameFrom, heir.InstalledBuild)
+	}
+	if data, _ := os.ReadFile(filepath.Join(heir.GameDir(), "Client", "client")); string(data) != "7" {
+		t.Error("the game files were not moved to the heir")
+	}
+	// The remaining sharers follow the game files to their new owner
+	if other, _ = GetInstance(other.ID); other.GameFrom != heir.ID {
+		t.Errorf("other sharer GameFrom = %q, want %q", other.GameFrom, heir.ID)
+	}
+	if owner := FindInstance("release", 0); owner == nil || owner.ID != heir.ID {
+		t.Error("FindInstance does not resolve to the heir")
+	}
+}
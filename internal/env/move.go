@@ -0,0 +1,333 @@
+package env
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// moveJournalName records a move in progress in the instance's descriptor directory
+const moveJournalName = ".moving"
+
+// moveTmpSuffix marks a file that is still being copied
+const moveTmpSuffix = ".moving-tmp"
+
+// instanceMove is the journal of a move, so an interrupted one can be finished later
+type instanceMove struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// PendingMove is a move that was interrupted and can be resumed
+type PendingMove struct {
+	ID string `json:"id"`
+	To string `json:"to"`
+}
+
+// MoveInstance moves an instance's files into destDir/<id>, for example to an external drive.
+// Moving to the instances directory brings the instance back to its default place.
+// Files are renamed where possible and copied across filesystems; an interrupted move
+// is resumed by calling MoveInstance again with the same destination.
+func MoveInstance(ctx context.Context, id string, destDir string, progress func(copied, total int64, currentFile string)) (*Instance, error) {
+	inst, err := GetInstance(id)
+	if err != nil {
+		return nil, err
+	}
+
+	destDir, err = filepath.Abs(destDir)
+	if err != nil {
+		return nil, err
+	}
+	to := filepath.Join(destDir, inst.ID)
+	if samePath(destDir, GetInstancesDir()) {
+		to = inst.DescriptorDir()
+	}
+
+	journal := filepath.Join(inst.DescriptorDir(), moveJournalName)
+	move := instanceMove{From: inst.Dir(), To: to}
+	if data, err := os.ReadFile(journal); err == nil {
+		var pending instanceMove
+		if json.Unmarshal(data, &pending) == nil && pending.To != "" {
+			if !samePath(pending.To, to) {
+				return nil, fmt.Errorf("instance %s is still being moved to %s; finish that move first", id, pending.To)
+			}
+			move = pending
+			logger.Info("Resuming instance move", "instance", id, "to", to)
+		}
+	}
+
+	if samePath(move.From, move.To) {
+		os.Remove(journal)
+		return inst, nil
+	}
+	if isWithin(move.To, move.From) {
+		return nil, fmt.Errorf("cannot move an instance into itself")
+	}
+	if entries, err := os.ReadDir(move.To); err == nil && len(entries) > 0 && !samePath(move.To, inst.DescriptorDir()) {
+		if _, err := os.Stat(journal); err != nil {
+			return nil, fmt.Errorf("destination %s is not empty", move.To)
+		}
+	}
+
+	if data, err := json.MarshalIndent(move, "", "  "); err == nil {
+		if err := os.WriteFile(journal, data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write move journal: %w", err)
+		}
+	}
+	if err := os.MkdirAll(move.To, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create destination: %w", err)
+	}
+
+	logger.Info("Moving instance", "instance", id, "from", move.From, "to", move.To)
+	entries, err := os.ReadDir(move.From)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		// The descriptor and journal stay in the instances directory
+		if samePath(move.From, inst.DescriptorDir()) && (name == InstanceFileName || name == moveJournalName) {
+			continue
+		}
+		names = append(names, name)
+	}
+	total := dirSizes(move.From, names)
+
+	var copied int64
+	links := make(hardLinks)
+	for _, name := range names {
+		err := moveTreeLinked(ctx, filepath.Join(move.From, name), filepath.Join(move.To, name), links, func(n int64, file string) {
+			copied += n
+			if progress != nil {
+				progress(copied, total, file)
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to move %s: %w", name, err)
+		}
+	}
+	for _, name := range names {
+		if err := os.RemoveAll(filepath.Join(move.From, name)); err != nil {
+			return nil, fmt.Errorf("failed to remove %s after moving it: %w", name, err)
+		}
+	}
+
+	updated, err := UpdateInstance(id, func(i *Instance) {
+		if samePath(move.To, i.DescriptorDir()) {
+			i.Location = ""
+		} else {
+			i.Location = move.To
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !samePath(move.From, inst.DescriptorDir()) {
+		os.Remove(move.From)
+	}
+	os.Remove(journal)
+	InvalidateInstanceSizes(id)
+
+	logger.Info("Moved instance", "instance", id, "to", move.To)
+	return updated, nil
+}
+
+// PendingMoves returns moves that were interrupted before they finished
+func PendingMoves() []PendingMove {
+	var pending []PendingMove
+	for _, inst := range ListInstanceDescriptors() {
+		data, err := os.ReadFile(filepath.Join(inst.DescriptorDir(), moveJournalName))
+		if err != nil {
+			continue
+		}
+		var move instanceMove
+		if json.Unmarshal(data, &move) == nil && move.To != "" {
+			pending = append(pending, PendingMove{ID: inst.ID, To: move.To})
+		}
+	}
+	return pending
+}
+
+// moveTree moves src to dst, renaming when both are on one filesystem and copying otherwise.
+// Copies skip files that already arrived, so an interrupted move continues where it stopped,
+// and keep files hard-linked to each other linked, so shared game files are copied once.
+// copied, if set, is called with the bytes of each file as it is moved.
+func moveTree(ctx context.Context, src, dst string, copied func(n int64, file string)) error {
+	if err := moveTreeLinked(ctx, src, dst, make(hardLinks), copied); err != nil {
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// moveTreeLinked is moveTree for several trees moved together. links is shared between them,
+// so files hard-linked across the trees stay linked, and a copied src is left for the caller
+// to remove once every tree has arrived: removing it earlier would unlink the files it shares.
+func moveTreeLinked(ctx context.Context, src, dst string, links hardLinks, copied func(n int64, file string)) error {
+	if _, err := os.Lstat(dst); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(src, dst); err == nil {
+			if copied != nil {
+				copied(dirSize(dst), dst)
+			}
+			return nil
+		}
+	}
+
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			os.Remove(target)
+			return os.Symlink(link, target)
+		case strings.HasSuffix(path, moveTmpSuffix):
+			return nil
+		}
+
+		if links.link(path, target, info) {
+			if copied != nil {
+				copied(0, rel)
+			}
+			return nil
+		}
+		if done, err := os.Stat(target); err == nil && done.Size() == info.Size() && done.ModTime().Equal(info.ModTime()) {
+			if copied != nil {
+				copied(info.Size(), rel)
+			}
+			return nil
+		}
+		if err := copyFileExact(path, target, info); err != nil {
+			return err
+		}
+		if copied != nil {
+			copied(info.Size(), rel)
+		}
+		return nil
+	})
+}
+
+// copyFileExact copies a file through a temporary name and keeps its mode and modification time,
+// which is how an interrupted copy recognizes files that are already complete
+func copyFileExact(src, dst string, info os.FileInfo) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := dst + moveTmpSuffix
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chtimes(tmp, info.ModTime(), info.ModTime()); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// hardLinks maps each hard-linked file seen during a copy to its copy, so the other links
+// to the same file are linked to that copy instead of being copied again
+type hardLinks map[fileID]string
+
+// link makes target a hard link to the copy of path when another link to the same file was
+// copied before. It reports false when the file has to be copied: it is not hard-linked, it
+// is the first of its links, or the destination does not support hard links.
+func (h hardLinks) link(path, target string, info os.FileInfo) bool {
+	id, ok := linkedFileID(path, info)
+	if !ok {
+		return false
+	}
+	first, seen := h[id]
+	if !seen {
+		h[id] = target
+		return false
+	}
+
+	firstInfo, err := os.Stat(first)
+	if err != nil {
+		return false
+	}
+	if done, err := os.Stat(target); err == nil {
+		if os.SameFile(done, firstInfo) {
+			return true
+		}
+		os.Remove(target)
+	}
+	return os.Link(first, target) == nil
+}
+
+// dirSize returns the disk usage of the regular files below path; a file with several
+// hard links below path is counted once
+func dirSize(path string) int64 {
+	return dirSizes(path, []string{"."})
+}
+
+// dirSizes is dirSize for the entries names of dir together, counting files hard-linked
+// across them once
+func dirSizes(dir string, names []string) int64 {
+	var size int64
+	seen := make(map[fileID]bool)
+	for _, name := range names {
+		filepath.Walk(filepath.Join(dir, name), func(file string, info os.FileInfo, err error) error {
+			if err != nil || !info.Mode().IsRegular() {
+				return nil
+			}
+			if id, ok := linkedFileID(file, info); ok {
+				if seen[id] {
+					return nil
+				}
+				seen[id] = true
+			}
+			size += info.Size()
+			return nil
+		})
+	}
+	return size
+}
+
+func samePath(a, b string) bool {
+	return filepath.Clean(a) == filepath.Clean(b)
+}
+
+// isWithin reports whether path is inside dir
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
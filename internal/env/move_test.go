@@ -0,0 +1,53 @@
+package env
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCopyKeepsHardLinks(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	os.MkdirAll(filepath.Join(src, "game", "Client"), 0755)
+	os.MkdirAll(filepath.Join(src, "generations", "1"), 0755)
+	data := filepath.Join(src, "game", "Client", "data")
+	if err := os.WriteFile(data, []byte("shared"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(data, filepath.Join(src, "generations", "1", "data")); err != nil {
+		t.Skip("hard links are not supported:", err)
+	}
+	os.WriteFile(filepath.Join(src, "game", "Client", "other"), []byte("own"), 0644)
+
+	if got, want := dirSize(src), int64(len("shared")+len("own")); got != want {
+		t.Errorf("dirSize = %d, want %d", got, want)
+	}
+
+	// Existing target directories make the move copy, as it does across volumes
+	dst := filepath.Join(t.TempDir(), "dst")
+	os.MkdirAll(filepath.Join(dst, "game"), 0755)
+	os.MkdirAll(filepath.Join(dst, "generations"), 0755)
+	links := make(hardLinks)
+	for _, name := range []string{"game", "generations"} {
+		err := moveTreeLinked(context.Background(), filepath.Join(src, name), filepath.Join(dst, name), links, func(int64, string) {})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a, err := os.Stat(filepath.Join(dst, "game", "Client", "data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.Stat(filepath.Join(dst, "generations", "1", "data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(a, b) {
+		t.Error("hard-linked files were copied apart")
+	}
+	if got, _ := os.ReadFile(filepath.Join(dst, "generations", "1", "data")); string(got) != "shared" {
+		t.Errorf("linked file holds %q", got)
+	}
+}
@@ -0,0 +1,496 @@
+package env
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RelocationMode says what happens to existing instances when the instances directory changes
+type RelocationMode string
+
+const (
+	RelocateMove RelocationMode = "move" // Instances follow the directory; old copies are removed once verified
+	RelocateCopy RelocationMode = "copy" // Instances are copied and the originals stay where they are
+	RelocateNone RelocationMode = "none" // Only the directory changes; existing instances stay behind
+)
+
+// relocationReserve is kept free on the destination so the copy never fills the volume
+const relocationReserve = 512 * 1024 * 1024
+
+// relocationStagingPrefix names the dot directory an instance is copied into before it is verified
+const relocationStagingPrefix = ".relocating-"
+
+// relocationJournalName records a relocation in progress in the app directory, so one that
+// was interrupted is finished or undone on the next start
+const relocationJournalName = ".relocating"
+
+// RelocationPlan describes what changing the instances directory involves
+type RelocationPlan struct {
+	From        string   `json:"from"`
+	To          string   `json:"to"`
+	Mode        string   `json:"mode"`
+	Instances   []string `json:"instances"`
+	Bytes       int64    `json:"bytes"`       // Size of the instances to relocate
+	NeededBytes int64    `json:"neededBytes"` // Free space the destination needs; 0 when moving within one volume
+	FreeBytes   int64    `json:"freeBytes"`   // Free space at the destination, -1 if unknown
+	SameVolume  bool     `json:"sameVolume"`
+	Conflicts   []string `json:"conflicts,omitempty"` // Instances that already exist in the destination
+}
+
+// Relocation is a finished relocation whose old copies have not been removed yet.
+// Call Finish once the new directory is saved, or Rollback to return to the old one.
+type Relocation struct {
+	Plan RelocationPlan
+
+	mode       RelocationMode
+	previous   string   // Custom instances directory before the relocation
+	renamed    []string // Instances renamed into the new directory
+	copied     []string // Instances copied into the new directory
+	adopted    []string // Moved instances whose files already lived at the new place
+	sourceDirs map[string]string
+}
+
+// relocationJournal is the on-disk form of a Relocation. Instances are listed before they are
+// renamed, copied or adopted, so undoing it also covers a step that was cut off halfway.
+type relocationJournal struct {
+	Plan       RelocationPlan    `json:"plan"`
+	Mode       RelocationMode    `json:"mode"`
+	Previous   string            `json:"previous"`
+	Renamed    []string          `json:"renamed,omitempty"`
+	Copied     []string          `json:"copied,omitempty"`
+	Adopted    []string          `json:"adopted,omitempty"`
+	SourceDirs map[string]string `json:"sourceDirs"`
+}
+
+func relocationJournalPath() string {
+	return filepath.Join(GetDefaultAppDir(), relocationJournalName)
+}
+
+// saveJournal records the relocation's progress
+func (r *Relocation) saveJournal() error {
+	data, err := json.MarshalIndent(relocationJournal{
+		Plan:       r.Plan,
+		Mode:       r.mode,
+		Previous:   r.previous,
+		Renamed:    r.renamed,
+		Copied:     r.copied,
+		Adopted:    r.adopted,
+		SourceDirs: r.sourceDirs,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := relocationJournalPath()
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write relocation journal: %w", err)
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// intend lists an instance under a step before the step is taken
+func (r *Relocation) intend(step *[]string, id string) error {
+	*step = append(*step, id)
+	return r.saveJournal()
+}
+
+// abandon takes an instance off a step that did not happen
+func (r *Relocation) abandon(step *[]string, id string) {
+	if n := len(*step); n > 0 && (*step)[n-1] == id {
+		*step = (*step)[:n-1]
+	}
+	if err := r.saveJournal(); err != nil {
+		logger.Warn("Failed to update relocation journal", "error", err)
+	}
+}
+
+// dropRelocationJournal forgets a relocation that is complete or undone
+func dropRelocationJournal() {
+	os.Remove(relocationJournalPath())
+}
+
+// RecoverRelocation deals with a change of the instances directory that was interrupted.
+// If the new directory had been saved, the old copies are removed as Finish would have;
+// otherwise everything put in the new directory is removed and renamed instances go back.
+func RecoverRelocation() {
+	data, err := os.ReadFile(relocationJournalPath())
+	if err != nil {
+		return
+	}
+	var journal relocationJournal
+	if err := json.Unmarshal(data, &journal); err != nil {
+		logger.Warn("Ignoring unreadable relocation journal", "error", err)
+		dropRelocationJournal()
+		return
+	}
+	r := &Relocation{
+		Plan:       journal.Plan,
+		mode:       journal.Mode,
+		previous:   journal.Previous,
+		renamed:    journal.Renamed,
+		copied:     journal.Copied,
+		adopted:    journal.Adopted,
+		sourceDirs: journal.SourceDirs,
+	}
+	if r.sourceDirs == nil {
+		r.sourceDirs = make(map[string]string)
+	}
+
+	if samePath(GetInstancesDir(), r.Plan.To) {
+		logger.Info("Finishing interrupted change of the instances directory", "to", r.Plan.To)
+		if err := r.Finish(); err != nil {
+			logger.Warn("Failed to finish changing the instances directory", "error", err)
+		}
+		return
+	}
+	logger.Info("Undoing interrupted change of the instances directory", "to", r.Plan.To)
+	r.undo()
+	dropRelocationJournal()
+	ReloadInstances()
+	InvalidateInstanceSizes("")
+}
+
+// PlanRelocation reports what relocating instances to customDir would take.
+// An empty customDir means the default instances directory.
+func PlanRelocation(customDir string, mode RelocationMode) (*RelocationPlan, error) {
+	plan, _, err := planRelocation(customDir, mode)
+	return plan, err
+}
+
+// relocationSource is one instance and the directory it is relocated from
+type relocationSource struct {
+	inst  *Instance
+	src   string
+	adopt bool // The instance's files already sit where its descriptor will go
+}
+
+func planRelocation(customDir string, mode RelocationMode) (*RelocationPlan, []relocationSource, error) {
+	switch mode {
+	case RelocateMove, RelocateCopy, RelocateNone:
+	default:
+		return nil, nil, fmt.Errorf("unknown relocation mode %q", mode)
+	}
+
+	to := filepath.Join(GetDefaultAppDir(), "instances")
+	if customDir != "" {
+		abs, err := filepath.Abs(customDir)
+		if err != nil {
+			return nil, nil, err
+		}
+		to = abs
+	}
+	plan := &RelocationPlan{From: GetInstancesDir(), To: to, Mode: string(mode), FreeBytes: -1}
+	if samePath(plan.From, plan.To) || mode == RelocateNone {
+		return plan, nil, nil
+	}
+
+	var sources []relocationSource
+	for _, inst := range ListInstanceDescriptors() {
+		if isWithin(plan.To, inst.Dir()) || samePath(plan.To, inst.Dir()) {
+			return nil, nil, fmt.Errorf("the new directory is inside instance %s", inst.ID)
+		}
+
+		target := filepath.Join(plan.To, inst.ID)
+		source := relocationSource{inst: inst, src: inst.DescriptorDir()}
+		switch {
+		case mode == RelocateMove && inst.Location != "" && samePath(inst.Location, target):
+			source.adopt = true
+		case mode == RelocateCopy:
+			// A copy is independent, so instances moved elsewhere bring their files along
+			source.src = inst.Dir()
+		}
+
+		if !source.adopt {
+			if entries, err := os.ReadDir(target); err == nil && len(entries) > 0 {
+				plan.Conflicts = append(plan.Conflicts, inst.ID)
+			}
+			plan.Bytes += dirSize(source.src)
+		}
+		plan.Instances = append(plan.Instances, inst.ID)
+		sources = append(sources, source)
+	}
+
+	plan.SameVolume = canRename(plan.From, plan.To)
+	if mode == RelocateCopy || !plan.SameVolume {
+		plan.NeededBytes = plan.Bytes
+	}
+	if free, err := diskFree(existingParent(plan.To)); err == nil {
+		plan.FreeBytes = free
+	}
+	return plan, sources, nil
+}
+
+// RelocateInstances changes the instances directory to customDir and brings existing
+// instances along as mode says. Copies are staged, verified and then put in place;
+// if anything fails, everything done so far is undone and the directory is unchanged.
+// On success the new directory is active, and the originals stay until Finish is called.
+func RelocateInstances(ctx context.Context, customDir string, mode RelocationMode, progress func(copied, total int64, currentFile string)) (*Relocation, error) {
+	plan, sources, err := planRelocation(customDir, mode)
+	if err != nil {
+		return nil, err
+	}
+	r := &Relocation{Plan: *plan, mode: mode, previous: customInstanceDir, sourceDirs: make(map[string]string)}
+
+	if len(sources) > 0 {
+		if len(plan.Conflicts) > 0 {
+			return nil, fmt.Errorf("the new directory already contains instances named %s", strings.Join(plan.Conflicts, ", "))
+		}
+		if _, err := os.Stat(relocationJournalPath()); err == nil {
+			return nil, fmt.Errorf("an earlier change of the instances directory was interrupted; restart the launcher to finish it")
+		}
+		if pending := PendingMoves(); len(pending) > 0 {
+			return nil, fmt.Errorf("instance %s is still being moved; finish that move first", pending[0].ID)
+		}
+		if plan.FreeBytes >= 0 && plan.NeededBytes > 0 && plan.FreeBytes < plan.NeededBytes+relocationReserve {
+			return nil, fmt.Errorf("not enough free space in %s: %d MB needed, %d MB available",
+				plan.To, (plan.NeededBytes+relocationReserve)/(1024*1024), plan.FreeBytes/(1024*1024))
+		}
+		if err := os.MkdirAll(plan.To, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", plan.To, err)
+		}
+		for _, source := range sources {
+			r.sourceDirs[source.inst.ID] = source.src
+		}
+		if err := r.saveJournal(); err != nil {
+			return nil, err
+		}
+
+		logger.Info("Relocating instances", "count", len(sources), "from", plan.From, "to", plan.To, "mode", mode)
+		var copied int64
+		report := func(n int64, file string) {
+			copied += n
+			if progress != nil {
+				progress(copied, plan.Bytes, file)
+			}
+		}
+		for _, source := range sources {
+			if err := r.relocate(ctx, source, report); err != nil {
+				r.undo()
+				dropRelocationJournal()
+				return nil, fmt.Errorf("failed to relocate instance %s: %w", source.inst.ID, err)
+			}
+		}
+	}
+
+	SetCustomInstanceDir(customDir)
+	ReloadInstances()
+	InvalidateInstanceSizes("")
+	logger.Info("Instances directory changed", "dir", plan.To)
+	return r, nil
+}
+
+// relocate brings one instance into the new directory
+func (r *Relocation) relocate(ctx context.Context, source relocationSource, report func(n int64, file string)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	inst := source.inst
+	target := filepath.Join(r.Plan.To, inst.ID)
+
+	if source.adopt {
+		moved := *inst
+		moved.Location = ""
+		if err := r.intend(&r.adopted, inst.ID); err != nil {
+			return err
+		}
+		if err := writeDescriptorTo(target, &moved); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if r.mode == RelocateMove && r.Plan.SameVolume {
+		size := dirSize(source.src)
+		os.Remove(target) // An empty directory left by an earlier attempt
+		if err := r.intend(&r.renamed, inst.ID); err != nil {
+			return err
+		}
+		if err := os.Rename(source.src, target); err == nil {
+			report(size, inst.ID)
+			return nil
+		}
+		r.abandon(&r.renamed, inst.ID)
+	}
+
+	staging := filepath.Join(r.Plan.To, relocationStagingPrefix+inst.ID)
+	if err := copyTree(ctx, source.src, staging, report); err != nil {
+		os.RemoveAll(staging)
+		return err
+	}
+	if err := verifyTree(source.src, staging); err != nil {
+		os.RemoveAll(staging)
+		return fmt.Errorf("verification failed: %w", err)
+	}
+	if r.mode == RelocateCopy {
+		// The copy owns its files wherever the original kept them
+		independent := *inst
+		independent.Location = ""
+		if err := writeDescriptorTo(staging, &independent); err != nil {
+			os.RemoveAll(staging)
+			return err
+		}
+	}
+	os.Remove(target)
+	if err := r.intend(&r.copied, inst.ID); err != nil {
+		os.RemoveAll(staging)
+		return err
+	}
+	if err := os.Rename(staging, target); err != nil {
+		os.RemoveAll(staging)
+		return err
+	}
+	return nil
+}
+
+// undo removes what the relocation put in the new directory and restores renamed instances
+func (r *Relocation) undo() {
+	for _, id := range r.Plan.Instances {
+		os.RemoveAll(filepath.Join(r.Plan.To, relocationStagingPrefix+id))
+	}
+	for _, id := range r.copied {
+		os.RemoveAll(filepath.Join(r.Plan.To, id))
+	}
+	for _, id := range r.adopted {
+		os.Remove(filepath.Join(r.Plan.To, id, InstanceFileName))
+	}
+	for _, id := range r.renamed {
+		// The journal lists a rename before it happens
+		if _, err := os.Stat(r.sourceDirs[id]); err == nil {
+			continue
+		}
+		if err := os.Rename(filepath.Join(r.Plan.To, id), r.sourceDirs[id]); err != nil {
+			logger.Warn("Failed to return instance", "instance", id, "to", r.sourceDirs[id], "error", err)
+		}
+	}
+	r.copied, r.adopted, r.renamed = nil, nil, nil
+}
+
+// Rollback returns to the previous instances directory and removes the relocated copies
+func (r *Relocation) Rollback() {
+	SetCustomInstanceDir(r.previous)
+	r.undo()
+	dropRelocationJournal()
+	ReloadInstances()
+	InvalidateInstanceSizes("")
+	logger.Info("Returned to previous instances directory", "dir", r.Plan.From)
+}
+
+// Finish removes the originals of moved instances. Copies keep their originals.
+// The journal is kept while an old copy remains, so the next start tries again.
+func (r *Relocation) Finish() error {
+	if r.mode != RelocateMove {
+		dropRelocationJournal()
+		return nil
+	}
+	var failed []string
+	for _, id := range append(r.copied, r.adopted...) {
+		if err := os.RemoveAll(r.sourceDirs[id]); err != nil {
+			logger.Warn("Failed to remove old copy of instance", "instance", id, "error", err)
+			failed = append(failed, id)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("old copies of %s could not be removed from %s", strings.Join(failed, ", "), r.Plan.From)
+	}
+	dropRelocationJournal()
+	return nil
+}
+
+// copyTree copies src into dst, keeping modes, modification times, symlinks and hard links.
+// Files that already arrived whole are skipped.
+func copyTree(ctx context.Context, src, dst string, copied func(n int64, file string)) error {
+	links := make(hardLinks)
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			os.Remove(target)
+			return os.Symlink(link, target)
+		case !info.Mode().IsRegular(), strings.HasSuffix(path, moveTmpSuffix):
+			return nil
+		}
+
+		if links.link(path, target, info) {
+			copied(0, rel)
+			return nil
+		}
+		if done, err := os.Stat(target); err != nil || done.Size() != info.Size() || !done.ModTime().Equal(info.ModTime()) {
+			if err := copyFileExact(path, target, info); err != nil {
+				return err
+			}
+		}
+		copied(info.Size(), rel)
+		return nil
+	})
+}
+
+// verifyTree checks that every file and symlink in src exists in dst with the same size
+func verifyTree(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || strings.HasSuffix(path, moveTmpSuffix) {
+			return nil
+		}
+		if !info.Mode().IsRegular() && info.Mode()&os.ModeSymlink == 0 {
+			return nil
+		}
+		rel, _ := filepath.Rel(src, path)
+		got, err := os.Lstat(filepath.Join(dst, rel))
+		if err != nil {
+			return fmt.Errorf("%s is missing", rel)
+		}
+		if info.Mode().IsRegular() && (!got.Mode().IsRegular() || got.Size() != info.Size()) {
+			return fmt.Errorf("%s has %d bytes instead of %d", rel, got.Size(), info.Size())
+		}
+		return nil
+	})
+}
+
+// canRename reports whether entries of from can be renamed into to, i.e. both are on one volume
+func canRename(from, to string) bool {
+	probe := filepath.Join(from, ".relocate-probe")
+	if err := os.WriteFile(probe, nil, 0644); err != nil {
+		return false
+	}
+	defer os.Remove(probe)
+
+	target := filepath.Join(existingParent(to), ".relocate-probe")
+	if err := os.Rename(probe, target); err != nil {
+		return false
+	}
+	os.Remove(target)
+	return true
+}
+
+// existingParent returns path or its closest ancestor that exists
+func existingParent(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
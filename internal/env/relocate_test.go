@@ -0,0 +1,130 @@
+package env
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestRecoverRelocation(t *testing.T) {
+	tests := []struct {
+		name string
+		// interrupt leaves a relocation of instance id from "from" to "to" half done
+		// and returns the instances directory the launcher restarts with
+		interrupt func(t *testing.T, id, from, to string) string
+		wantDir   string // "from" or "to"
+	}{
+		{
+			name: "move before the new directory was saved is undone",
+			interrupt: func(t *testing.T, id, from, to string) string {
+				if _, err := RelocateInstances(context.Background(), to, RelocateMove, nil); err != nil {
+					t.Fatal(err)
+				}
+				return ""
+			},
+			wantDir: "from",
+		},
+		{
+			name: "move after the new directory was saved is finished",
+			interrupt: func(t *testing.T, id, from, to string) string {
+				if _, err := RelocateInstances(context.Background(), to, RelocateMove, nil); err != nil {
+					t.Fatal(err)
+				}
+				return to
+			},
+			wantDir: "to",
+		},
+		{
+			name: "copy cut off while staging is undone",
+			interrupt: func(t *testing.T, id, from, to string) string {
+				r := &Relocation{
+					Plan:       RelocationPlan{From: from, To: to, Instances: []string{id}},
+					mode:       RelocateCopy,
+					sourceDirs: map[string]string{id: filepath.Join(from, id)},
+				}
+				staging := filepath.Join(to, relocationStagingPrefix+id)
+				if err := os.MkdirAll(staging, 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := r.saveJournal(); err != nil {
+					t.Fatal(err)
+				}
+				return ""
+			},
+			wantDir: "from",
+		},
+		{
+			name: "rename recorded but not done leaves the instance alone",
+			interrupt: func(t *testing.T, id, from, to string) string {
+				r := &Relocation{
+					Plan:       RelocationPlan{From: from, To: to, Instances: []string{id}},
+					mode:       RelocateMove,
+					renamed:    []string{id},
+					sourceDirs: map[string]string{id: filepath.Join(from, id)},
+				}
+				if err := r.saveJournal(); err != nil {
+					t.Fatal(err)
+				}
+				return ""
+			},
+			wantDir: "from",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			t.Setenv("LOCALAPPDATA", home)
+			SetCustomInstanceDir("")
+			ReloadInstances()
+			t.Cleanup(func() {
+				SetCustomInstanceDir("")
+				ReloadInstances()
+			})
+
+			inst, err := CreateInstance("Relocated", "release", 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			from := GetInstancesDir()
+			to := filepath.Join(home, "elsewhere")
+
+			restartDir := tt.interrupt(t, inst.ID, from, to)
+			if !exists(relocationJournalPath()) {
+				t.Fatal("relocation left no journal")
+			}
+
+			// Restart with the configured directory
+			SetCustomInstanceDir(restartDir)
+			ReloadInstances()
+			RecoverRelocation()
+
+			if exists(relocationJournalPath()) {
+				t.Error("journal was not removed")
+			}
+			want, other := filepath.Join(from, inst.ID), filepath.Join(to, inst.ID)
+			if tt.wantDir == "to" {
+				want, other = other, want
+			}
+			if !exists(filepath.Join(want, InstanceFileName)) {
+				t.Errorf("instance missing from %s", want)
+			}
+			if exists(other) {
+				t.Errorf("instance left behind in %s", other)
+			}
+			if exists(filepath.Join(to, relocationStagingPrefix+inst.ID)) {
+				t.Error("staging directory was not removed")
+			}
+			if _, err := GetInstance(inst.ID); err != nil {
+				t.Errorf("instance not listed after recovery: %v", err)
+			}
+		})
+	}
+}
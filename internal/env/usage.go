@@ -0,0 +1,148 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sizeCacheTTL is how long computed instance sizes are reused
+const sizeCacheTTL = 10 * time.Minute
+
+// sizeWorkers bounds how many directory walks run at once
+const sizeWorkers = 4
+
+// InstanceSize is the disk usage of one instance in bytes
+type InstanceSize struct {
+	ID          string `json:"id"`
+	Game        int64  `json:"game"`        // 0 for instances that share another instance's game files
+	Generations int64  `json:"generations"` // Previous builds kept for rollback
+	UserData    int64  `json:"userData"`    // UserData without mods and saves
+	Mods        int64  `json:"mods"`
+	Saves       int64  `json:"saves"`
+	Total       int64  `json:"total"`
+	SharedGame  bool   `json:"sharedGame"`
+	ComputedAt  string `json:"computedAt"` // ISO 8601 format
+}
+
+var sizeCache = struct {
+	mu      sync.Mutex
+	entries map[string]sizeCacheEntry
+}{entries: make(map[string]sizeCacheEntry)}
+
+type sizeCacheEntry struct {
+	size InstanceSize
+	at   time.Time
+}
+
+// InvalidateInstanceSizes drops the cached size of an instance, or of all instances for ""
+func InvalidateInstanceSizes(id string) {
+	sizeCache.mu.Lock()
+	defer sizeCache.mu.Unlock()
+	if id == "" {
+		sizeCache.entries = make(map[string]sizeCacheEntry)
+		return
+	}
+	delete(sizeCache.entries, id)
+}
+
+// GetInstanceSizes returns the disk usage of every instance, oldest first.
+// Sizes are computed concurrently and cached for a while.
+func GetInstanceSizes() []InstanceSize {
+	instances := ListInstanceDescriptors()
+	sizes := make([]InstanceSize, len(instances))
+
+	type walk struct {
+		index int
+		field *int64
+		dir   string
+		skip  []string // Top-level entries counted elsewhere
+	}
+	var walks []walk
+
+	sizeCache.mu.Lock()
+	for i, inst := range instances {
+		if cached, ok := sizeCache.entries[inst.ID]; ok && time.Since(cached.at) < sizeCacheTTL {
+			sizes[i] = cached.size
+			continue
+		}
+
+		sizes[i] = InstanceSize{ID: inst.ID, SharedGame: inst.GameFrom != ""}
+		s := &sizes[i]
+		if inst.GameFrom == "" {
+			walks = append(walks, walk{i, &s.Game, filepath.Join(inst.Dir(), "game"), nil})
+		}
+		walks = append(walks,
+			walk{i, &s.Generations, filepath.Join(inst.Dir(), "generations"), nil},
+			walk{i, &s.UserData, inst.UserDataDir(), []string{"mods", "saves"}},
+			walk{i, &s.Mods, filepath.Join(inst.UserDataDir(), "Mods"), nil},
+			walk{i, &s.Saves, filepath.Join(inst.UserDataDir(), "Saves"), nil},
+			walk{i, &s.Saves, filepath.Join(inst.Dir(), "saves"), nil},
+		)
+	}
+	sizeCache.mu.Unlock()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan walk)
+	for w := 0; w < sizeWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				n := sizeExcluding(job.dir, job.skip)
+				mu.Lock()
+				*job.field += n
+				mu.Unlock()
+			}
+		}()
+	}
+	computed := make(map[int]bool)
+	for _, job := range walks {
+		computed[job.index] = true
+		jobs <- job
+	}
+	close(jobs)
+	wg.Wait()
+
+	now := time.Now()
+	sizeCache.mu.Lock()
+	for i := range computed {
+		s := &sizes[i]
+		s.Total = s.Game + s.Generations + s.UserData + s.Mods + s.Saves
+		s.ComputedAt = now.Format(time.RFC3339)
+		sizeCache.entries[s.ID] = sizeCacheEntry{size: *s, at: now}
+	}
+	sizeCache.mu.Unlock()
+
+	return sizes
+}
+
+// sizeExcluding returns the size of dir without the top-level entries named in skip
+func sizeExcluding(dir string, skip []string) int64 {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+	var size int64
+	for _, entry := range entries {
+		skipped := false
+		for _, name := range skip {
+			if strings.EqualFold(entry.Name(), name) {
+				skipped = true
+				break
+			}
+		}
+		if !skipped {
+			size += dirSize(filepath.Join(dir, entry.Name()))
+		}
+	}
+	return size
+}
+
+// DiskFree returns the bytes available on the volume holding path, or that will hold it once it is created
+func DiskFree(path string) (int64, error) {
+	return diskFree(existingParent(path))
+}
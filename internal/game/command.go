@@ -0,0 +1,220 @@
+package game
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+
+	"HyPrism/internal/env"
+)
+
+// redacted replaces secrets in previews of a launch command
+const redacted = "<redacted>"
+
+// secretFlags are client arguments whose value must never be shown
+var secretFlags = map[string]bool{
+	"--identity-token": true,
+	"--session-token":  true,
+}
+
+// secretWords mark environment variables and flags that carry credentials
+var secretWords = []string{"TOKEN", "SECRET", "PASSWORD", "PASSWD", "API_KEY", "APIKEY", "CREDENTIAL"}
+
+// LaunchCommand is the process started for an instance
+type LaunchCommand struct {
+	Path string   `json:"path"`
+	Args []string `json:"args"`
+	Env  []string `json:"env"` // Variables set on top of the launcher's environment, in the order applied
+	Dir  string   `json:"dir"`
+	Line string   `json:"line"` // The whole command as it would be typed in a shell
+
+	overrides  []envOverride
+	clientPath string // The game client, which Path only differs from when wrappers are used
+	javaPath   string // Runtime the client starts its JVMs with
+	patchFile  string // Aurora patch written before the start and removed after exit
+	patchEmbed string // Embedded file the patch is read from
+}
+
+// envOverride is one change to the inherited environment; an empty value removes the variable
+type envOverride struct {
+	key   string
+	value string
+}
+
+// profileEnv returns the environment overrides of a launch profile sorted by name,
+// followed by the JVM options, so the same profile always produces the same environment
+func profileEnv(settings env.LaunchSettings) []envOverride {
+	keys := make([]string, 0, len(settings.Env))
+	for key := range settings.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	overrides := make([]envOverride, 0, len(keys)+1)
+	for _, key := range keys {
+		overrides = append(overrides, envOverride{key, settings.Env[key]})
+	}
+
+	if opts := settings.JVMOptions(); len(opts) > 0 {
+		// Every JVM the client starts reads JAVA_TOOL_OPTIONS; options the user already exports come first
+		value := strings.Join(opts, " ")
+		if existing := strings.TrimSpace(os.Getenv("JAVA_TOOL_OPTIONS")); existing != "" {
+			value = existing + " " + value
+		}
+		overrides = append(overrides, envOverride{"JAVA_TOOL_OPTIONS", value})
+	}
+	return overrides
+}
+
+// envKey returns the form of a variable name used to compare it; Windows ignores case
+func envKey(key string) string {
+	if runtime.GOOS == "windows" {
+		return strings.ToUpper(key)
+	}
+	return key
+}
+
+// mergeEnv applies overrides to base. Variables keep their position in base; new ones are added in order.
+func mergeEnv(base []string, overrides []envOverride) []string {
+	merged := append([]string(nil), base...)
+	for _, o := range overrides {
+		replaced := false
+		kept := merged[:0]
+		for _, entry := range merged {
+			key, _, _ := strings.Cut(entry, "=")
+			if envKey(key) != envKey(o.key) {
+				kept = append(kept, entry)
+				continue
+			}
+			if !replaced && o.value != "" {
+				kept = append(kept, o.key+"="+o.value)
+			}
+			replaced = true
+		}
+		merged = kept
+		if !replaced && o.value != "" {
+			merged = append(merged, o.key+"="+o.value)
+		}
+	}
+	return merged
+}
+
+// overrideEntries formats overrides as KEY=value, with removals shown as KEY=
+func overrideEntries(overrides []envOverride) []string {
+	entries := make([]string, 0, len(overrides))
+	for _, o := range overrides {
+		entries = append(entries, o.key+"="+o.value)
+	}
+	return entries
+}
+
+// command returns the exec.Cmd that runs c
+func (c *LaunchCommand) command() *exec.Cmd {
+	cmd := exec.Command(c.Path, c.Args...)
+	cmd.Env = mergeEnv(os.Environ(), c.overrides)
+	cmd.Dir = c.Dir
+	cmd.SysProcAttr = getWindowsSysProcAttr()
+	return cmd
+}
+
+// isSecretName reports whether a variable or flag name looks like it holds a credential
+func isSecretName(name string) bool {
+	upper := strings.ToUpper(strings.TrimLeft(name, "-"))
+	upper = strings.ReplaceAll(upper, "-", "_")
+	for _, word := range secretWords {
+		if strings.Contains(upper, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSecretFlag reports whether arg is a flag whose value is a credential
+func isSecretFlag(arg string) bool {
+	if !strings.HasPrefix(arg, "--") || strings.Contains(arg, "=") {
+		return false
+	}
+	return secretFlags[arg] || isSecretName(arg)
+}
+
+// Redacted returns a copy of c with tokens and other credentials hidden
+func (c LaunchCommand) Redacted() LaunchCommand {
+	vars := make([]string, len(c.Env))
+	for i, entry := range c.Env {
+		vars[i] = entry
+		if key, value, _ := strings.Cut(entry, "="); value != "" && isSecretName(key) {
+			vars[i] = key + "=" + redacted
+		}
+	}
+
+	c.Args = redactArgs(c.Args)
+	c.Env = vars
+	c.Line = commandLine(vars, c.Path, c.Args)
+	return c
+}
+
+// redactArgs returns a copy of args with the values of credential flags hidden
+func redactArgs(args []string) []string {
+	out := make([]string, len(args))
+	for i, arg := range args {
+		out[i] = arg
+		if i > 0 && isSecretFlag(args[i-1]) {
+			out[i] = redacted
+			continue
+		}
+		if flag, _, ok := strings.Cut(arg, "="); ok && isSecretFlag(flag) {
+			out[i] = flag + "=" + redacted
+		}
+	}
+	return out
+}
+
+// redactSettings returns a copy of a launch profile with credentials hidden
+func redactSettings(settings env.LaunchSettings) env.LaunchSettings {
+	settings.ExtraArgs = redactArgs(settings.ExtraArgs)
+	if settings.Env != nil {
+		vars := make(map[string]string, len(settings.Env))
+		for key, value := range settings.Env {
+			if value != "" && isSecretName(key) {
+				value = redacted
+			}
+			vars[key] = value
+		}
+		settings.Env = vars
+	}
+	return settings
+}
+
+// commandLine formats a command with its environment the way it would be typed in a shell
+func commandLine(vars []string, path string, args []string) string {
+	parts := make([]string, 0, len(vars)+len(args)+1)
+	for _, entry := range vars {
+		key, value, _ := strings.Cut(entry, "=")
+		parts = append(parts, key+"="+shellQuote(value))
+	}
+	parts = append(parts, shellQuote(path))
+	for _, arg := range args {
+		parts = append(parts, shellQuote(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// shellQuote quotes s for a POSIX shell when it contains anything but safe characters
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	safe := true
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=,+@%", r)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,129 @@
+package game
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeEnv(t *testing.T) {
+	tests := []struct {
+		name      string
+		base      []string
+		overrides []envOverride
+		want      []string
+	}{
+		{
+			name:      "replaced variable keeps its position",
+			base:      []string{"A=1", "B=2", "C=3"},
+			overrides: []envOverride{{"B", "x"}},
+			want:      []string{"A=1", "B=x", "C=3"},
+		},
+		{
+			name:      "new variables are added in order",
+			base:      []string{"A=1"},
+			overrides: []envOverride{{"Z", "1"}, {"Y", "2"}},
+			want:      []string{"A=1", "Z=1", "Y=2"},
+		},
+		{
+			name:      "empty value removes",
+			base:      []string{"A=1", "B=2"},
+			overrides: []envOverride{{"A", ""}},
+			want:      []string{"B=2"},
+		},
+		{
+			name:      "removing a missing variable does nothing",
+			base:      []string{"A=1"},
+			overrides: []envOverride{{"B", ""}},
+			want:      []string{"A=1"},
+		},
+		{
+			name:      "duplicates collapse into the first",
+			base:      []string{"A=1", "B=2", "A=3"},
+			overrides: []envOverride{{"A", "x"}},
+			want:      []string{"A=x", "B=2"},
+		},
+		{
+			name:      "later overrides win",
+			base:      []string{"A=1"},
+			overrides: []envOverride{{"A", "x"}, {"A", "y"}},
+			want:      []string{"A=y"},
+		},
+		{
+			name:      "value may contain equals signs",
+			base:      []string{"OPTS=-Da=b"},
+			overrides: []envOverride{{"OPTS", "-Dc=d"}},
+			want:      []string{"OPTS=-Dc=d"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := append([]string(nil), tt.base...)
+			got := mergeEnv(base, tt.overrides)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeEnv = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(base, tt.base) {
+				t.Errorf("base was modified: %v", base)
+			}
+		})
+	}
+}
+
+func TestLaunchCommandRedacted(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		env      []string
+		wantArgs []string
+		wantEnv  []string
+	}{
+		{
+			name:     "token flag followed by its value",
+			args:     []string{"--identity-token", "abc", "--uuid", "123"},
+			wantArgs: []string{"--identity-token", redacted, "--uuid", "123"},
+		},
+		{
+			name:     "token flag with inline value",
+			args:     []string{"--session-token=abc", "--name=player"},
+			wantArgs: []string{"--session-token=" + redacted, "--name=player"},
+		},
+		{
+			name:     "flags named like credentials",
+			args:     []string{"--api-key", "k", "--password=p"},
+			wantArgs: []string{"--api-key", redacted, "--password=" + redacted},
+		},
+		{
+			name:    "secret variables",
+			env:     []string{"MY_TOKEN=abc", "DB_PASSWORD=p", "HOME=/home/a"},
+			wantEnv: []string{"MY_TOKEN=" + redacted, "DB_PASSWORD=" + redacted, "HOME=/home/a"},
+		},
+		{
+			name:    "removed secret stays visible as removed",
+			env:     []string{"MY_TOKEN="},
+			wantEnv: []string{"MY_TOKEN="},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := LaunchCommand{
+				Path: "/game/HytaleClient",
+				Args: append([]string(nil), tt.args...),
+				Env:  append([]string(nil), tt.env...),
+			}
+			got := c.Redacted()
+			if tt.wantArgs != nil && !reflect.DeepEqual(got.Args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", got.Args, tt.wantArgs)
+			}
+			if tt.wantEnv != nil && !reflect.DeepEqual(got.Env, tt.wantEnv) {
+				t.Errorf("env = %v, want %v", got.Env, tt.wantEnv)
+			}
+			if strings.Contains(got.Line, "abc") {
+				t.Errorf("line %q still contains a secret", got.Line)
+			}
+			if !reflect.DeepEqual(c.Args, tt.args) || !reflect.DeepEqual(c.Env, tt.env) {
+				t.Error("Redacted changed the original command")
+			}
+		})
+	}
+}
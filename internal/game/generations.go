@@ -0,0 +1,209 @@
+package game
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"HyPrism/internal/env"
+)
+
+const generationsDirName = "generations"
+
+var (
+	generationsMu   sync.Mutex
+	keptGenerations = 2
+)
+
+// SetKeptGenerations sets how many previous builds are kept per instance for rollback
+// A value of 0 disables keeping previous builds
+func SetKeptGenerations(n int) {
+	if n < 0 {
+		n = 0
+	}
+	generationsMu.Lock()
+	keptGenerations = n
+	generationsMu.Unlock()
+}
+
+func getKeptGenerations() int {
+	generationsMu.Lock()
+	defer generationsMu.Unlock()
+	return keptGenerations
+}
+
+// Generation is a previous game build kept in an instance for rollback
+type Generation struct {
+	Build      int    `json:"build"`
+	ArchivedAt string `json:"archivedAt"` // ISO 8601 format
+	path       string
+}
+
+func generationsDir(instanceDir string) string {
+	return filepath.Join(instanceDir, generationsDirName)
+}
+
+func generationDir(instanceDir string, build int) string {
+	return filepath.Join(generationsDir(instanceDir), strconv.Itoa(build))
+}
+
+// listGenerations returns the kept generations of an instance, most recently archived first
+func listGenerations(instanceDir string) []Generation {
+	entries, err := os.ReadDir(generationsDir(instanceDir))
+	if err != nil {
+		return []Generation{}
+	}
+
+	generations := []Generation{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		build, err := strconv.Atoi(entry.Name())
+		if err != nil || build <= 0 {
+			continue
+		}
+
+		dir := filepath.Join(generationsDir(instanceDir), entry.Name())
+		if _, err := os.Stat(ClientPath(filepath.Join(dir, gameDirName))); err != nil {
+			continue
+		}
+
+		// version.txt is written when the generation is archived, so its time is the archive time
+		archivedAt := time.Time{}
+		if info, err := os.Stat(filepath.Join(dir, versionFileName)); err == nil {
+			archivedAt = info.ModTime()
+		}
+
+		generations = append(generations, Generation{
+			Build:      build,
+			ArchivedAt: archivedAt.Format(time.RFC3339),
+			path:       dir,
+		})
+	}
+
+	sort.Slice(generations, func(i, j int) bool {
+		return generations[i].ArchivedAt > generations[j].ArchivedAt
+	})
+	return generations
+}
+
+// archiveGeneration keeps a replaced game tree as a generation of its build,
+// then prunes generations beyond the configured limit
+func archiveGeneration(instanceDir string, gameDir string, build int) error {
+	limit := getKeptGenerations()
+	if limit == 0 || build <= 0 {
+		return os.RemoveAll(gameDir)
+	}
+
+	dir := generationDir(instanceDir, build)
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(gameDir, filepath.Join(dir, gameDirName)); err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, versionFileName), []byte(strconv.Itoa(build)), 0644); err != nil {
+		return err
+	}
+
+	logger.Info("Kept build for rollback", "build", build, "instance", filepath.Base(instanceDir))
+
+	generations := listGenerations(instanceDir)
+	for i := limit; i < len(generations); i++ {
+		logger.Info("Removing old generation", "build", generations[i].Build, "instance", filepath.Base(instanceDir))
+		os.RemoveAll(generations[i].path)
+	}
+	return nil
+}
+
+// ListInstanceGenerations returns the previous builds kept for an instance, most recent first
+func ListInstanceGenerations(branch string, version int) []Generation {
+	return listGenerations(env.GetInstanceDir(branch, version))
+}
+
+// RollbackInstance restores a kept generation of an instance: the one of build, or the most
+// recently archived one when build is 0. The build being replaced is archived in turn, so a
+// rollback can itself be undone, and a newer build rolled back from is skipped by automatic updates.
+func RollbackInstance(branch string, version int, build int) (int, error) {
+	// A rollback swaps the live tree like an install does, so the two must not overlap
+	installMutex.Lock()
+	if isInstalling {
+		installMutex.Unlock()
+		return 0, fmt.Errorf("installation already in progress")
+	}
+	isInstalling = true
+	installMutex.Unlock()
+
+	defer func() {
+		installMutex.Lock()
+		isInstalling = false
+		installMutex.Unlock()
+	}()
+
+	instanceDir := env.GetInstanceDir(branch, version)
+	generations := listGenerations(instanceDir)
+	if len(generations) == 0 {
+		return 0, fmt.Errorf("no previous builds are kept for %s", filepath.Base(instanceDir))
+	}
+	target := generations[0]
+	if build > 0 {
+		found := false
+		for _, g := range generations {
+			if g.Build == build {
+				target, found = g, true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("build %d is not kept for %s", build, filepath.Base(instanceDir))
+		}
+	}
+
+	currentBuild := env.GetInstanceBuild(branch, version)
+	if _, err := os.Stat(ClientPath(env.GetInstanceGameDir(branch, version))); err != nil {
+		currentBuild = 0
+	}
+
+	txn, err := beginInstall(branch, version, currentBuild, target.Build)
+	if err != nil {
+		return 0, err
+	}
+
+	// Move the kept tree in as the staged tree; an abort moves it back
+	generationGameDir := filepath.Join(target.path, gameDirName)
+	txn.journal.RestoreTo = generationGameDir
+	if err := txn.writeJournal(); err != nil {
+		txn.abort()
+		return 0, err
+	}
+	if err := os.Remove(txn.StagingDir()); err != nil {
+		txn.abort()
+		return 0, fmt.Errorf("failed to prepare rollback: %w", err)
+	}
+	if err := os.Rename(generationGameDir, txn.StagingDir()); err != nil {
+		txn.abort()
+		return 0, fmt.Errorf("failed to restore build %d: %w", target.Build, err)
+	}
+
+	if err := txn.commit(); err != nil {
+		txn.abort()
+		return 0, err
+	}
+
+	// The generation's tree is live again; drop its now empty slot
+	if target.Build != currentBuild {
+		os.RemoveAll(target.path)
+	}
+
+	logger.Info("Rolled back instance", "instance", filepath.Base(instanceDir), "from", currentBuild, "to", target.Build)
+	return target.Build, nil
+}
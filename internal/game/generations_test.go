@@ -0,0 +1,180 @@
+package game
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"HyPrism/internal/env"
+	"HyPrism/internal/pwr"
+)
+
+// useTempAppDir points the app directory at a fresh temporary directory
+func useTempAppDir(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("LOCALAPPDATA", dir)
+}
+
+// writeTree creates a game tree whose client holds the build number
+func writeTree(t *testing.T, gameDir string, build int) {
+	t.Helper()
+	client := ClientPath(gameDir)
+	if err := os.MkdirAll(filepath.Dir(client), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(client, []byte(strconv.Itoa(build)), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// treeBuild reads back the build written by writeTree
+func treeBuild(t *testing.T, gameDir string) int {
+	t.Helper()
+	data, err := os.ReadFile(ClientPath(gameDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	build, _ := strconv.Atoi(string(data))
+	return build
+}
+
+// installWithGenerations sets up the latest release instance at build current, keeping older builds
+func installWithGenerations(t *testing.T, current int, kept ...int) *env.Instance {
+	t.Helper()
+	inst, err := env.EnsureInstance("release", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTree(t, inst.GameDir(), current)
+	if err := env.SetInstanceBuild(inst.ID, "release", 0, current); err != nil {
+		t.Fatal(err)
+	}
+	for i, build := range kept {
+		dir := generationDir(inst.Dir(), build)
+		writeTree(t, filepath.Join(dir, gameDirName), build)
+		marker := filepath.Join(dir, versionFileName)
+		os.WriteFile(marker, []byte(strconv.Itoa(build)), 0644)
+		// Earlier arguments were archived more recently
+		archived := time.Now().Add(-time.Duration(i+1) * time.Hour)
+		os.Chtimes(marker, archived, archived)
+	}
+	return inst
+}
+
+func TestRollbackInstance(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  int
+		kept     []int
+		target   int
+		want     int
+		wantSkip int
+		wantErr  bool
+	}{
+		{name: "most recent generation", current: 10, kept: []int{9, 8}, target: 0, want: 9, wantSkip: 10},
+		{name: "chosen generation", current: 10, kept: []int{9, 8}, target: 8, want: 8, wantSkip: 10},
+		{name: "build that is not kept", current: 10, kept: []int{9}, target: 7, wantErr: true},
+		{name: "forward to a newer kept build", current: 8, kept: []int{10}, target: 10, want: 10, wantSkip: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempAppDir(t)
+			inst := installWithGenerations(t, tt.current, tt.kept...)
+
+			got, err := RollbackInstance("release", 0, tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if treeBuild(t, inst.GameDir()) != tt.current {
+					t.Fatal("a failed rollback changed the game files")
+				}
+				return
+			}
+			if got != tt.want || treeBuild(t, inst.GameDir()) != tt.want {
+				t.Fatalf("rolled back to %d, tree holds %d, want %d", got, treeBuild(t, inst.GameDir()), tt.want)
+			}
+
+			after := env.FindInstance("release", 0)
+			if after.InstalledBuild != tt.want || after.SkipBuild != tt.wantSkip {
+				t.Fatalf("instance records build %d, skip %d; want %d, skip %d", after.InstalledBuild, after.SkipBuild, tt.want, tt.wantSkip)
+			}
+
+			// The replaced build is kept in turn, so the rollback can be undone
+			found := false
+			for _, g := range ListInstanceGenerations("release", 0) {
+				found = found || g.Build == tt.current
+			}
+			if !found {
+				t.Fatalf("build %d was not kept after the rollback", tt.current)
+			}
+		})
+	}
+}
+
+func TestSkippedBuildIsLiftedByNewerInstall(t *testing.T) {
+	useTempAppDir(t)
+	inst := installWithGenerations(t, 10, 9)
+	if _, err := RollbackInstance("release", 0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	env.SetInstanceBuild(inst.ID, "release", 0, 10)
+	if skip := env.FindInstance("release", 0).SkipBuild; skip != 0 {
+		t.Fatalf("skip = %d after reinstalling the skipped build", skip)
+	}
+}
+
+func TestUpdateAfterRollback(t *testing.T) {
+	useTempAppDir(t)
+	inst := installWithGenerations(t, 10, 9)
+	if _, err := RollbackInstance("release", 0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	latest := 10
+	findLatestBuild = func(string) int { return latest }
+	t.Cleanup(func() { findLatestBuild = pwr.FindLatestVersion })
+
+	// With no newer build out, updating keeps the build rolled back to instead of reinstalling 10
+	if err := installGameBuild(context.Background(), "release", 0, 0, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := treeBuild(t, inst.GameDir()); got != 9 {
+		t.Fatalf("tree holds build %d after the update, want 9", got)
+	}
+	after := env.FindInstance("release", 0)
+	if after.InstalledBuild != 9 || after.SkipBuild != 10 {
+		t.Fatalf("instance records build %d, skip %d; want 9, skip 10", after.InstalledBuild, after.SkipBuild)
+	}
+
+	tests := []struct {
+		name      string
+		latest    int
+		build     int // Build asked for explicitly
+		installed int
+		want      int
+	}{
+		{name: "skipped build is the newest", latest: 10, installed: 9, want: 9},
+		{name: "newer build is out", latest: 11, installed: 9, want: 11},
+		{name: "explicit build", latest: 10, build: 10, installed: 9, want: 10},
+		{name: "nothing installed", latest: 10, want: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			latest = tt.latest
+			got, err := resolveInstallTarget("release", 0, tt.build, tt.installed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("target = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"HyPrism/internal/java"
 	"HyPrism/internal/pwr"
 	"HyPrism/internal/pwr/butler"
+	"HyPrism/internal/store"
 )
 
 var (
@@ -19,6 +20,9 @@ var (
 	isInstalling bool
 )
 
+// findLatestBuild returns the newest build of a branch; replaced in tests
+var findLatestBuild = pwr.FindLatestVersion
+
 // EnsureInstalled ensures the game is installed and up to date
 func EnsureInstalled(ctx context.Context, progress func(stage string, progress float64, msg string, file string, speed string, down, total int64)) error {
 	// Prevent multiple simultaneous installations
@@ -155,6 +159,13 @@ func EnsureInstalledVersion(ctx context.Context, versionType string, progress fu
 
 // EnsureInstalledVersionSpecific ensures a specific branch AND version is installed
 func EnsureInstalledVersionSpecific(ctx context.Context, versionType string, version int, progress func(stage string, progress float64, msg string, file string, speed string, down, total int64)) error {
+	return EnsureInstalledBuild(ctx, versionType, version, 0, progress)
+}
+
+// EnsureInstalledBuild is EnsureInstalledVersionSpecific for an instance that should start at a
+// given build, such as an imported auto-update instance. A build of 0 picks the instance's own
+// build. An instance that is already installed keeps the build it has.
+func EnsureInstalledBuild(ctx context.Context, versionType string, version int, build int, progress func(stage string, progress float64, msg string, file string, speed string, down, total int64)) error {
 	// Prevent multiple simultaneous installations
 	installMutex.Lock()
 	if isInstalling {
@@ -172,18 +183,10 @@ func EnsureInstalledVersionSpecific(ctx context.Context, versionType string, ver
 
 	// Check if this specific version is already installed in instance folder
 	instanceGameDir := env.GetInstanceGameDir(versionType, version)
-	var clientPath string
-	switch runtime.GOOS {
-	case "darwin":
-		clientPath = filepath.Join(instanceGameDir, "Client", "Hytale.app", "Contents", "MacOS", "HytaleClient")
-	case "windows":
-		clientPath = filepath.Join(instanceGameDir, "Client", "HytaleClient.exe")
-	default:
-		clientPath = filepath.Join(instanceGameDir, "Client", "HytaleClient")
-	}
+	clientPath := ClientPath(instanceGameDir)
 
 	if _, err := os.Stat(clientPath); err == nil {
-		fmt.Printf("Instance %s v%d already installed at %s\n", versionType, version, instanceGameDir)
+		logger.Info("Instance already installed", "branch", versionType, "version", version, "dir", instanceGameDir)
 		// Don't just say "complete" - we still need to indicate we're launching
 		// This ensures the frontend transitions to the correct state
 		if progress != nil {
@@ -212,7 +215,7 @@ func EnsureInstalledVersionSpecific(ctx context.Context, versionType string, ver
 	}
 
 	// Install to instance-specific directory
-	if err := InstallGameToInstance(ctx, versionType, version, progress); err != nil {
+	if err := installGameBuild(ctx, versionType, version, build, progress); err != nil {
 		return fmt.Errorf("failed to install game: %w", err)
 	}
 
@@ -220,60 +223,196 @@ func EnsureInstalledVersionSpecific(ctx context.Context, versionType string, ver
 }
 
 // InstallGameToInstance installs the game to an instance-specific directory
+// If the instance already has an older build, recorded in its instance.json, it is upgraded with
+// a chain of incremental patches; a full build is only downloaded when no chain exists.
 func InstallGameToInstance(ctx context.Context, versionType string, version int, progressCallback func(stage string, progress float64, message string, currentFile string, speed string, downloaded, total int64)) error {
+	return installGameBuild(ctx, versionType, version, 0, progressCallback)
+}
+
+// resolveInstallTarget returns the build installGameBuild installs: build if given, else the
+// pinned version, else the newest build. An installed instance that was rolled back from the
+// newest build keeps the build it has until a newer one is out.
+func resolveInstallTarget(versionType string, version int, build int, installedBuild int) (int, error) {
+	if build > 0 {
+		return build, nil
+	}
+	if version > 0 {
+		return version, nil
+	}
+
+	latest := findLatestBuild(versionType)
+	if latest == 0 {
+		return 0, fmt.Errorf("could not determine latest version for %s", versionType)
+	}
+	if inst := env.FindInstance(versionType, 0); inst != nil && installedBuild > 0 && latest <= inst.SkipBuild {
+		logger.Info("Keeping installed build; newest build was rolled back from", "branch", versionType, "build", installedBuild, "skipped", inst.SkipBuild)
+		return installedBuild, nil
+	}
+	return latest, nil
+}
+
+// installGameBuild installs build into an instance; 0 means its pinned version or the newest build
+func installGameBuild(ctx context.Context, versionType string, version int, build int, progressCallback func(stage string, progress float64, message string, currentFile string, speed string, downloaded, total int64)) error {
 	instanceGameDir := env.GetInstanceGameDir(versionType, version)
 
-	// Download the patch file
-	pwrPath, err := pwr.DownloadPWR(ctx, versionType, 0, version, progressCallback)
+	// Only trust the recorded build if the client is actually there
+	installedBuild := env.GetInstanceBuild(versionType, version)
+	if _, err := os.Stat(ClientPath(instanceGameDir)); err != nil {
+		installedBuild = 0
+	}
+
+	targetBuild, err := resolveInstallTarget(versionType, version, build, installedBuild)
 	if err != nil {
-		return fmt.Errorf("failed to download game patch: %w", err)
+		return err
+	}
+
+	if installedBuild == targetBuild {
+		logger.Info("Instance already up to date", "branch", versionType, "version", version, "build", targetBuild)
+		if progressCallback != nil {
+			progressCallback("complete", 100, fmt.Sprintf("%s build %d is up to date", versionType, targetBuild), "", "", 0, 0)
+		}
+		return nil
 	}
 
-	// Verify the patch file exists
-	info, err := os.Stat(pwrPath)
+	steps, err := pwr.PlanPatchChain(versionType, installedBuild, targetBuild)
 	if err != nil {
-		return fmt.Errorf("patch file not accessible: %w", err)
+		return fmt.Errorf("failed to plan game update: %w", err)
+	}
+	if len(steps) == 0 {
+		return fmt.Errorf("installed build %d is newer than requested build %d", installedBuild, targetBuild)
 	}
-	fmt.Printf("Patch file size: %d bytes\n", info.Size())
 
-	// Apply the patch to instance directory
-	if progressCallback != nil {
-		progressCallback("install", 0, "Installing game...", "", "", 0, 0)
+	// Build the new tree next to the live one so a failure never leaves the instance broken
+	txn, err := beginInstall(versionType, version, installedBuild, targetBuild)
+	if err != nil {
+		return err
 	}
+	defer txn.abort()
+
+	for i, step := range steps {
+		if progressCallback != nil {
+			if step.IsFullBuild() {
+				progressCallback("download", 0, fmt.Sprintf("Downloading %s build %d...", versionType, step.To), "", "", 0, 0)
+			} else {
+				progressCallback("download", 0, fmt.Sprintf("Downloading patch %d/%d (build %d → %d)...", i+1, len(steps), step.From, step.To), "", "", 0, 0)
+			}
+		}
+
+		// Download the patch file
+		pwrPath, err := pwr.DownloadPWR(ctx, versionType, step.From, step.To, progressCallback)
+		if err != nil {
+			return fmt.Errorf("failed to download game patch %d->%d: %w", step.From, step.To, err)
+		}
+
+		// Verify the patch file exists
+		info, err := os.Stat(pwrPath)
+		if err != nil {
+			return fmt.Errorf("patch file not accessible: %w", err)
+		}
+		logger.Debug("Patch file ready", "bytes", info.Size())
 
-	if err := pwr.ApplyPWRToDir(ctx, pwrPath, instanceGameDir, progressCallback); err != nil {
-		return fmt.Errorf("failed to apply game patch: %w", err)
+		// Apply the patch into the staging tree
+		if progressCallback != nil {
+			progressCallback("install", 0, fmt.Sprintf("Applying patch %d/%d...", i+1, len(steps)), "", "", 0, 0)
+		}
+
+		if i == 0 && !step.IsFullBuild() {
+			// First incremental hop reads the live tree and writes the result into staging
+			err = pwr.ApplyPWRToNewDir(ctx, pwrPath, instanceGameDir, txn.StagingDir(), progressCallback)
+		} else {
+			// Full builds start from the empty staging tree; later hops patch staging in place
+			err = pwr.ApplyPWRToDir(ctx, pwrPath, txn.StagingDir(), progressCallback)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to apply game patch %d->%d: %w", step.From, step.To, err)
+		}
 	}
 
-	// Verify installation
-	var clientPath string
-	switch runtime.GOOS {
-	case "darwin":
-		clientPath = filepath.Join(instanceGameDir, "Client", "Hytale.app", "Contents", "MacOS", "HytaleClient")
-	case "windows":
-		clientPath = filepath.Join(instanceGameDir, "Client", "HytaleClient.exe")
-	default:
-		clientPath = filepath.Join(instanceGameDir, "Client", "HytaleClient")
+	// Record the signature of the exact build, so it can be verified and healed later
+	if err := pwr.RecordBuild(ctx, versionType, targetBuild, txn.StagingDir()); err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		logger.Warn("Failed to record build signature", "build", targetBuild, "error", err)
 	}
 
-	if _, err := os.Stat(clientPath); err != nil {
-		return fmt.Errorf("installation incomplete: client not found at %s", clientPath)
+	// Share identical files with other instances through the game file store
+	if progressCallback != nil {
+		progressCallback("install", 99, "Sharing game files with other instances...", "", "", 0, 0)
+	}
+	if _, err := store.Ingest(ctx, txn.StagingDir(), nil); err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		logger.Warn("Failed to share game files", "error", err)
 	}
 
-	// Save version marker in instance directory
-	versionFile := filepath.Join(env.GetInstanceDir(versionType, version), "version.txt")
-	os.WriteFile(versionFile, []byte(fmt.Sprintf("%d", version)), 0644)
+	// Verify the staged client and swap it into place
+	if err := txn.commit(); err != nil {
+		return err
+	}
 
 	if progressCallback != nil {
-		progressCallback("complete", 100, fmt.Sprintf("%s v%d installed successfully", versionType, version), "", "", 0, 0)
+		progressCallback("complete", 100, fmt.Sprintf("%s build %d installed successfully", versionType, targetBuild), "", "", 0, 0)
 	}
 
 	return nil
 }
 
+// ClientPath returns the path of the client executable inside a game directory
+func ClientPath(gameDir string) string {
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(gameDir, "Client", "Hytale.app", "Contents", "MacOS", "HytaleClient")
+	case "windows":
+		return filepath.Join(gameDir, "Client", "HytaleClient.exe")
+	default:
+		return filepath.Join(gameDir, "Client", "HytaleClient")
+	}
+}
+
 func getFirstURL(urls []string) string {
 	if len(urls) == 0 {
 		return "none"
 	}
 	return urls[0]
 }
+
+// DeduplicateInstances moves the game files of every instance and kept generation into
+// the shared store, so identical files are only kept once. UserData is never shared.
+func DeduplicateInstances(ctx context.Context, progressCallback func(stage string, progress float64, message string, currentFile string, speed string, downloaded, total int64)) (*store.IngestResult, error) {
+	var trees []string
+	for _, inst := range env.ListInstanceDescriptors() {
+		if inst.GameFrom != "" {
+			continue
+		}
+		if dirExists(inst.GameDir()) {
+			trees = append(trees, inst.GameDir())
+		}
+		for _, gen := range listGenerations(inst.Dir()) {
+			trees = append(trees, filepath.Join(gen.path, gameDirName))
+		}
+	}
+
+	total := &store.IngestResult{}
+	for i, tree := range trees {
+		result, err := store.Ingest(ctx, tree, func(done, count int) {
+			if progressCallback != nil && count > 0 {
+				progress := (float64(i) + float64(done)/float64(count)) / float64(len(trees)) * 100
+				progressCallback("dedupe", progress, fmt.Sprintf("Sharing game files (%d/%d)...", i+1, len(trees)), tree, "", int64(done), int64(count))
+			}
+		})
+		if err != nil {
+			return total, err
+		}
+		total.Files += result.Files
+		total.Linked += result.Linked
+		total.Added += result.Added
+		total.SavedBytes += result.SavedBytes
+	}
+
+	if progressCallback != nil {
+		progressCallback("complete", 100, "Game files shared", "", "", 0, 0)
+	}
+	return total, nil
+}
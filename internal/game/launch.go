@@ -1,26 +1,69 @@
 package game
 
 import (
+	"bytes"
 	"crypto/md5"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
-	"strings"
+	"sync"
+	"time"
 
 	"HyPrism/internal/env"
+	"HyPrism/internal/logging"
 	"HyPrism/internal/util"
 )
 
+// logger writes the game subsystem's part of the launcher log
+var logger = logging.For(logging.Game)
+
 // LaunchInstance launches a specific branch/version instance
 func LaunchInstance(playerName string, branch string, version int, fakeServer bool) error {
+	inst := env.FindInstance(branch, version)
+	if inst == nil {
+		return fmt.Errorf("instance %s v%d not installed", branch, version)
+	}
+	return launch(playerName, inst, fakeServer)
+}
+
+// LaunchInstanceByID starts the game for the instance with the given ID
+func LaunchInstanceByID(playerName string, id string, fakeServer bool) error {
+	inst, err := env.GetInstance(id)
+	if err != nil {
+		return err
+	}
+	return launch(playerName, inst, fakeServer)
+}
+
+// PreviewLaunchCommand returns the command LaunchInstanceByID would run, with secrets redacted
+func PreviewLaunchCommand(playerName string, id string, fakeServer bool) (*LaunchCommand, error) {
+	inst, err := env.GetInstance(id)
+	if err != nil {
+		return nil, err
+	}
+	c, err := buildLaunchCommand(playerName, inst, fakeServer)
+	if err != nil {
+		return nil, err
+	}
+	preview := c.Redacted()
+	return &preview, nil
+}
+
+// buildLaunchCommand resolves the client, Java and the instance's launch profile into the command to run.
+// It has no side effects, so it also serves previews.
+func buildLaunchCommand(playerName string, inst *env.Instance, fakeServer bool) (*LaunchCommand, error) {
 	baseDir := env.GetDefaultAppDir()
+	branch, version := inst.Branch, inst.Version
+
+	if err := inst.Launch.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid launch profile for instance %s: %w", inst.ID, err)
+	}
 
-	// Get instance-specific game directory
-	gameDir := env.GetInstanceGameDir(branch, version)
+	// Game files may be shared with other instances of the same build
+	gameDir := inst.GameDir()
 	// Verify client exists
 	var clientPath string
 	switch runtime.GOOS {
@@ -33,28 +76,15 @@ func LaunchInstance(playerName string, branch string, version int, fakeServer bo
 	}
 
 	if _, err := os.Stat(clientPath); err != nil {
-		return fmt.Errorf("game client not found at %s (instance %s v%d not installed): %w", clientPath, branch, version, err)
+		return nil, fmt.Errorf("game client not found at %s (instance %s v%d not installed): %w", clientPath, branch, version, err)
 	}
 
-	// Use instance-specific UserData
-	userDataDir := env.GetInstanceUserDataDir(branch, version)
-	_ = os.MkdirAll(userDataDir, 0755)
-
 	// Set up Java path
 	var jrePath string
 	jreDir := filepath.Join(baseDir, "jre")
 
 	switch runtime.GOOS {
 	case "darwin":
-		javaDir := filepath.Join(baseDir, "java")
-		javaHomeBin := filepath.Join(javaDir, "Contents", "Home", "bin")
-
-		if _, err := os.Stat(javaHomeBin); err != nil {
-			os.RemoveAll(javaDir)
-			os.MkdirAll(filepath.Join(javaDir, "Contents", "Home"), 0755)
-			os.Symlink(filepath.Join(jreDir, "bin"), filepath.Join(javaDir, "Contents", "Home", "bin"))
-			os.Symlink(filepath.Join(jreDir, "lib"), filepath.Join(javaDir, "Contents", "Home", "lib"))
-		}
 		jrePath = filepath.Join(baseDir, "java", "Contents", "Home", "bin", "java")
 	case "windows":
 		jrePath = filepath.Join(jreDir, "bin", "java.exe")
@@ -62,244 +92,277 @@ func LaunchInstance(playerName string, branch string, version int, fakeServer bo
 		jrePath = filepath.Join(jreDir, "bin", "java")
 	}
 
-	if _, err := os.Stat(jrePath); err != nil {
-		return fmt.Errorf("Java not found at %s: %w", jrePath, err)
-	}
-
-	fmt.Printf("=== LAUNCH INSTANCE ===\n")
-	fmt.Printf("Branch: %s, Version: %d\n", branch, version)
-	fmt.Printf("Game dir: %s\n", gameDir)
-	fmt.Printf("UserData: %s\n", userDataDir)
-	fmt.Printf("========================\n")
-	var cmd *exec.Cmd
-	args := []string{
+	c := &LaunchCommand{Path: clientPath, clientPath: clientPath, javaPath: jrePath}
+	c.Args = []string{
 		"--app-dir", gameDir,
-		"--user-dir", userDataDir,
+		"--user-dir", inst.UserDataDir(),
 		"--java-exec", jrePath,
 	}
-	var dllName, embedName string
-	if fakeServer {
 
+	if fakeServer {
 		if runtime.GOOS == "windows" {
-			dllName = filepath.Join(filepath.Dir(clientPath), "Secur32.dll")
-			embedName = filepath.Join("Aurora", "Build", "Aurora.dll")
+			c.patchFile = filepath.Join(filepath.Dir(clientPath), "Secur32.dll")
+			c.patchEmbed = filepath.Join("Aurora", "Build", "Aurora.dll")
 		} else {
-			dllName = filepath.Join(os.TempDir(), "Aurora.so")
-			embedName = filepath.Join("Aurora", "Build", "Aurora.so")
-			os.Setenv("LD_PRELOAD", dllName)
+			c.patchFile = filepath.Join(os.TempDir(), "Aurora.so")
+			c.patchEmbed = filepath.Join("Aurora", "Build", "Aurora.so")
+			c.overrides = append(c.overrides, envOverride{"LD_PRELOAD", c.patchFile})
 		}
 
-		// Write the embedded patch file to disk
-		data, err := newEmbeddedFiles.ReadFile(embedName)
-		if err != nil {
-			return errors.New("failed to read embedded Aurora patch; try offline mode")
-		}
-		os.WriteFile(dllName, data, 0777)
-
-		args = append(args,
+		c.Args = append(c.Args,
 			"--auth-mode", "authenticated",
 			"--uuid", util.UsernameToUuid("test"),
 			"--name", playerName,
 			"--identity-token", util.GenerateIdentityJwt("hytale:client", "test", ""),
 			"--session-token", util.GenerateSessionJwt("hytale:client", "test"))
-
 	} else {
-		args = append(args,
+		c.Args = append(c.Args,
 			"--auth-mode", "offline",
 			"--uuid", "00000000-1337-1337-1337-000000000000",
 			"--name", playerName,
 		)
 	}
 
-	if runtime.GOOS == "darwin" {
-		appBundlePath := filepath.Join(gameDir, "Client", "Hytale.app")
-		cmd = exec.Command("open", append([]string{appBundlePath}, args...)...)
-	} else if runtime.GOOS == "windows" {
+	// Per-instance extra arguments go last so they can override the defaults
+	c.Args = append(c.Args, inst.Launch.ExtraArgs...)
+
+	switch runtime.GOOS {
+	case "darwin":
+		// The executable inside the app bundle is started directly rather than through open,
+		// so the client is the launcher's child and each session can be stopped on its own
+	case "windows":
 		// Windows - launch directly without special working directory
-		cmd = exec.Command(clientPath, args...)
-		cmd.SysProcAttr = getWindowsSysProcAttr()
-	} else {
+	default:
 		// Linux - must set LD_LIBRARY_PATH to find SDL3_image and other native libraries
-		clientDir := filepath.Join(gameDir, "Client")
-		cmd = exec.Command(clientPath, args...)
 		// Preserve LD_LIBRARY_PATH with Client directory first
-		existingLdPath := os.Getenv("LD_LIBRARY_PATH")
-		newLdPath := clientDir
-		if existingLdPath != "" {
-			newLdPath = fmt.Sprintf("%s:%s", clientDir, existingLdPath)
+		ldPath := filepath.Join(gameDir, "Client")
+		if existing := os.Getenv("LD_LIBRARY_PATH"); existing != "" {
+			ldPath = fmt.Sprintf("%s:%s", ldPath, existing)
 		}
-		cmd.Env = append(os.Environ(),
-			fmt.Sprintf("LD_LIBRARY_PATH=%s", newLdPath),
-		)
+		c.overrides = append(c.overrides, envOverride{"LD_LIBRARY_PATH", ldPath})
 	}
 
-	cmd.Dir = baseDir
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	// The profile is applied last so it can replace anything the launcher sets
+	c.overrides = append(c.overrides, profileEnv(inst.Launch)...)
 
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start game: %w", err)
+	if runtime.GOOS == "linux" {
+		if err := wrapCommand(c, inst.Launch.ActiveWrappers()); err != nil {
+			return nil, err
+		}
 	}
+	c.Env = overrideEntries(c.overrides)
+	c.Dir = inst.LaunchWorkingDir(baseDir)
+	c.Line = commandLine(c.Env, c.Path, c.Args)
+	return c, nil
+}
 
-	gameProcess = cmd.Process
-	gameRunning = true
-
-	go func() {
-		cmd.Wait()
-		gameProcess = nil
-		gameRunning = false
-		os.Remove(dllName)
-	}()
-
-	return nil
+// prepareJava makes sure the bundled Java runtime is where the client expects it
+func prepareJava(baseDir string) {
+	if runtime.GOOS != "darwin" {
+		return
+	}
+	jreDir := filepath.Join(baseDir, "jre")
+	javaDir := filepath.Join(baseDir, "java")
+	javaHomeBin := filepath.Join(javaDir, "Contents", "Home", "bin")
+
+	if _, err := os.Stat(javaHomeBin); err != nil {
+		os.RemoveAll(javaDir)
+		os.MkdirAll(filepath.Join(javaDir, "Contents", "Home"), 0755)
+		os.Symlink(filepath.Join(jreDir, "bin"), filepath.Join(javaDir, "Contents", "Home", "bin"))
+		os.Symlink(filepath.Join(jreDir, "lib"), filepath.Join(javaDir, "Contents", "Home", "lib"))
+	}
 }
 
-var gameProcess *os.Process
-var gameRunning bool
+// launch starts the game files of an instance with the instance's own UserData
+func launch(playerName string, inst *env.Instance, fakeServer bool) error {
+	prepareJava(env.GetDefaultAppDir())
 
-// KillGame terminates the running game process
-func KillGame() error {
-	if !gameRunning {
-		return fmt.Errorf("no game process running")
+	c, err := buildLaunchCommand(playerName, inst, fakeServer)
+	if err != nil {
+		return err
 	}
 
-	// Try to kill by process reference first
-	if gameProcess != nil {
-		err := gameProcess.Kill()
-		if err == nil {
-			gameProcess = nil
-			gameRunning = false
-			fmt.Println("Game process terminated")
-			return nil
-		}
+	if _, err := os.Stat(c.javaPath); err != nil {
+		return fmt.Errorf("Java not found at %s: %w", c.javaPath, err)
 	}
 
-	// If that fails, try to find and kill by name
-	if runtime.GOOS == "darwin" {
-		exec.Command("pkill", "-f", "Hytale").Run()
-	} else if runtime.GOOS == "windows" {
-		cmd := exec.Command("taskkill", "/F", "/IM", "HytaleClient.exe")
-		cmd.SysProcAttr = getWindowsSysProcAttr()
-		cmd.Run()
-	} else {
-		exec.Command("pkill", "-f", "HytaleClient").Run()
+	// Sessions of one instance share its UserData, so a second one needs the instance's consent
+	if !inst.Launch.AllowConcurrent && Processes().InstanceRunning(inst.ID) {
+		return fmt.Errorf("instance %s is already running; allow concurrent sessions in its launch settings to start another", inst.Name)
 	}
 
-	gameProcess = nil
-	gameRunning = false
-	fmt.Println("Game process terminated")
-	return nil
-}
+	// Use instance-specific UserData
+	_ = os.MkdirAll(inst.UserDataDir(), 0755)
+	if info, err := os.Stat(c.Dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("working directory %s does not exist", c.Dir)
+	}
 
-// IsGameRunning checks if the game process is still running
-func IsGameRunning() bool {
-	// Active check for the game process by name
-	var isRunning bool
-
-	if runtime.GOOS == "darwin" {
-		// Check for Hytale process on macOS
-		out, err := exec.Command("pgrep", "-f", "Hytale").Output()
-		isRunning = err == nil && len(out) > 0
-	} else if runtime.GOOS == "windows" {
-		// Check for HytaleClient.exe on Windows using WMI (no window flash)
-		isRunning = isWindowsProcessRunning("HytaleClient.exe")
-	} else {
-		// Linux
-		out, err := exec.Command("pgrep", "-f", "HytaleClient").Output()
-		isRunning = err == nil && len(out) > 0
+	logger.Info("Launching instance",
+		"instance", inst.ID,
+		"name", inst.Name,
+		"branch", inst.Branch,
+		"version", inst.Version,
+		"gameDir", inst.GameDir(),
+		"userData", inst.UserDataDir(),
+		"command", c.Redacted().Line)
+
+	if c.patchFile != "" {
+		// Write the embedded patch file to disk
+		data, err := newEmbeddedFiles.ReadFile(c.patchEmbed)
+		if err != nil {
+			return errors.New("failed to read embedded Aurora patch; try offline mode")
+		}
+		if err := writePatchFile(c.patchFile, data); err != nil {
+			return fmt.Errorf("failed to write Aurora patch: %w", err)
+		}
 	}
 
-	// Update global state
-	gameRunning = isRunning
-	if !isRunning {
-		gameProcess = nil
+	log, err := newSession(inst)
+	if err != nil {
+		return fmt.Errorf("failed to create session log: %w", err)
 	}
+	log.note("Instance %s (%s), %s build %d", inst.ID, inst.Name, inst.Branch, inst.InstalledBuild)
+	log.note("Command: %s", c.Redacted().Line)
+
+	cmd := c.command()
+	stdout, stderr := log.stream("stdout"), log.stream("stderr")
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	patchFile := c.patchFile
+	session, err := Processes().Start(cmd, StartOptions{
+		ID:         log.sessionID,
+		InstanceID: inst.ID,
+		LogPath:    log.path,
+		ClientPath: c.clientPath,
+		Command:    c.Redacted().Line,
+		OnExit: func(p Process) {
+			stdout.Flush()
+			stderr.Flush()
+			if p.Signal != "" {
+				log.note("Session ended by %s after %s", p.Signal, time.Duration(p.DurationMs)*time.Millisecond)
+			} else {
+				log.note("Session exited with code %d after %s", p.ExitCode, time.Duration(p.DurationMs)*time.Millisecond)
+			}
+			log.Close()
+			reportCrash(inst, c, p)
+			// The patch is shared by every session started in fake online mode
+			if patchFile != "" && !Processes().Running() {
+				os.Remove(patchFile)
+			}
+		},
+	})
+	if err != nil {
+		log.note("Failed to start: %v", err)
+		log.Close()
+		return fmt.Errorf("failed to start game: %w", err)
+	}
+	logger.Info("Game session started", "session", session.ID, "instance", inst.ID, "log", session.LogPath)
 
-	return isRunning
+	if err := env.MarkInstancePlayed(inst.ID); err != nil {
+		logger.Warn("Failed to record last played time", "instance", inst.ID, "error", err)
+	}
+
+	return nil
 }
 
-// WaitForGameExit waits for the game to exit and returns
-func WaitForGameExit() {
-	if !gameRunning {
-		return
+// writePatchFile puts the Aurora patch at path. Other sessions may have the file loaded, so
+// it is left alone when it already holds data, and otherwise replaced through a rename
+// rather than rewritten in place.
+func writePatchFile(path string, data []byte) error {
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, data) {
+		return nil
 	}
-
-	// Poll until the game is no longer running
-	for IsGameRunning() {
-		// Sleep briefly to avoid busy-waiting
-		// The IsGameRunning function already checks the actual process
+	tmp := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+	if err := os.WriteFile(tmp, data, 0777); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
 	}
+	return nil
+}
 
-	gameProcess = nil
-	gameRunning = false
+var (
+	supervisorMu sync.Mutex
+	supervisor   = NewSupervisor(nil)
+)
+
+// SetSupervisor makes s the supervisor new game processes are handed to
+func SetSupervisor(s *Supervisor) {
+	supervisorMu.Lock()
+	supervisor = s
+	supervisorMu.Unlock()
 }
 
-// GetGameLogs returns the game log file content
-func GetGameLogs() (string, error) {
-	baseDir := env.GetDefaultAppDir()
+// Processes returns the supervisor that owns the game processes
+func Processes() *Supervisor {
+	supervisorMu.Lock()
+	defer supervisorMu.Unlock()
+	return supervisor
+}
 
-	// Try multiple log paths based on typical Hytale log locations
-	paths := []string{
-		// UserData logs
-		filepath.Join(baseDir, "UserData", "logs", "latest.log"),
-		filepath.Join(baseDir, "UserData", "logs", "game.log"),
-		filepath.Join(baseDir, "UserData", "logs", "client.log"),
-		// Instance logs (latest)
-		filepath.Join(env.GetInstanceGameDir("release", 0), "logs", "latest.log"),
-		filepath.Join(env.GetInstanceGameDir("release", 0), "logs", "game.log"),
-		filepath.Join(env.GetInstanceGameDir("release", 0), "Client", "logs", "latest.log"),
-		// HyPrism specific log
-		filepath.Join(baseDir, "logs", "game.log"),
-	}
+// KillSession asks every session of an instance to close, and kills those that do not
+func KillSession(instanceID string) error {
+	return Processes().StopInstance(instanceID, DefaultStopGrace)
+}
 
-	var allLogs strings.Builder
-	foundAny := false
+// ListRunningSessions returns the game sessions that are running, oldest first
+func ListRunningSessions() []Process {
+	return Processes().List()
+}
 
-	for _, p := range paths {
-		if data, err := os.ReadFile(p); err == nil && len(data) > 0 {
-			foundAny = true
-			allLogs.WriteString(fmt.Sprintf("=== %s ===\n", filepath.Base(p)))
+// SessionsUsingInstance returns the running sessions of an instance and of every instance that
+// shares game files with it through GameFrom, oldest first
+func SessionsUsingInstance(id string) []Process {
+	owner := id
+	if inst, err := env.GetInstance(id); err == nil {
+		owner = inst.GameOwner()
+	}
 
-			content := string(data)
-			// Return last 30KB of each log
-			if len(content) > 30*1024 {
-				content = content[len(content)-30*1024:]
-			}
-			allLogs.WriteString(content)
-			allLogs.WriteString("\n\n")
+	var using []Process
+	for _, p := range Processes().List() {
+		if p.InstanceID == id || p.InstanceID == owner {
+			using = append(using, p)
+		} else if other, err := env.GetInstance(p.InstanceID); err == nil && other.GameOwner() == owner {
+			using = append(using, p)
 		}
 	}
+	return using
+}
 
-	if foundAny {
-		return allLogs.String(), nil
+// KillGame asks every game process started by the launcher to close, and kills those that do not
+func KillGame() error {
+	if err := Processes().StopAll(DefaultStopGrace); err != nil {
+		return err
 	}
+	logger.Info("Game processes terminated")
+	return nil
+}
 
-	// List what directories exist to help debug
-	var debug strings.Builder
-	debug.WriteString("No game logs found. Checking directories:\n\n")
+// IsGameRunning reports whether a game process started by the launcher is still running
+func IsGameRunning() bool {
+	return Processes().Running()
+}
 
-	checkDirs := []string{
-		filepath.Join(baseDir, "UserData"),
-		filepath.Join(baseDir, "UserData", "logs"),
-		env.GetInstanceDir("release", 0),
-		filepath.Join(env.GetInstanceGameDir("release", 0), "logs"),
-	}
+// WaitForGameExit waits for every game process started by the launcher to exit
+func WaitForGameExit() {
+	Processes().WaitAll()
+}
 
-	for _, dir := range checkDirs {
-		if _, err := os.Stat(dir); err == nil {
-			debug.WriteString(fmt.Sprintf("✓ %s exists\n", dir))
-			// List files in the directory
-			if entries, err := os.ReadDir(dir); err == nil {
-				for _, e := range entries {
-					debug.WriteString(fmt.Sprintf("   - %s\n", e.Name()))
-				}
-			}
-		} else {
-			debug.WriteString(fmt.Sprintf("✗ %s not found\n", dir))
-		}
+// GetGameLogs returns the end of the most recently written session log
+func GetGameLogs() (string, error) {
+	instanceID, sessionID, ok := latestSession()
+	if !ok {
+		return "No game logs yet. Logs are written once the game has been started.", nil
 	}
-
-	return debug.String(), nil
+	content, err := ReadSessionLog(instanceID, sessionID, 256*1024)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("=== %s / %s ===\n%s", instanceID, sessionID, content), nil
 }
 
 // UUID represents a UUID
@@ -324,18 +387,3 @@ func (u UUID) String() string {
 	return fmt.Sprintf("%s-%s-%s-%s-%s",
 		hex[0:8], hex[8:12], hex[12:16], hex[16:20], hex[20:32])
 }
-
-// setSDLVideoDriver sets the SDL_VIDEODRIVER environment variable for Linux
-func setSDLVideoDriver(cmd *exec.Cmd) {
-	if runtime.GOOS != "linux" {
-		return
-	}
-
-	// Check if running under Wayland
-	waylandDisplay := os.Getenv("WAYLAND_DISPLAY")
-	xdgSession := os.Getenv("XDG_SESSION_TYPE")
-
-	if waylandDisplay != "" || strings.ToLower(xdgSession) == "wayland" {
-		cmd.Env = append(os.Environ(), "SDL_VIDEODRIVER=wayland,x11")
-	}
-}
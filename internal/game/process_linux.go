@@ -0,0 +1,105 @@
+//go:build linux
+
+package game
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// startInOwnGroup puts the started process in a new process group, so the client
+// can be found and stopped through any wrappers it was started with
+func startInOwnGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// groupAlive reports whether any process of the group still runs.
+// Zombies are ignored: they stay in the group until reaped, which may never happen in containers.
+func groupAlive(pgid int) bool {
+	if pgid <= 0 || syscall.Kill(-pgid, 0) != nil {
+		return false
+	}
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return true
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		if state, group := processStat(pid); group == pgid && state != "Z" && state != "X" {
+			return true
+		}
+	}
+	return false
+}
+
+// terminateProcess signals the process group started as pid, which holds the client and its wrappers.
+// Without force the processes are asked to close; with force they are killed.
+func terminateProcess(pid int, force bool) error {
+	if pid <= 0 {
+		return syscall.ESRCH
+	}
+	sig := syscall.SIGTERM
+	if force {
+		sig = syscall.SIGKILL
+	}
+	return syscall.Kill(-pid, sig)
+}
+
+// findGroupProcess returns the PID of the process in group pgid running the executable at path, or 0
+func findGroupProcess(pgid int, path string) int {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return 0
+	}
+	want, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		want = path
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		if _, group := processStat(pid); group != pgid {
+			continue
+		}
+		if exe, err := os.Readlink(filepath.Join("/proc", entry.Name(), "exe")); err == nil && exe == want {
+			return pid
+		}
+	}
+	return 0
+}
+
+// processStat reads the state and process group of pid from /proc, or returns "" and -1
+func processStat(pid int) (string, int) {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return "", -1
+	}
+	// The command name may contain spaces and parentheses; fields follow the last ')'
+	stat := string(data)
+	end := strings.LastIndexByte(stat, ')')
+	if end < 0 {
+		return "", -1
+	}
+	fields := strings.Fields(stat[end+1:])
+	// state, ppid, pgrp
+	if len(fields) < 3 {
+		return "", -1
+	}
+	pgid, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return "", -1
+	}
+	return fields[0], pgid
+}
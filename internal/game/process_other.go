@@ -0,0 +1,40 @@
+//go:build !linux && !windows
+
+package game
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// startInOwnGroup puts the started process in a new process group
+func startInOwnGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// groupAlive reports whether any process of the group still runs
+func groupAlive(pgid int) bool {
+	return pgid > 0 && syscall.Kill(-pgid, 0) == nil
+}
+
+// terminateProcess signals the process group started as pid. The client is started
+// directly, not through LaunchServices, so it is in that group and no other session is.
+// Without force the processes are asked to close; with force they are killed.
+func terminateProcess(pid int, force bool) error {
+	if pid <= 0 {
+		return syscall.ESRCH
+	}
+	sig := syscall.SIGTERM
+	if force {
+		sig = syscall.SIGKILL
+	}
+	return syscall.Kill(-pid, sig)
+}
+
+// findGroupProcess is a stub; wrappers are only supported on Linux
+func findGroupProcess(pgid int, path string) int {
+	return 0
+}
@@ -0,0 +1,33 @@
+//go:build windows
+
+package game
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+// startInOwnGroup is a no-op; the client already gets its own process group on Windows
+func startInOwnGroup(cmd *exec.Cmd) {}
+
+// groupAlive always reports false; Windows has no process groups to outlive the started process
+func groupAlive(pgid int) bool {
+	return false
+}
+
+// terminateProcess closes the process tree started as pid. Without force the
+// client is asked to close its windows; with force it is killed.
+func terminateProcess(pid int, force bool) error {
+	args := []string{"/T", "/PID", strconv.Itoa(pid)}
+	if force {
+		args = append([]string{"/F"}, args...)
+	}
+	cmd := exec.Command("taskkill", args...)
+	cmd.SysProcAttr = getWindowsSysProcAttr()
+	return cmd.Run()
+}
+
+// findGroupProcess is a stub; wrappers are only supported on Linux
+func findGroupProcess(pgid int, path string) int {
+	return 0
+}
@@ -0,0 +1,450 @@
+package game
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"HyPrism/internal/crash"
+	"HyPrism/internal/env"
+)
+
+// sessionLogExt is the extension of session log files; the file name is the session ID.
+// Rotated parts append a number: <id>.log.1 is the part written before <id>.log.
+const sessionLogExt = ".log"
+
+// maxSessionLogParts is how many rotated parts are kept next to the current file of a session
+const maxSessionLogParts = 3
+
+// maxLogLine is the longest line streamed to the frontend; longer lines are cut
+const maxLogLine = 8 * 1024
+
+// sessionTimeFormat is the timestamp session IDs start with
+const sessionTimeFormat = "20060102-150405"
+
+// SessionLine is one line of client output, streamed to the frontend as "game:log"
+type SessionLine struct {
+	InstanceID string `json:"instanceId"`
+	SessionID  string `json:"sessionId"`
+	Stream     string `json:"stream"` // stdout or stderr
+	Line       string `json:"line"`
+	Time       string `json:"time"` // ISO 8601 format
+}
+
+// Session describes the log of one game session of an instance
+type Session struct {
+	ID         string `json:"id"`
+	InstanceID string `json:"instanceId"`
+	StartedAt  string `json:"startedAt"` // ISO 8601 format
+	UpdatedAt  string `json:"updatedAt"` // ISO 8601 format, last write to the log
+	Size       int64  `json:"size"`      // Bytes over all parts of the log
+	Parts      int    `json:"parts"`     // Number of files the log was rotated into
+	Running    bool   `json:"running"`
+}
+
+var (
+	sessionLogMu       sync.Mutex
+	sessionLogLimit    int64 = 10 * 1024 * 1024 // Size of a log file before it is rotated
+	sessionLogKeep           = 20               // Sessions kept per instance
+	sessionLineHandler func(SessionLine)
+	crashHandler       func(*crash.Report)
+)
+
+// SetSessionLogLimits sets the size at which a session log is rotated and how many sessions
+// are kept per instance. Values below 1 keep the current setting.
+func SetSessionLogLimits(rotateBytes int64, keepSessions int) {
+	sessionLogMu.Lock()
+	defer sessionLogMu.Unlock()
+	if rotateBytes > 0 {
+		sessionLogLimit = rotateBytes
+	}
+	if keepSessions > 0 {
+		sessionLogKeep = keepSessions
+	}
+}
+
+// SetSessionLineHandler sets the function every line of client output is passed to
+func SetSessionLineHandler(fn func(SessionLine)) {
+	sessionLogMu.Lock()
+	sessionLineHandler = fn
+	sessionLogMu.Unlock()
+}
+
+// SetCrashHandler sets the function called with the report of every session that crashed
+func SetCrashHandler(fn func(*crash.Report)) {
+	sessionLogMu.Lock()
+	crashHandler = fn
+	sessionLogMu.Unlock()
+}
+
+// sessionLogSettings returns the current rotation size, retention and line handler
+func sessionLogSettings() (int64, int, func(SessionLine)) {
+	sessionLogMu.Lock()
+	defer sessionLogMu.Unlock()
+	return sessionLogLimit, sessionLogKeep, sessionLineHandler
+}
+
+// sessionLog writes the output of one session to a rotating file and streams it line by line
+type sessionLog struct {
+	mu         sync.Mutex
+	instanceID string
+	sessionID  string
+	path       string
+	file       *os.File
+	size       int64
+	limit      int64
+	onLine     func(SessionLine)
+}
+
+// newSession picks an ID for a new session of inst, creates its log and prunes old sessions
+func newSession(inst *env.Instance) (*sessionLog, error) {
+	dir := inst.LogsDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	limit, keep, onLine := sessionLogSettings()
+
+	base := time.Now().Format(sessionTimeFormat)
+	id := base
+	for n := 2; ; n++ {
+		path := filepath.Join(dir, id+sessionLogExt)
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			// This session counts towards the retention limit
+			pruneSessions(inst, keep-1, id)
+			return &sessionLog{
+				instanceID: inst.ID,
+				sessionID:  id,
+				path:       path,
+				file:       f,
+				limit:      limit,
+				onLine:     onLine,
+			}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// note writes a line from the launcher, such as the command or how the session ended
+func (l *sessionLog) note(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.writeLocked([]byte(fmt.Sprintf("[HyPrism] "+format+"\n", args...)))
+}
+
+// stream returns a writer for one of the client's output streams
+func (l *sessionLog) stream(name string) *streamWriter {
+	return &streamWriter{log: l, name: name}
+}
+
+// writeLocked appends p to the log file, rotating it first if it is full. l.mu must be held.
+func (l *sessionLog) writeLocked(p []byte) {
+	if l.file == nil {
+		return
+	}
+	if l.size > 0 && l.size+int64(len(p)) > l.limit {
+		l.rotateLocked()
+	}
+	n, _ := l.file.Write(p)
+	l.size += int64(n)
+}
+
+// rotateLocked moves the current file to part 1, shifting older parts up and dropping the oldest. l.mu must be held.
+func (l *sessionLog) rotateLocked() {
+	l.file.Close()
+	os.Remove(fmt.Sprintf("%s.%d", l.path, maxSessionLogParts))
+	for n := maxSessionLogParts - 1; n >= 1; n-- {
+		os.Rename(fmt.Sprintf("%s.%d", l.path, n), fmt.Sprintf("%s.%d", l.path, n+1))
+	}
+	os.Rename(l.path, l.path+".1")
+
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		logger.Warn("Failed to rotate session log", "path", l.path, "error", err)
+		l.file = nil
+		return
+	}
+	l.file = f
+	l.size = 0
+}
+
+// Close closes the log file
+func (l *sessionLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// streamWriter splits one output stream into lines for the log and the line handler
+type streamWriter struct {
+	log     *sessionLog
+	name    string
+	partial []byte
+}
+
+// Write writes complete lines to the log and keeps the rest until its line ends.
+// exec.Cmd copies each stream from a single goroutine, so partial needs no lock.
+func (w *streamWriter) Write(p []byte) (int, error) {
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(w.partial[:i+1])
+		w.partial = w.partial[i+1:]
+	}
+	// A line without an end is written once it gets too long to hold
+	if len(w.partial) > maxLogLine {
+		w.emit(append(w.partial, '\n'))
+		w.partial = nil
+	}
+	return len(p), nil
+}
+
+// Flush writes a last line that did not end in a newline
+func (w *streamWriter) Flush() {
+	if len(w.partial) > 0 {
+		w.emit(append(w.partial, '\n'))
+		w.partial = nil
+	}
+}
+
+// emit writes one line, including its newline, and passes it to the line handler
+func (w *streamWriter) emit(line []byte) {
+	w.log.mu.Lock()
+	w.log.writeLocked(line)
+	w.log.mu.Unlock()
+
+	if w.log.onLine == nil {
+		return
+	}
+	text := strings.TrimRight(string(line), "\r\n")
+	if len(text) > maxLogLine {
+		text = text[:maxLogLine]
+	}
+	w.log.onLine(SessionLine{
+		InstanceID: w.log.instanceID,
+		SessionID:  w.log.sessionID,
+		Stream:     w.name,
+		Line:       text,
+		Time:       time.Now().Format(time.RFC3339),
+	})
+}
+
+// validSessionID reports whether id can name a session log without leaving the logs directory
+func validSessionID(id string) bool {
+	return id != "" && !strings.ContainsAny(id, `/\`) && id != "." && id != ".."
+}
+
+// sessionParts returns the files of a session's log, oldest first
+func sessionParts(dir string, id string) []string {
+	current := filepath.Join(dir, id+sessionLogExt)
+	var parts []string
+	for n := maxSessionLogParts; n >= 1; n-- {
+		part := fmt.Sprintf("%s.%d", current, n)
+		if _, err := os.Stat(part); err == nil {
+			parts = append(parts, part)
+		}
+	}
+	if _, err := os.Stat(current); err == nil {
+		parts = append(parts, current)
+	}
+	return parts
+}
+
+// ListSessions returns the logged sessions of an instance, newest first
+func ListSessions(instanceID string) ([]Session, error) {
+	inst, err := env.GetInstance(instanceID)
+	if err != nil {
+		return nil, err
+	}
+	dir := inst.LogsDir()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Session{}, nil
+		}
+		return nil, err
+	}
+
+	running := make(map[string]bool)
+	for _, p := range Processes().ListInstance(instanceID) {
+		running[p.ID] = true
+	}
+
+	sessions := []Session{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, sessionLogExt) {
+			continue
+		}
+		id := strings.TrimSuffix(name, sessionLogExt)
+		s := Session{ID: id, InstanceID: instanceID, Running: running[id]}
+		if started, err := time.ParseInLocation(sessionTimeFormat, sessionTimePrefix(id), time.Local); err == nil {
+			s.StartedAt = started.Format(time.RFC3339)
+		}
+		var updated time.Time
+		for _, part := range sessionParts(dir, id) {
+			info, err := os.Stat(part)
+			if err != nil {
+				continue
+			}
+			s.Size += info.Size()
+			s.Parts++
+			if info.ModTime().After(updated) {
+				updated = info.ModTime()
+			}
+		}
+		if !updated.IsZero() {
+			s.UpdatedAt = updated.Format(time.RFC3339)
+		}
+		if s.StartedAt == "" {
+			s.StartedAt = s.UpdatedAt
+		}
+		sessions = append(sessions, s)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].StartedAt != sessions[j].StartedAt {
+			return sessions[i].StartedAt > sessions[j].StartedAt
+		}
+		return sessions[i].ID > sessions[j].ID
+	})
+	return sessions, nil
+}
+
+// sessionTimePrefix returns the timestamp part of a session ID
+func sessionTimePrefix(id string) string {
+	if len(id) < len(sessionTimeFormat) {
+		return id
+	}
+	return id[:len(sessionTimeFormat)]
+}
+
+// ReadSessionLog returns the log of a session with all its parts joined.
+// With maxBytes above 0 only the end of the log is returned.
+func ReadSessionLog(instanceID string, sessionID string, maxBytes int64) (string, error) {
+	if !validSessionID(sessionID) {
+		return "", fmt.Errorf("invalid session ID %q", sessionID)
+	}
+	inst, err := env.GetInstance(instanceID)
+	if err != nil {
+		return "", err
+	}
+	parts := sessionParts(inst.LogsDir(), sessionID)
+	if len(parts) == 0 {
+		return "", fmt.Errorf("session %s of instance %s not found", sessionID, instanceID)
+	}
+
+	var buf bytes.Buffer
+	for _, part := range parts {
+		data, err := os.ReadFile(part)
+		if err != nil {
+			return "", err
+		}
+		buf.Write(data)
+	}
+	data := buf.Bytes()
+	if maxBytes > 0 && int64(len(data)) > maxBytes {
+		data = data[int64(len(data))-maxBytes:]
+		// Start at a line boundary
+		if i := bytes.IndexByte(data, '\n'); i >= 0 && i < len(data)-1 {
+			data = data[i+1:]
+		}
+	}
+	return string(data), nil
+}
+
+// pruneSessions deletes the oldest session logs of an instance beyond keep, except running sessions and skip
+func pruneSessions(inst *env.Instance, keep int, skip string) {
+	sessions, err := ListSessions(inst.ID)
+	if err != nil {
+		return
+	}
+	kept := 0
+	for _, s := range sessions {
+		if s.ID == skip {
+			continue
+		}
+		if s.Running || kept < keep {
+			kept++
+			continue
+		}
+		for _, part := range sessionParts(inst.LogsDir(), s.ID) {
+			os.Remove(part)
+		}
+	}
+}
+
+// latestSession returns the instance and session that were written to most recently
+func latestSession() (string, string, bool) {
+	var instanceID, sessionID, updated string
+	for _, inst := range env.ListInstanceDescriptors() {
+		sessions, err := ListSessions(inst.ID)
+		if err != nil {
+			continue
+		}
+		for _, s := range sessions {
+			if s.UpdatedAt > updated {
+				instanceID, sessionID, updated = inst.ID, s.ID, s.UpdatedAt
+			}
+		}
+	}
+	return instanceID, sessionID, sessionID != ""
+}
+
+// crashTailBytes is how much of the session log goes into a crash report
+const crashTailBytes = 64 * 1024
+
+// reportCrash writes a crash report for a session that exited with an error or a signal.
+// Sessions the launcher was asked to stop are not crashes.
+func reportCrash(inst *env.Instance, c *LaunchCommand, p Process) {
+	if p.Stopped || (p.ExitCode == 0 && p.Signal == "") {
+		return
+	}
+
+	started, _ := time.Parse(time.RFC3339, p.StartedAt)
+	tail, _ := ReadSessionLog(inst.ID, p.ID, crashTailBytes)
+	report := crash.Build(crash.Input{
+		InstanceID: inst.ID,
+		SessionID:  p.ID,
+		StartedAt:  started,
+		Exit: crash.Exit{
+			Code:       p.ExitCode,
+			Signal:     p.Signal,
+			DurationMs: p.DurationMs,
+			Error:      p.Error,
+		},
+		Command:    p.Command,
+		Profile:    redactSettings(inst.Launch),
+		SessionLog: tail,
+		WorkingDir: c.Dir,
+	})
+	path, err := crash.Write(report)
+	if err != nil {
+		logger.Error("Failed to write crash report", "session", p.ID, "error", err)
+		return
+	}
+	logger.Warn("Game session crashed", "session", p.ID, "instance", inst.ID, "report", path)
+
+	sessionLogMu.Lock()
+	handler := crashHandler
+	sessionLogMu.Unlock()
+	if handler != nil {
+		handler(report)
+	}
+}
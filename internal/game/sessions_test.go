@@ -0,0 +1,87 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSessionLogRotation(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     int64
+		writes    []string
+		wantParts []string // Contents of the files of the log, oldest first
+	}{
+		{
+			name:      "under the limit",
+			limit:     100,
+			writes:    []string{"aaaa\n", "bbbb\n"},
+			wantParts: []string{"aaaa\nbbbb\n"},
+		},
+		{
+			name:      "rotates before the line that does not fit",
+			limit:     10,
+			writes:    []string{"aaaa\n", "bbbb\n", "cccc\n"},
+			wantParts: []string{"aaaa\nbbbb\n", "cccc\n"},
+		},
+		{
+			name:      "lines are never split across files",
+			limit:     8,
+			writes:    []string{"aa", "aa\nbb", "bb\n"},
+			wantParts: []string{"aaaa\n", "bbbb\n"},
+		},
+		{
+			name:      "a line longer than the limit gets a file of its own",
+			limit:     4,
+			writes:    []string{"long line\n", "x\n"},
+			wantParts: []string{"long line\n", "x\n"},
+		},
+		{
+			name:      "oldest parts are dropped",
+			limit:     5,
+			writes:    []string{"1111\n", "2222\n", "3333\n", "4444\n", "5555\n", "6666\n"},
+			wantParts: []string{"3333\n", "4444\n", "5555\n", "6666\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "session"+sessionLogExt)
+			f, err := os.Create(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var lines []string
+			l := &sessionLog{path: path, file: f, limit: tt.limit, onLine: func(line SessionLine) {
+				lines = append(lines, line.Line)
+			}}
+
+			w := l.stream("stdout")
+			for _, s := range tt.writes {
+				w.Write([]byte(s))
+			}
+			w.Flush()
+			l.Close()
+
+			parts := sessionParts(dir, "session")
+			if len(parts) != len(tt.wantParts) {
+				t.Fatalf("log has %d files, want %d", len(parts), len(tt.wantParts))
+			}
+			for i, part := range parts {
+				data, _ := os.ReadFile(part)
+				if string(data) != tt.wantParts[i] {
+					t.Errorf("file %d holds %q, want %q", i, data, tt.wantParts[i])
+				}
+			}
+
+			// Every line reaches the handler, whatever was rotated away
+			want := strings.Split(strings.TrimSuffix(strings.Join(tt.writes, ""), "\n"), "\n")
+			if strings.Join(lines, "|") != strings.Join(want, "|") {
+				t.Errorf("streamed lines %q, want %q", lines, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,394 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Process event types, sent to the frontend as "game:<type>"
+const (
+	EventStarted = "started"
+	EventExited  = "exited"
+)
+
+// DefaultStopGrace is how long a client may take to close after being asked before it is killed
+const DefaultStopGrace = 10 * time.Second
+
+// exitedHistory is how many exited sessions the supervisor remembers for Get
+const exitedHistory = 32
+
+// ErrNotRunning is returned when stopping a process that is not running
+var ErrNotRunning = errors.New("no game process running")
+
+// Process is a snapshot of a game session: a game client started by the launcher
+type Process struct {
+	ID         string `json:"id"` // Session ID, the name of the session's log; unique within an instance
+	InstanceID string `json:"instanceId"`
+	PID        int    `json:"pid"`                 // The started process; a wrapper when wrappers are used
+	ClientPID  int    `json:"clientPid,omitempty"` // The client itself once it was found under its wrappers
+	Command    string `json:"command"`             // Command line with secrets redacted
+	StartedAt  string `json:"startedAt"`           // ISO 8601 format
+	Running    bool   `json:"running"`
+	ExitCode   int    `json:"exitCode"`           // -1 when the process was ended by a signal
+	Signal     string `json:"signal,omitempty"`   // Signal that ended the process, if any
+	Error      string `json:"error,omitempty"`    // Why waiting for the process failed, if it did
+	ExitedAt   string `json:"exitedAt,omitempty"` // ISO 8601 format
+	DurationMs int64  `json:"durationMs"`         // Time the process ran, or has run so far
+	Stopped    bool   `json:"stopped,omitempty"`  // The launcher asked it to close
+	LogPath    string `json:"logPath,omitempty"`  // File the client's output is written to
+}
+
+// ProcessEvent is sent when a supervised process starts or exits
+type ProcessEvent struct {
+	Type    string  `json:"type"`
+	Process Process `json:"process"`
+}
+
+// StartOptions describes a process handed to the supervisor
+type StartOptions struct {
+	ID         string // Session ID; generated when empty, and must not be in use by the instance
+	InstanceID string
+	LogPath    string        // File the caller directs the client's output to
+	ClientPath string        // The client executable, used to find it under wrappers
+	Command    string        // Command line with secrets redacted
+	OnExit     func(Process) // Cleanup run with the final state after the process and everything it started have exited
+}
+
+// supervised is the supervisor's private record of a Process
+type supervised struct {
+	Process
+	cmd        *exec.Cmd
+	clientPath string
+	started    time.Time
+	onExit     func(Process)
+	done       chan struct{}
+}
+
+// sessionKey identifies a process: session IDs are only unique within an instance
+type sessionKey struct {
+	instanceID string
+	id         string
+}
+
+// Supervisor owns the game processes started by the launcher. It is safe for concurrent use.
+type Supervisor struct {
+	mu        sync.Mutex
+	processes map[sessionKey]*supervised
+	exited    []sessionKey // Exited sessions still in processes, oldest first
+	nextSeq   int
+	notify    func(ProcessEvent)
+}
+
+// NewSupervisor creates a supervisor.
+// notify is called when a process starts or exits; it runs without the supervisor lock held.
+func NewSupervisor(notify func(ProcessEvent)) *Supervisor {
+	return &Supervisor{
+		processes: make(map[sessionKey]*supervised),
+		notify:    notify,
+	}
+}
+
+// Start starts cmd and watches it until it exits
+func (s *Supervisor) Start(cmd *exec.Cmd, opts StartOptions) (Process, error) {
+	s.mu.Lock()
+	s.nextSeq++
+	key := sessionKey{opts.InstanceID, opts.ID}
+	if key.id == "" {
+		key.id = fmt.Sprintf("game-%d", s.nextSeq)
+	}
+	if s.processes[key] != nil {
+		s.mu.Unlock()
+		return Process{}, fmt.Errorf("instance %s already has a session %s", key.instanceID, key.id)
+	}
+
+	// Start under the lock, so a concurrent start cannot take the same key
+	startInOwnGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		s.mu.Unlock()
+		return Process{}, err
+	}
+	now := time.Now()
+
+	p := &supervised{
+		Process: Process{
+			ID:         key.id,
+			InstanceID: key.instanceID,
+			LogPath:    opts.LogPath,
+			PID:        cmd.Process.Pid,
+			Command:    opts.Command,
+			StartedAt:  now.Format(time.RFC3339),
+			Running:    true,
+		},
+		cmd:        cmd,
+		clientPath: opts.ClientPath,
+		started:    now,
+		onExit:     opts.OnExit,
+		done:       make(chan struct{}),
+	}
+	s.processes[key] = p
+	snapshot := p.Process
+	s.mu.Unlock()
+
+	logger.Info("Game process started", "session", p.ID, "pid", p.PID, "instance", p.InstanceID)
+	s.emit(EventStarted, snapshot)
+
+	go s.watch(p)
+	return snapshot, nil
+}
+
+// watch waits for a process, and anything it started in its group, then records how it ended
+func (s *Supervisor) watch(p *supervised) {
+	if p.clientPath != "" && p.cmd.Path != p.clientPath {
+		go s.findClient(p)
+	}
+
+	waitErr := p.cmd.Wait()
+	// Some wrappers return while the client they started keeps running
+	for groupAlive(p.PID) {
+		time.Sleep(time.Second)
+	}
+
+	s.mu.Lock()
+	ended := time.Now()
+	p.Running = false
+	p.ExitedAt = ended.Format(time.RFC3339)
+	p.DurationMs = ended.Sub(p.started).Milliseconds()
+	p.ExitCode = -1
+	if state := p.cmd.ProcessState; state != nil {
+		p.ExitCode = state.ExitCode()
+		if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			p.Signal = status.Signal().String()
+		}
+	}
+	var exitErr *exec.ExitError
+	if waitErr != nil && !errors.As(waitErr, &exitErr) {
+		p.Error = waitErr.Error()
+	}
+	snapshot := p.Process
+	onExit := p.onExit
+	s.mu.Unlock()
+
+	if onExit != nil {
+		onExit(snapshot)
+	}
+	close(p.done)
+
+	ranFor := time.Duration(snapshot.DurationMs) * time.Millisecond
+	if snapshot.Signal != "" {
+		logger.Info("Game process ended by signal", "session", snapshot.ID, "instance", snapshot.InstanceID, "signal", snapshot.Signal, "ranFor", ranFor)
+	} else {
+		logger.Info("Game process exited", "session", snapshot.ID, "instance", snapshot.InstanceID, "code", snapshot.ExitCode, "ranFor", ranFor)
+	}
+	s.emit(EventExited, snapshot)
+	s.forget(sessionKey{snapshot.InstanceID, snapshot.ID})
+}
+
+// forget adds an exited session to the history and drops the oldest beyond exitedHistory
+func (s *Supervisor) forget(key sessionKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.exited = append(s.exited, key)
+	for len(s.exited) > exitedHistory {
+		delete(s.processes, s.exited[0])
+		s.exited = s.exited[1:]
+	}
+}
+
+// findClient records the PID of the client once a wrapper has started it
+func (s *Supervisor) findClient(p *supervised) {
+	for i := 0; i < 30; i++ {
+		select {
+		case <-p.done:
+			return
+		default:
+		}
+		if pid := findGroupProcess(p.PID, p.clientPath); pid != 0 {
+			s.mu.Lock()
+			p.ClientPID = pid
+			s.mu.Unlock()
+			logger.Info("Game client found under wrapper", "session", p.ID, "pid", pid, "wrapperPid", p.PID)
+			return
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// emit sends an event to the notify callback, if there is one
+func (s *Supervisor) emit(eventType string, p Process) {
+	if s.notify != nil {
+		s.notify(ProcessEvent{Type: eventType, Process: p})
+	}
+}
+
+// snapshotLocked returns a copy of a process with its run time so far. s.mu must be held.
+func (s *Supervisor) snapshotLocked(p *supervised) Process {
+	c := p.Process
+	if c.Running {
+		c.DurationMs = time.Since(p.started).Milliseconds()
+	}
+	return c
+}
+
+// List returns the running processes, oldest first
+func (s *Supervisor) List() []Process {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]Process, 0, len(s.processes))
+	for _, p := range s.processes {
+		if p.Running {
+			list = append(list, s.snapshotLocked(p))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].StartedAt != list[j].StartedAt {
+			return list[i].StartedAt < list[j].StartedAt
+		}
+		return list[i].PID < list[j].PID
+	})
+	return list
+}
+
+// Get returns a snapshot of a session of an instance, running or recently exited
+func (s *Supervisor) Get(instanceID string, id string) (Process, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.processes[sessionKey{instanceID, id}]
+	if !ok {
+		return Process{}, fmt.Errorf("game process %s of instance %s not found", id, instanceID)
+	}
+	return s.snapshotLocked(p), nil
+}
+
+// Running reports whether any supervised process is still running
+func (s *Supervisor) Running() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.processes {
+		if p.Running {
+			return true
+		}
+	}
+	return false
+}
+
+// ListInstance returns the running sessions of an instance, oldest first
+func (s *Supervisor) ListInstance(instanceID string) []Process {
+	var sessions []Process
+	for _, p := range s.List() {
+		if p.InstanceID == instanceID {
+			sessions = append(sessions, p)
+		}
+	}
+	return sessions
+}
+
+// InstanceRunning reports whether an instance has a running session
+func (s *Supervisor) InstanceRunning(instanceID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.processes {
+		if p.Running && p.InstanceID == instanceID {
+			return true
+		}
+	}
+	return false
+}
+
+// Stop asks a session of an instance to close and kills it if it is still running after grace
+func (s *Supervisor) Stop(instanceID string, id string, grace time.Duration) error {
+	s.mu.Lock()
+	p, ok := s.processes[sessionKey{instanceID, id}]
+	if !ok || !p.Running {
+		s.mu.Unlock()
+		return ErrNotRunning
+	}
+	p.Stopped = true
+	s.mu.Unlock()
+
+	if err := terminateProcess(p.PID, false); err != nil {
+		logger.Warn("Failed to ask game process to close", "session", id, "error", err)
+	} else {
+		select {
+		case <-p.done:
+			return nil
+		case <-time.After(grace):
+			logger.Warn("Game process did not close in time, killing it", "session", id, "grace", grace)
+		}
+	}
+
+	killErr := terminateProcess(p.PID, true)
+	select {
+	case <-p.done:
+		return nil
+	case <-time.After(5 * time.Second):
+		if killErr != nil {
+			return fmt.Errorf("failed to kill game process %s: %w", id, killErr)
+		}
+		return fmt.Errorf("game process %s is still running after being killed", id)
+	}
+}
+
+// StopInstance stops every running session of an instance and returns the first error
+func (s *Supervisor) StopInstance(instanceID string, grace time.Duration) error {
+	return s.stopEach(s.ListInstance(instanceID), grace)
+}
+
+// StopAll stops every running process and returns the first error
+func (s *Supervisor) StopAll(grace time.Duration) error {
+	return s.stopEach(s.List(), grace)
+}
+
+// stopEach stops processes in parallel and returns the first error
+func (s *Supervisor) stopEach(running []Process, grace time.Duration) error {
+	if len(running) == 0 {
+		return ErrNotRunning
+	}
+
+	errs := make(chan error, len(running))
+	for _, p := range running {
+		go func(p Process) {
+			errs <- s.Stop(p.InstanceID, p.ID, grace)
+		}(p)
+	}
+
+	var first error
+	for range running {
+		if err := <-errs; err != nil && !errors.Is(err, ErrNotRunning) && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Wait blocks until a session of an instance has exited and returns its final state
+func (s *Supervisor) Wait(instanceID string, id string) (Process, error) {
+	s.mu.Lock()
+	p, ok := s.processes[sessionKey{instanceID, id}]
+	s.mu.Unlock()
+	if !ok {
+		return Process{}, fmt.Errorf("game process %s of instance %s not found", id, instanceID)
+	}
+	<-p.done
+
+	// The session may already have left the history
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return p.Process, nil
+}
+
+// WaitAll blocks until no supervised process is running
+func (s *Supervisor) WaitAll() {
+	for _, p := range s.List() {
+		s.Wait(p.InstanceID, p.ID)
+	}
+}
@@ -0,0 +1,184 @@
+//go:build !windows
+
+package game
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"HyPrism/internal/env"
+)
+
+func TestSupervisorSessionsPerInstance(t *testing.T) {
+	s := NewSupervisor(nil)
+
+	// Sessions started in the same second get the same ID in different instances
+	a, err := s.Start(exec.Command("sleep", "5"), StartOptions{ID: "2026-01-02_03-04-05", InstanceID: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := s.Start(exec.Command("sleep", "5"), StartOptions{ID: "2026-01-02_03-04-05", InstanceID: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.ID != "2026-01-02_03-04-05" || b.ID != a.ID {
+		t.Fatalf("session IDs were changed: %q, %q", a.ID, b.ID)
+	}
+
+	// The same session twice in one instance is refused before anything starts
+	if _, err := s.Start(exec.Command("sleep", "5"), StartOptions{ID: a.ID, InstanceID: "a"}); err == nil {
+		t.Fatal("a second session with the same ID was started")
+	}
+
+	if err := s.Stop("a", a.ID, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := s.Get("b", b.ID); err != nil || !p.Running {
+		t.Fatalf("stopping a also stopped b: %+v, %v", p, err)
+	}
+	if err := s.StopAll(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if s.Running() {
+		t.Fatal("sessions still running after StopAll")
+	}
+}
+
+func TestWritePatchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Aurora.so")
+	if err := writePatchFile(path, []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.Stat(path)
+
+	// A file already holding the patch is left alone, since a running session may have it loaded
+	if err := writePatchFile(path, []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := os.Stat(path)
+	if !os.SameFile(before, after) {
+		t.Fatal("an up to date patch was rewritten")
+	}
+
+	// A different patch replaces the file instead of overwriting the loaded one
+	if err := writePatchFile(path, []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+	replaced, _ := os.Stat(path)
+	if os.SameFile(before, replaced) {
+		t.Fatal("the patch was rewritten in place")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "v2" {
+		t.Fatalf("patch = %q", data)
+	}
+}
+
+func TestSessionsUsingInstance(t *testing.T) {
+	useTempAppDir(t)
+	for _, inst := range []*env.Instance{
+		env.NewInstance("owner", "", "release", 0),
+		{ID: "borrower", Branch: "release", Version: 1, GameFrom: "owner"},
+		env.NewInstance("other", "", "release", 2),
+	} {
+		if err := env.SaveInstance(inst); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := NewSupervisor(nil)
+	previous := Processes()
+	SetSupervisor(s)
+	defer SetSupervisor(previous)
+	defer s.StopAll(time.Second)
+
+	if _, err := s.Start(exec.Command("sleep", "5"), StartOptions{ID: "s1", InstanceID: "borrower"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		id   string
+		want int
+	}{
+		{id: "borrower", want: 1},
+		{id: "owner", want: 1}, // Its game files are in use by the borrower
+		{id: "other", want: 0},
+	}
+	for _, tt := range tests {
+		if got := SessionsUsingInstance(tt.id); len(got) != tt.want {
+			t.Errorf("%s: %d sessions, want %d", tt.id, len(got), tt.want)
+		}
+	}
+}
+
+func TestSupervisorForgetsOldSessions(t *testing.T) {
+	s := NewSupervisor(nil)
+
+	var first, last Process
+	for i := 0; i < exitedHistory+5; i++ {
+		p, err := s.Start(exec.Command("true"), StartOptions{InstanceID: "a"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		exited, err := s.Wait("a", p.ID)
+		if err != nil || exited.Running {
+			t.Fatalf("Wait returned %+v, %v", exited, err)
+		}
+		if i == 0 {
+			first = p
+		}
+		last = p
+	}
+
+	// The exit is recorded after Wait returns; give the watcher a moment
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.mu.Lock()
+		n := len(s.processes)
+		s.mu.Unlock()
+		if n <= exitedHistory || time.Now().After(deadline) {
+			if n > exitedHistory {
+				t.Fatalf("supervisor holds %d sessions, want at most %d", n, exitedHistory)
+			}
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := s.Get("a", first.ID); err == nil {
+		t.Error("the oldest exited session is still kept")
+	}
+	if p, err := s.Get("a", last.ID); err != nil || p.Running {
+		t.Errorf("the latest session is gone: %+v, %v", p, err)
+	}
+}
+
+func TestStopOneOfTwoSessionsOfTheSameClient(t *testing.T) {
+	// Both sessions run the same client executable, as two instances sharing a game do
+	client := filepath.Join(t.TempDir(), "HytaleClient")
+	if err := os.WriteFile(client, []byte("#!/bin/sh\nexec sleep 5\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	s := NewSupervisor(nil)
+	a, err := s.Start(exec.Command(client), StartOptions{InstanceID: "a", ClientPath: client})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := s.Start(exec.Command(client), StartOptions{InstanceID: "b", ClientPath: client})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.StopAll(time.Second) })
+
+	if err := s.Stop("a", a.ID, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := s.Get("a", a.ID); p.Running || !p.Stopped {
+		t.Errorf("stopped session: running %v, stopped %v", p.Running, p.Stopped)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if p, err := s.Get("b", b.ID); err != nil || !p.Running {
+		t.Fatalf("stopping a also stopped b: %+v, %v", p, err)
+	}
+}
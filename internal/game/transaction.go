@@ -0,0 +1,270 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"HyPrism/internal/env"
+	"HyPrism/internal/store"
+)
+
+// Install journal states
+const (
+	// txnStaging means the new tree is being built; the live game directory is untouched
+	txnStaging = "staging"
+	// txnSwapping means the staged tree was verified and is being moved into place
+	txnSwapping = "swapping"
+)
+
+const (
+	journalFileName   = ".installing"
+	stagingDirSuffix  = ".staging"
+	previousDirSuffix = ".old"
+	gameDirName       = "game"
+	versionFileName   = "version.txt"
+)
+
+// installJournal is written to an instance while an install or update is in flight,
+// so an interrupted transaction can be rolled back or finished on the next start
+type installJournal struct {
+	State     string    `json:"state"`
+	Branch    string    `json:"branch"`
+	Version   int       `json:"version"`
+	FromBuild int       `json:"fromBuild"`
+	ToBuild   int       `json:"toBuild"`
+	StartedAt time.Time `json:"startedAt"`
+	// RestoreTo is where the staged tree goes back to on abort when it was
+	// moved in from elsewhere (a kept generation) rather than built fresh
+	RestoreTo string `json:"restoreTo,omitempty"`
+}
+
+// installTransaction builds a new game tree next to the live one and swaps it in atomically
+type installTransaction struct {
+	instanceID  string
+	instanceDir string
+	journal     installJournal
+	committed   bool
+}
+
+// GameDir returns the live game directory of the instance
+func (t *installTransaction) GameDir() string {
+	return filepath.Join(t.instanceDir, gameDirName)
+}
+
+// StagingDir returns the directory the new game tree is built in
+func (t *installTransaction) StagingDir() string {
+	return t.GameDir() + stagingDirSuffix
+}
+
+func (t *installTransaction) previousDir() string {
+	return t.GameDir() + previousDirSuffix
+}
+
+func (t *installTransaction) journalPath() string {
+	return filepath.Join(t.instanceDir, journalFileName)
+}
+
+// beginInstall starts a transaction for an instance, clearing leftovers of earlier attempts
+func beginInstall(branch string, version int, fromBuild, toBuild int) (*installTransaction, error) {
+	inst, err := env.EnsureInstance(branch, version)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &installTransaction{
+		instanceID:  inst.ID,
+		instanceDir: inst.Dir(),
+		journal: installJournal{
+			State:     txnStaging,
+			Branch:    branch,
+			Version:   version,
+			FromBuild: fromBuild,
+			ToBuild:   toBuild,
+			StartedAt: time.Now(),
+		},
+	}
+
+	if err := os.MkdirAll(t.instanceDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create instance directory: %w", err)
+	}
+	if err := os.RemoveAll(t.StagingDir()); err != nil {
+		return nil, fmt.Errorf("failed to clear old staging directory: %w", err)
+	}
+	if err := os.RemoveAll(t.previousDir()); err != nil {
+		return nil, fmt.Errorf("failed to clear old backup directory: %w", err)
+	}
+	if err := t.writeJournal(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(t.StagingDir(), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	return t, nil
+}
+
+// commit verifies the staged tree and swaps it into place
+func (t *installTransaction) commit() error {
+	clientPath := ClientPath(t.StagingDir())
+	if _, err := os.Stat(clientPath); err != nil {
+		return fmt.Errorf("installation incomplete: client not found at %s", clientPath)
+	}
+	if runtime.GOOS != "windows" {
+		os.Chmod(clientPath, 0755)
+	}
+
+	t.journal.State = txnSwapping
+	if err := t.writeJournal(); err != nil {
+		return err
+	}
+
+	if err := t.swap(); err != nil {
+		return err
+	}
+
+	t.committed = true
+	t.finish()
+	return nil
+}
+
+// swap moves the live tree aside and the staged tree into its place
+func (t *installTransaction) swap() error {
+	if _, err := os.Stat(t.GameDir()); err == nil {
+		if err := os.Rename(t.GameDir(), t.previousDir()); err != nil {
+			return fmt.Errorf("failed to move old game files aside: %w", err)
+		}
+	}
+
+	if err := os.Rename(t.StagingDir(), t.GameDir()); err != nil {
+		// Put the old tree back so the instance keeps working
+		os.Rename(t.previousDir(), t.GameDir())
+		return fmt.Errorf("failed to move new game files into place: %w", err)
+	}
+	return nil
+}
+
+// finish records the new build in the instance descriptor, keeps the replaced tree as a generation and drops the journal
+func (t *installTransaction) finish() {
+	if err := env.SetInstanceBuild(t.instanceID, t.journal.Branch, t.journal.Version, t.journal.ToBuild); err != nil {
+		logger.Warn("Failed to record installed build", "error", err)
+	}
+	// Rolling back from a build marks it broken, so automatic updates do not install it again
+	if t.journal.RestoreTo != "" && t.journal.FromBuild > t.journal.ToBuild {
+		if err := env.SkipInstanceBuild(t.instanceID, t.journal.FromBuild); err != nil {
+			logger.Warn("Failed to record skipped build", "build", t.journal.FromBuild, "error", err)
+		}
+	}
+	env.InvalidateInstanceSizes(t.instanceID)
+
+	if dirExists(t.previousDir()) {
+		if t.journal.FromBuild == t.journal.ToBuild {
+			os.RemoveAll(t.previousDir())
+		} else if err := archiveGeneration(t.instanceDir, t.previousDir(), t.journal.FromBuild); err != nil {
+			logger.Warn("Failed to keep build for rollback", "build", t.journal.FromBuild, "error", err)
+			os.RemoveAll(t.previousDir())
+		}
+	}
+	os.Remove(t.journalPath())
+
+	// Replaced trees and pruned generations may have been the last users of some stored files
+	go store.CollectGarbage()
+}
+
+// abort discards the staged tree; the live game directory was never touched
+func (t *installTransaction) abort() {
+	if t.committed {
+		return
+	}
+	if t.journal.RestoreTo != "" && dirExists(t.StagingDir()) && !dirExists(t.journal.RestoreTo) {
+		if err := os.Rename(t.StagingDir(), t.journal.RestoreTo); err != nil {
+			logger.Warn("Failed to move staged tree back", "to", t.journal.RestoreTo, "error", err)
+		}
+	}
+	os.RemoveAll(t.StagingDir())
+	os.Remove(t.journalPath())
+}
+
+func (t *installTransaction) writeJournal() error {
+	data, err := json.MarshalIndent(t.journal, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := t.journalPath() + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write install journal: %w", err)
+	}
+	if err := os.Rename(tmpPath, t.journalPath()); err != nil {
+		return fmt.Errorf("failed to write install journal: %w", err)
+	}
+	return nil
+}
+
+// RecoverInterruptedInstalls rolls back or finishes install transactions that were
+// interrupted by a crash or by the launcher being closed
+func RecoverInterruptedInstalls() {
+	for _, inst := range env.ListInstanceDescriptors() {
+		instanceDir := inst.Dir()
+		data, err := os.ReadFile(filepath.Join(instanceDir, journalFileName))
+		if err != nil {
+			continue
+		}
+
+		t := &installTransaction{instanceID: inst.ID, instanceDir: instanceDir}
+		if err := json.Unmarshal(data, &t.journal); err != nil {
+			logger.Warn("Discarding unreadable install journal", "dir", instanceDir)
+			t.journal.State = txnStaging
+		}
+
+		if err := t.recover(); err != nil {
+			logger.Error("Failed to recover interrupted install", "dir", instanceDir, "error", err)
+		}
+	}
+}
+
+// recover brings an instance back to a consistent state from its journal
+func (t *installTransaction) recover() error {
+	if t.journal.State != txnSwapping {
+		// Interrupted while staging: the live tree is intact, drop the partial one
+		logger.Info("Rolling back interrupted install", "dir", t.instanceDir)
+		t.abort()
+		return nil
+	}
+
+	_, stagedErr := os.Stat(ClientPath(t.StagingDir()))
+	stagedReady := stagedErr == nil
+	_, liveErr := os.Stat(t.GameDir())
+	liveExists := liveErr == nil
+
+	switch {
+	case stagedReady:
+		// The new tree was verified before the swap started; complete the swap
+		logger.Info("Finishing interrupted update", "build", t.journal.ToBuild, "dir", t.instanceDir)
+		if err := t.swap(); err != nil {
+			return err
+		}
+		t.finish()
+	case liveExists && !dirExists(t.StagingDir()):
+		// Both renames happened; only the cleanup is missing
+		t.finish()
+	default:
+		// Nothing usable was staged: restore the previous tree if it was moved aside
+		logger.Info("Restoring previous game files", "dir", t.instanceDir)
+		if !liveExists && dirExists(t.previousDir()) {
+			if err := os.Rename(t.previousDir(), t.GameDir()); err != nil {
+				return err
+			}
+		}
+		t.abort()
+	}
+	return nil
+}
+
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
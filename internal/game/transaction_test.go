@@ -0,0 +1,93 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"HyPrism/internal/env"
+)
+
+func TestRecoverInterruptedInstalls(t *testing.T) {
+	tests := []struct {
+		name  string
+		state string
+		// Layout when the launcher stopped; 0 means the tree is missing, -1 a tree without a client
+		live, staged, previous int
+		want                   int  // Build the live tree holds after recovery
+		wantKept               bool // Build 1 was kept as a generation
+	}{
+		{name: "staging is rolled back", state: txnStaging, live: 1, staged: -1, want: 1},
+		{name: "verified tree not yet swapped", state: txnSwapping, live: 1, staged: 2, want: 2, wantKept: true},
+		{name: "live tree moved aside", state: txnSwapping, staged: 2, previous: 1, want: 2, wantKept: true},
+		{name: "swapped but not cleaned up", state: txnSwapping, live: 2, previous: 1, want: 2, wantKept: true},
+		{name: "nothing usable staged", state: txnSwapping, staged: -1, previous: 1, want: 1},
+	}
+
+	layout := func(t *testing.T, dir string, build int) {
+		switch {
+		case build > 0:
+			writeTree(t, dir, build)
+		case build < 0:
+			if err := os.MkdirAll(filepath.Join(dir, "Client"), 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempAppDir(t)
+			inst, err := env.EnsureInstance("release", 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := env.SetInstanceBuild(inst.ID, "release", 0, 1); err != nil {
+				t.Fatal(err)
+			}
+			// EnsureInstance creates an empty game directory
+			os.RemoveAll(inst.GameDir())
+
+			txn := &installTransaction{
+				instanceID:  inst.ID,
+				instanceDir: inst.Dir(),
+				journal: installJournal{
+					State:     tt.state,
+					Branch:    "release",
+					FromBuild: 1,
+					ToBuild:   2,
+					StartedAt: time.Now(),
+				},
+			}
+			layout(t, txn.GameDir(), tt.live)
+			layout(t, txn.StagingDir(), tt.staged)
+			layout(t, txn.previousDir(), tt.previous)
+			if err := txn.writeJournal(); err != nil {
+				t.Fatal(err)
+			}
+
+			RecoverInterruptedInstalls()
+
+			if got := treeBuild(t, txn.GameDir()); got != tt.want {
+				t.Fatalf("live tree holds build %d, want %d", got, tt.want)
+			}
+			if got := env.FindInstance("release", 0).InstalledBuild; got != tt.want {
+				t.Errorf("instance records build %d, want %d", got, tt.want)
+			}
+			for _, leftover := range []string{txn.journalPath(), txn.StagingDir(), txn.previousDir()} {
+				if _, err := os.Stat(leftover); err == nil {
+					t.Errorf("%s was left behind", filepath.Base(leftover))
+				}
+			}
+
+			kept := false
+			for _, g := range listGenerations(inst.Dir()) {
+				kept = kept || g.Build == 1
+			}
+			if kept != tt.wantKept {
+				t.Errorf("build 1 kept = %v, want %v", kept, tt.wantKept)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package game
+
+import (
+	"context"
+	"fmt"
+
+	"HyPrism/internal/env"
+	"HyPrism/internal/pwr"
+	"HyPrism/internal/pwr/butler"
+)
+
+// VerifyInstance checks an instance's game files against the signature of its installed build
+func VerifyInstance(ctx context.Context, branch string, version int, progress func(stage string, progress float64, msg string, file string, speed string, down, total int64)) (*pwr.VerifyResult, error) {
+	if !env.IsVersionInstalled(branch, version) {
+		return nil, fmt.Errorf("instance %s v%d is not installed", branch, version)
+	}
+
+	if _, err := butler.InstallButler(ctx, progress); err != nil {
+		return nil, fmt.Errorf("failed to install Butler tool: %w", err)
+	}
+
+	build := env.GetInstanceBuild(branch, version)
+	return pwr.VerifyDir(ctx, branch, build, env.GetInstanceGameDir(branch, version), progress)
+}
+
+// HealInstance verifies an instance and repairs only the damaged or missing files,
+// keeping the rest of the game tree, UserData and mods in place
+func HealInstance(ctx context.Context, branch string, version int, progress func(stage string, progress float64, msg string, file string, speed string, down, total int64)) (*pwr.HealResult, error) {
+	installMutex.Lock()
+	if isInstalling {
+		installMutex.Unlock()
+		return nil, fmt.Errorf("installation already in progress")
+	}
+	isInstalling = true
+	installMutex.Unlock()
+
+	defer func() {
+		installMutex.Lock()
+		isInstalling = false
+		installMutex.Unlock()
+	}()
+
+	if !env.IsVersionInstalled(branch, version) {
+		return nil, fmt.Errorf("instance %s v%d is not installed", branch, version)
+	}
+	if _, err := butler.InstallButler(ctx, progress); err != nil {
+		return nil, fmt.Errorf("failed to install Butler tool: %w", err)
+	}
+
+	// HealDir verifies first and only touches what butler reports as wounded
+	build := env.GetInstanceBuild(branch, version)
+	result, err := pwr.HealDir(ctx, branch, build, env.GetInstanceGameDir(branch, version), progress)
+	if err != nil {
+		return nil, err
+	}
+
+	if progress != nil {
+		if len(result.Repaired) == 0 {
+			progress("complete", 100, "No damaged files found", "", "", 0, 0)
+		} else {
+			progress("complete", 100, fmt.Sprintf("Repaired %d file(s)", len(result.Repaired)), "", "", 0, 0)
+		}
+	}
+	return result, nil
+}
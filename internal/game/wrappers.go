@@ -0,0 +1,73 @@
+package game
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"HyPrism/internal/env"
+)
+
+// wrapCommand places the active wrappers of a launch profile in front of c.
+// Each wrapper must be found on the PATH the client is started with.
+func wrapCommand(c *LaunchCommand, wrappers []env.LaunchWrapper) error {
+	if len(wrappers) == 0 {
+		return nil
+	}
+
+	searchPath := os.Getenv("PATH")
+	for _, o := range c.overrides {
+		if o.key == "PATH" {
+			searchPath = o.value
+		}
+	}
+
+	var prefix []string
+	for _, w := range wrappers {
+		resolved, err := lookPathIn(strings.TrimSpace(w.Command), searchPath)
+		if err != nil {
+			return fmt.Errorf("wrapper %s: %w", w.Command, err)
+		}
+		prefix = append(prefix, resolved)
+		prefix = append(prefix, w.Args...)
+	}
+
+	args := append([]string{}, prefix[1:]...)
+	args = append(args, c.Path)
+	c.Args = append(args, c.Args...)
+	c.Path = prefix[0]
+	return nil
+}
+
+// lookPathIn finds an executable like exec.LookPath, but searches pathValue instead of the launcher's PATH
+func lookPathIn(name string, pathValue string) (string, error) {
+	if strings.Contains(name, "/") {
+		if err := checkExecutable(name); err != nil {
+			return "", err
+		}
+		return filepath.Abs(name)
+	}
+	for _, dir := range filepath.SplitList(pathValue) {
+		if dir == "" {
+			continue
+		}
+		candidate := filepath.Join(dir, name)
+		if checkExecutable(candidate) == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("%q not found on PATH; install it or remove the wrapper", name)
+}
+
+// checkExecutable reports why path cannot be executed, if it cannot
+func checkExecutable(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() || info.Mode()&0111 == 0 {
+		return fmt.Errorf("%s is not executable", path)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+//go:build !windows
+
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"HyPrism/internal/env"
+)
+
+func TestWrapCommand(t *testing.T) {
+	bin := t.TempDir()
+	other := t.TempDir()
+	for _, name := range []string{"gamemoderun", "mangohud"} {
+		os.WriteFile(filepath.Join(bin, name), []byte("#!/bin/sh\n"), 0755)
+	}
+	os.WriteFile(filepath.Join(other, "prime-run"), []byte("#!/bin/sh\n"), 0755)
+	os.WriteFile(filepath.Join(bin, "plain"), []byte("data"), 0644)
+	t.Setenv("PATH", bin)
+
+	tests := []struct {
+		name      string
+		wrappers  []env.LaunchWrapper
+		overrides []envOverride
+		wantPath  string
+		wantArgs  []string
+		wantErr   bool
+	}{
+		{
+			name:     "no wrappers",
+			wantPath: "/game/HytaleClient",
+			wantArgs: []string{"--uuid", "123"},
+		},
+		{
+			name:     "wrapper with arguments",
+			wrappers: []env.LaunchWrapper{{Command: "mangohud", Args: []string{"--dlsym"}}},
+			wantPath: filepath.Join(bin, "mangohud"),
+			wantArgs: []string{"--dlsym", "/game/HytaleClient", "--uuid", "123"},
+		},
+		{
+			name:     "outermost wrapper first",
+			wrappers: []env.LaunchWrapper{{Command: "gamemoderun"}, {Command: "mangohud"}},
+			wantPath: filepath.Join(bin, "gamemoderun"),
+			wantArgs: []string{filepath.Join(bin, "mangohud"), "/game/HytaleClient", "--uuid", "123"},
+		},
+		{
+			name:     "absolute path",
+			wrappers: []env.LaunchWrapper{{Command: filepath.Join(other, "prime-run")}},
+			wantPath: filepath.Join(other, "prime-run"),
+			wantArgs: []string{"/game/HytaleClient", "--uuid", "123"},
+		},
+		{
+			name:      "profile PATH is searched",
+			wrappers:  []env.LaunchWrapper{{Command: "prime-run"}},
+			overrides: []envOverride{{"PATH", other}},
+			wantPath:  filepath.Join(other, "prime-run"),
+			wantArgs:  []string{"/game/HytaleClient", "--uuid", "123"},
+		},
+		{
+			name:     "missing wrapper",
+			wrappers: []env.LaunchWrapper{{Command: "prime-run"}},
+			wantErr:  true,
+		},
+		{
+			name:     "not executable",
+			wrappers: []env.LaunchWrapper{{Command: "plain"}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &LaunchCommand{
+				Path:      "/game/HytaleClient",
+				Args:      []string{"--uuid", "123"},
+				overrides: tt.overrides,
+			}
+			err := wrapCommand(c, tt.wrappers)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("wrapCommand error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if c.Path != tt.wantPath || !reflect.DeepEqual(c.Args, tt.wantArgs) {
+				t.Errorf("command = %s %v, want %s %v", c.Path, c.Args, tt.wantPath, tt.wantArgs)
+			}
+		})
+	}
+}
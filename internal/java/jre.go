@@ -11,10 +11,14 @@ import (
 	"time"
 
 	"HyPrism/internal/env"
+	"HyPrism/internal/logging"
 	"HyPrism/internal/util"
 	"HyPrism/internal/util/download"
 )
 
+// logger writes the java subsystem's part of the launcher log
+var logger = logging.For(logging.Java)
+
 // JREPlatform represents a JRE download for a specific platform
 type JREPlatform struct {
 	URL    string `json:"url"`
@@ -41,7 +45,7 @@ func DownloadJRE(ctx context.Context, progressCallback func(stage string, progre
 
 	// Check if JRE already exists
 	if _, err := os.Stat(javaPath); err == nil {
-		fmt.Println("Java Runtime already installed")
+		logger.Debug("Java runtime already installed", "path", javaPath)
 		if progressCallback != nil {
 			progressCallback("jre", 100, "Java Runtime ready", "", "", 0, 0)
 		}
@@ -56,6 +60,7 @@ func DownloadJRE(ctx context.Context, progressCallback func(stage string, progre
 	jreConfig, err := fetchJREConfig(ctx)
 	if err != nil {
 		// Fall back to default Adoptium URL
+		logger.Warn("Failed to fetch Java runtime config, using Adoptium", "error", err)
 		return downloadAdoptiumJRE(ctx, jreDir, progressCallback)
 	}
 
@@ -89,7 +94,8 @@ func DownloadJRE(ctx context.Context, progressCallback func(stage string, progre
 
 	archivePath := filepath.Join(env.GetCacheDir(), "jre"+archiveExt)
 
-	if err := download.DownloadWithProgress(archivePath, archConfig.URL, "jre", 0.8, progressCallback); err != nil {
+	logger.Info("Downloading Java runtime", "url", archConfig.URL)
+	if err := download.DownloadWithProgress(ctx, archivePath, archConfig.URL, "jre", 0.8, progressCallback); err != nil {
 		return fmt.Errorf("failed to download JRE: %w", err)
 	}
 
@@ -120,6 +126,7 @@ func DownloadJRE(ctx context.Context, progressCallback func(stage string, progre
 	if err := normalizeJREStructure(jreDir); err != nil {
 		return fmt.Errorf("failed to normalize JRE structure: %w", err)
 	}
+	logger.Info("Java runtime installed", "dir", jreDir)
 
 	if progressCallback != nil {
 		progressCallback("jre", 100, "Java Runtime installed", "", "", 0, 0)
@@ -180,7 +187,8 @@ func downloadAdoptiumJRE(ctx context.Context, jreDir string, progressCallback fu
 
 	archivePath := filepath.Join(env.GetCacheDir(), "jre."+archiveType)
 
-	if err := download.DownloadWithProgress(archivePath, url, "jre", 0.8, progressCallback); err != nil {
+	logger.Info("Downloading Java runtime from Adoptium", "url", url)
+	if err := download.DownloadWithProgress(ctx, archivePath, url, "jre", 0.8, progressCallback); err != nil {
 		return fmt.Errorf("failed to download JRE from Adoptium: %w", err)
 	}
 
@@ -197,6 +205,7 @@ func downloadAdoptiumJRE(ctx context.Context, jreDir string, progressCallback fu
 	if err := normalizeJREStructure(jreDir); err != nil {
 		return fmt.Errorf("failed to normalize JRE structure: %w", err)
 	}
+	logger.Info("Java runtime installed", "dir", jreDir)
 
 	if progressCallback != nil {
 		progressCallback("jre", 100, "Java Runtime installed", "", "", 0, 0)
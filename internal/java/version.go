@@ -0,0 +1,78 @@
+package java
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"HyPrism/internal/util"
+)
+
+// probeTimeout bounds how long the runtime may take to describe itself
+const probeTimeout = 15 * time.Second
+
+// RuntimeInfo describes a Java runtime as it reports itself
+type RuntimeInfo struct {
+	Vendor  string `json:"vendor"`
+	Version string `json:"version"`
+	Arch    string `json:"arch"`   // os.arch of the runtime, such as amd64 or aarch64
+	Output  string `json:"output"` // First line of java -version
+}
+
+// versionLinePattern reads the first line of java -version, as in: openjdk version "25.0.1" 2025-10-21
+var versionLinePattern = regexp.MustCompile(`version "([^"]+)"`)
+
+// Probe runs java -version at javaPath and reads the vendor, version and architecture it reports
+func Probe(ctx context.Context, javaPath string) (*RuntimeInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	// The property listing goes to stderr together with the usual version banner
+	cmd := exec.CommandContext(ctx, javaPath, "-XshowSettings:properties", "-version")
+	util.HideConsoleWindow(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("java -version did not finish: %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("java -version failed: %w: %s", err, strings.TrimSpace(lastLine(string(out))))
+	}
+	return parseVersionOutput(string(out)), nil
+}
+
+// parseVersionOutput reads the output of java -XshowSettings:properties -version
+func parseVersionOutput(out string) *RuntimeInfo {
+	info := &RuntimeInfo{}
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if key, value, ok := strings.Cut(line, " = "); ok {
+			switch key {
+			case "java.vendor":
+				info.Vendor = value
+			case "java.version":
+				info.Version = value
+			case "os.arch":
+				info.Arch = value
+			}
+			continue
+		}
+		if m := versionLinePattern.FindStringSubmatch(line); m != nil && info.Output == "" {
+			info.Output = line
+			if info.Version == "" {
+				info.Version = m[1]
+			}
+		}
+	}
+	return info
+}
+
+// lastLine returns the last non-empty line of s
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return lines[len(lines)-1]
+}
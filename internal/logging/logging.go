@@ -0,0 +1,237 @@
+// Package logging writes the launcher's own log: leveled, structured records with secrets redacted
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Subsystems whose level can be set on their own; other parts of the launcher log as Launcher
+const (
+	Launcher = "launcher"
+	Pwr      = "pwr"
+	Mods     = "mods"
+	Java     = "java"
+	Updater  = "updater"
+	Game     = "game"
+)
+
+// Subsystems lists the subsystems in the order settings show them
+var Subsystems = []string{Launcher, Pwr, Mods, Java, Updater, Game}
+
+// Log formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// SubsystemKey is the attribute every record carries its subsystem in
+const SubsystemKey = "subsystem"
+
+// Options configures where and how the launcher log is written
+type Options struct {
+	Path       string            // File the log is written to; empty logs to the console only
+	Format     string            // FormatText or FormatJSON
+	MaxBytes   int64             // Size of the file before it is rotated
+	MaxBackups int               // Rotated files kept next to the log: <path>.1 is the newest
+	Level      string            // Level of subsystems not in Levels: debug, info, warn or error
+	Levels     map[string]string // Per-subsystem levels
+}
+
+// state is the configuration every logger reads when it writes a record
+type state struct {
+	handler slog.Handler
+	file    *rotatingFile
+	path    string
+	level   slog.Level
+	levels  map[string]slog.Level
+}
+
+var (
+	mu      sync.RWMutex
+	current = &state{
+		handler: newHandler(os.Stdout, FormatText),
+		level:   slog.LevelInfo,
+	}
+)
+
+// Setup starts writing the log as opts describes. Loggers created before Setup follow the new settings.
+func Setup(opts Options) error {
+	level, levels, err := parseLevels(opts.Level, opts.Levels)
+	if err != nil {
+		return err
+	}
+
+	next := &state{level: level, levels: levels, path: opts.Path}
+	var out io.Writer = os.Stdout
+	if opts.Path != "" {
+		file, err := openRotating(opts.Path, opts.MaxBytes, opts.MaxBackups)
+		if err != nil {
+			return err
+		}
+		next.file = file
+		out = consoleCopy{file}
+	}
+	next.handler = newHandler(out, opts.Format)
+
+	mu.Lock()
+	prev := current
+	current = next
+	mu.Unlock()
+
+	if prev.file != nil {
+		prev.file.Close()
+	}
+	return nil
+}
+
+// SetLevels changes the default and per-subsystem levels without reopening the log
+func SetLevels(level string, levels map[string]string) error {
+	def, parsed, err := parseLevels(level, levels)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	next := *current
+	next.level = def
+	next.levels = parsed
+	current = &next
+	mu.Unlock()
+	return nil
+}
+
+// Path returns the file the log is written to, or "" before Setup
+func Path() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current.path
+}
+
+// Close flushes and closes the log file; later records go to the console only
+func Close() error {
+	mu.Lock()
+	prev := current
+	current = &state{
+		handler: newHandler(os.Stdout, FormatText),
+		level:   prev.level,
+		levels:  prev.levels,
+	}
+	mu.Unlock()
+
+	if prev.file != nil {
+		return prev.file.Close()
+	}
+	return nil
+}
+
+// For returns the logger of a subsystem
+func For(subsystem string) *slog.Logger {
+	return slog.New(&subsystemHandler{subsystem: subsystem})
+}
+
+// ParseLevel reads a level name: debug, info, warn (or warning) or error. An empty name is info.
+func ParseLevel(name string) (slog.Level, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return slog.LevelInfo, nil
+	}
+	if strings.EqualFold(name, "warning") {
+		return slog.LevelWarn, nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q", name)
+	}
+	return level, nil
+}
+
+// parseLevels parses a default level and per-subsystem levels
+func parseLevels(level string, levels map[string]string) (slog.Level, map[string]slog.Level, error) {
+	def, err := ParseLevel(level)
+	if err != nil {
+		return 0, nil, err
+	}
+	parsed := make(map[string]slog.Level, len(levels))
+	for subsystem, name := range levels {
+		l, err := ParseLevel(name)
+		if err != nil {
+			return 0, nil, fmt.Errorf("%s: %w", subsystem, err)
+		}
+		parsed[strings.ToLower(subsystem)] = l
+	}
+	return def, parsed, nil
+}
+
+// newHandler creates the handler that formats records, with secrets redacted
+func newHandler(w io.Writer, format string) slog.Handler {
+	opts := &slog.HandlerOptions{
+		// Levels are checked per subsystem before a record gets here
+		Level:       slog.LevelDebug,
+		ReplaceAttr: redactAttr,
+	}
+	if strings.EqualFold(format, FormatJSON) {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// consoleCopy writes to a log file and copies to stdout, which may not exist in GUI builds
+type consoleCopy struct {
+	file io.Writer
+}
+
+func (c consoleCopy) Write(p []byte) (int, error) {
+	n, err := c.file.Write(p)
+	os.Stdout.Write(p)
+	return n, err
+}
+
+// subsystemHandler tags records with a subsystem and filters them by its level.
+// It looks up the current state for every record so Setup applies to existing loggers.
+type subsystemHandler struct {
+	subsystem string
+	ops       []func(slog.Handler) slog.Handler // WithAttrs and WithGroup calls, replayed in order
+}
+
+func (h *subsystemHandler) Enabled(_ context.Context, level slog.Level) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	min, ok := current.levels[h.subsystem]
+	if !ok {
+		min = current.level
+	}
+	return level >= min
+}
+
+func (h *subsystemHandler) Handle(ctx context.Context, r slog.Record) error {
+	mu.RLock()
+	handler := current.handler
+	mu.RUnlock()
+
+	handler = handler.WithAttrs([]slog.Attr{slog.String(SubsystemKey, h.subsystem)})
+	for _, op := range h.ops {
+		handler = op(handler)
+	}
+	return handler.Handle(ctx, r)
+}
+
+func (h *subsystemHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithAttrs(attrs) })
+}
+
+func (h *subsystemHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithGroup(name) })
+}
+
+// with returns a copy of h with op added
+func (h *subsystemHandler) with(op func(slog.Handler) slog.Handler) slog.Handler {
+	ops := make([]func(slog.Handler) slog.Handler, len(h.ops), len(h.ops)+1)
+	copy(ops, h.ops)
+	return &subsystemHandler{subsystem: h.subsystem, ops: append(ops, op)}
+}
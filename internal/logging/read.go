@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Filter selects records when reading the log
+type Filter struct {
+	MinLevel  slog.Level // Records below this level are skipped
+	Subsystem string     // Only records of this subsystem; empty for all
+	MaxLines  int        // The newest records kept; 0 for all
+}
+
+var (
+	textLevelPattern     = regexp.MustCompile(`(?:^| )level=(\S+)`)
+	textSubsystemPattern = regexp.MustCompile(`(?:^| )subsystem=(\S+)`)
+)
+
+// Read returns the records of a log and its rotated files that match f, oldest first
+func Read(path string, maxBackups int, f Filter) (string, error) {
+	var lines []string
+	found := false
+	for i := maxBackups; i >= 0; i-- {
+		name := path
+		if i > 0 {
+			name = backupPath(path, i)
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return "", err
+		}
+		found = true
+		for _, line := range strings.Split(string(data), "\n") {
+			if line != "" && f.matches(line) {
+				lines = append(lines, line)
+			}
+		}
+	}
+	if !found {
+		return "", os.ErrNotExist
+	}
+
+	if f.MaxLines > 0 && len(lines) > f.MaxLines {
+		lines = lines[len(lines)-f.MaxLines:]
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// matches reports whether a line of the log passes the filter.
+// Lines written in either format are understood, since the format can change between runs.
+func (f Filter) matches(line string) bool {
+	levelName, subsystem := parseLine(line)
+	if levelName == "" {
+		// Not a record; only shown when nothing is filtered
+		return f.MinLevel <= slog.LevelDebug && f.Subsystem == ""
+	}
+	if f.Subsystem != "" && !strings.EqualFold(subsystem, f.Subsystem) {
+		return false
+	}
+	level, err := ParseLevel(levelName)
+	if err != nil {
+		return true
+	}
+	return level >= f.MinLevel
+}
+
+// parseLine returns the level and subsystem of a record
+func parseLine(line string) (level string, subsystem string) {
+	if strings.HasPrefix(line, "{") {
+		var record struct {
+			Level     string `json:"level"`
+			Subsystem string `json:"subsystem"`
+		}
+		if json.Unmarshal([]byte(line), &record) == nil {
+			return record.Level, record.Subsystem
+		}
+		return "", ""
+	}
+	if m := textLevelPattern.FindStringSubmatch(line); m != nil {
+		level = m[1]
+	}
+	if m := textSubsystemPattern.FindStringSubmatch(line); m != nil {
+		subsystem = m[1]
+	}
+	return level, subsystem
+}
@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces secrets in the log
+const Redacted = "[REDACTED]"
+
+// secretKeyWords mark an attribute whose whole value is a secret
+var secretKeyWords = []string{"token", "secret", "password", "passwd", "apikey", "api_key", "api-key", "authorization", "credential", "cookie"}
+
+var (
+	// key=value and "key": "value" pairs in free text
+	secretPairPattern = regexp.MustCompile(`(?i)([\w-]*(?:token|secret|passw(?:or)?d|api[_-]?key)["']?\s*[:=]\s*["']?)([^\s"'&,;]+)`)
+	// Authorization header values
+	authHeaderPattern = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[\w.~+/=-]+`)
+	// JSON web tokens
+	jwtPattern = regexp.MustCompile(`\beyJ[\w-]+\.[\w-]+\.[\w-]*`)
+)
+
+// Redact removes tokens, API keys and passwords from text
+func Redact(s string) string {
+	s = secretPairPattern.ReplaceAllString(s, "${1}"+Redacted)
+	s = authHeaderPattern.ReplaceAllString(s, "${1} "+Redacted)
+	return jwtPattern.ReplaceAllString(s, Redacted)
+}
+
+// isSecretKey reports whether an attribute name says its value is a secret
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, word := range secretKeyWords {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactAttr is the ReplaceAttr function of the log handlers
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey) {
+		return a
+	}
+	if isSecretKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, Redact(err.Error()))
+		}
+	}
+	return a
+}
@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain text", in: "Downloading build 4", want: "Downloading build 4"},
+		{name: "query parameter", in: "GET /patch?token=abc123&x=1", want: "GET /patch?token=" + Redacted + "&x=1"},
+		{name: "key value", in: "session_token=abc password=hunter2", want: "session_token=" + Redacted + " password=" + Redacted},
+		{name: "json field", in: `{"apiKey": "k-1", "name": "a"}`, want: `{"apiKey": "` + Redacted + `", "name": "a"}`},
+		{name: "bearer header", in: "Authorization: Bearer abc.def", want: "Authorization: Bearer " + Redacted},
+		{name: "jwt", in: "got eyJhbGciOi.eyJzdWIiOi.sig back", want: "got " + Redacted + " back"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Redact(tt.in); got != tt.want {
+				t.Errorf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactAttr(t *testing.T) {
+	tests := []struct {
+		name string
+		attr slog.Attr
+		want string
+	}{
+		{name: "secret key", attr: slog.Int("identityToken", 42), want: Redacted},
+		{name: "secret in a value", attr: slog.String("url", "https://x/?token=abc"), want: "https://x/?token=" + Redacted},
+		{name: "secret in an error", attr: slog.Any("error", errors.New("bad password=abc")), want: "bad password=" + Redacted},
+		{name: "ordinary value", attr: slog.Int("build", 4), want: "4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactAttr(nil, tt.attr).Value.String(); got != tt.want {
+				t.Errorf("redactAttr = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
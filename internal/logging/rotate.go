@@ -0,0 +1,108 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// rotatingFile is a log file that is renamed to <path>.1 once it reaches maxBytes.
+// Older files shift to <path>.2 and so on; files past maxBackups are removed.
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxBytes   int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// openRotating opens a log file for appending
+func openRotating(path string, maxBytes int64, maxBackups int) (*rotatingFile, error) {
+	if maxBytes <= 0 {
+		maxBytes = 5 * 1024 * 1024
+	}
+	if maxBackups < 0 {
+		maxBackups = 0
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f := &rotatingFile{path: path, maxBytes: maxBytes, maxBackups: maxBackups}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// open opens or creates the current file
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// Write appends p, rotating first when p would take the file past its limit
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.size > 0 && f.size+int64(len(p)) > f.maxBytes {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the backups and starts a new file. f.mu must be held.
+func (f *rotatingFile) rotate() error {
+	f.file.Close()
+	f.file = nil
+
+	if f.maxBackups == 0 {
+		os.Remove(f.path)
+	} else {
+		os.Remove(backupPath(f.path, f.maxBackups))
+		for i := f.maxBackups - 1; i >= 1; i-- {
+			os.Rename(backupPath(f.path, i), backupPath(f.path, i+1))
+		}
+		if err := os.Rename(f.path, backupPath(f.path, 1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return f.open()
+}
+
+// Close closes the current file
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// backupPath returns the name of the n-th rotated file
+func backupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
@@ -306,6 +306,7 @@ func DownloadMod(ctx context.Context, cfMod CurseForgeMod, progressCallback func
 		}
 	}); err != nil {
 		os.Remove(destPath)
+		logger.Warn("Mod download failed", "mod", cfMod.Name, "error", err)
 		return fmt.Errorf("failed to download mod: %w", err)
 	}
 
@@ -425,6 +426,7 @@ func DownloadModFile(ctx context.Context, modID int, fileID int, progressCallbac
 		}
 	}); err != nil {
 		os.Remove(destPath)
+		logger.Warn("Mod download failed", "mod", cfMod.Name, "error", err)
 		return fmt.Errorf("failed to download mod: %w", err)
 	}
 
@@ -479,6 +481,20 @@ func DownloadModFile(ctx context.Context, modID int, fileID int, progressCallbac
 
 // DownloadModToInstance downloads and installs a mod to a specific instance
 func DownloadModToInstance(ctx context.Context, cfMod CurseForgeMod, branch string, version int, progressCallback func(progress float64, message string)) error {
+	return downloadModTo(ctx, cfMod, GetInstanceModsDir(branch, version), progressCallback)
+}
+
+// DownloadModByID downloads and installs a mod to the instance with the given ID
+func DownloadModByID(ctx context.Context, cfMod CurseForgeMod, id string, progressCallback func(progress float64, message string)) error {
+	modsDir, err := GetModsDirByID(id)
+	if err != nil {
+		return err
+	}
+	return downloadModTo(ctx, cfMod, modsDir, progressCallback)
+}
+
+// downloadModTo downloads the latest file of a mod into a mods directory
+func downloadModTo(ctx context.Context, cfMod CurseForgeMod, modsDir string, progressCallback func(progress float64, message string)) error {
 	if len(cfMod.LatestFiles) == 0 {
 		return fmt.Errorf("no files available for mod %s", cfMod.Name)
 	}
@@ -495,7 +511,6 @@ func DownloadModToInstance(ctx context.Context, cfMod CurseForgeMod, branch stri
 		return fmt.Errorf("download not available for this mod (author disabled distribution)")
 	}
 
-	modsDir := GetInstanceModsDir(branch, version)
 	if err := os.MkdirAll(modsDir, 0755); err != nil {
 		return err
 	}
@@ -514,6 +529,7 @@ func DownloadModToInstance(ctx context.Context, cfMod CurseForgeMod, branch stri
 		}
 	}); err != nil {
 		os.Remove(destPath)
+		logger.Warn("Mod download failed", "mod", cfMod.Name, "error", err)
 		return fmt.Errorf("failed to download mod: %w", err)
 	}
 
@@ -555,9 +571,10 @@ func DownloadModToInstance(ctx context.Context, cfMod CurseForgeMod, branch stri
 		Category:     category,
 	}
 
-	if err := AddInstanceMod(mod, branch, version); err != nil {
+	if err := addModIn(modsDir, mod); err != nil {
 		return err
 	}
+	logger.Info("Installed mod", "mod", cfMod.Name, "version", latestFile.DisplayName, "dir", modsDir)
 
 	if progressCallback != nil {
 		progressCallback(100, fmt.Sprintf("Installed %s successfully!", cfMod.Name))
@@ -568,6 +585,20 @@ func DownloadModToInstance(ctx context.Context, cfMod CurseForgeMod, branch stri
 
 // DownloadModFileToInstance downloads and installs a specific mod file version to an instance
 func DownloadModFileToInstance(ctx context.Context, modID int, fileID int, branch string, version int, progressCallback func(progress float64, message string)) error {
+	return downloadModFileTo(ctx, modID, fileID, GetInstanceModsDir(branch, version), progressCallback)
+}
+
+// DownloadModFileByID downloads and installs a specific mod file version to the instance with the given ID
+func DownloadModFileByID(ctx context.Context, modID int, fileID int, id string, progressCallback func(progress float64, message string)) error {
+	modsDir, err := GetModsDirByID(id)
+	if err != nil {
+		return err
+	}
+	return downloadModFileTo(ctx, modID, fileID, modsDir, progressCallback)
+}
+
+// downloadModFileTo downloads a specific mod file into a mods directory, replacing any other version
+func downloadModFileTo(ctx context.Context, modID int, fileID int, modsDir string, progressCallback func(progress float64, message string)) error {
 	// Get mod details
 	cfMod, err := GetModDetails(ctx, modID)
 	if err != nil {
@@ -610,9 +641,8 @@ func DownloadModFileToInstance(ctx context.Context, modID int, fileID int, branc
 
 	// First, remove existing version of this mod if installed
 	existingModID := fmt.Sprintf("cf-%d", modID)
-	_ = RemoveInstanceMod(existingModID, branch, version)
+	_ = removeModIn(modsDir, existingModID)
 
-	modsDir := GetInstanceModsDir(branch, version)
 	if err := os.MkdirAll(modsDir, 0755); err != nil {
 		return err
 	}
@@ -631,6 +661,7 @@ func DownloadModFileToInstance(ctx context.Context, modID int, fileID int, branc
 		}
 	}); err != nil {
 		os.Remove(destPath)
+		logger.Warn("Mod download failed", "mod", cfMod.Name, "error", err)
 		return fmt.Errorf("failed to download mod: %w", err)
 	}
 
@@ -672,9 +703,10 @@ func DownloadModFileToInstance(ctx context.Context, modID int, fileID int, branc
 		Category:     category,
 	}
 
-	if err := AddInstanceMod(mod, branch, version); err != nil {
+	if err := addModIn(modsDir, mod); err != nil {
 		return err
 	}
+	logger.Info("Installed mod", "mod", cfMod.Name, "version", modFile.DisplayName, "dir", modsDir)
 
 	if progressCallback != nil {
 		progressCallback(100, fmt.Sprintf("Installed %s v%s successfully!", cfMod.Name, modFile.DisplayName))
@@ -685,7 +717,21 @@ func DownloadModFileToInstance(ctx context.Context, modID int, fileID int, branc
 
 // CheckInstanceForUpdates checks if any installed mods in an instance have updates
 func CheckInstanceForUpdates(ctx context.Context, branch string, version int) ([]Mod, error) {
-	mods, err := GetInstanceInstalledMods(branch, version)
+	return checkForUpdatesIn(ctx, GetInstanceModsDir(branch, version))
+}
+
+// CheckForUpdatesByID checks if any installed mods in the instance with the given ID have updates
+func CheckForUpdatesByID(ctx context.Context, id string) ([]Mod, error) {
+	modsDir, err := GetModsDirByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return checkForUpdatesIn(ctx, modsDir)
+}
+
+// checkForUpdatesIn checks the mods recorded in a mods directory for newer files
+func checkForUpdatesIn(ctx context.Context, modsDir string) ([]Mod, error) {
+	mods, err := installedModsIn(modsDir)
 	if err != nil {
 		return nil, err
 	}
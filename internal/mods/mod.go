@@ -7,8 +7,12 @@ import (
 	"path/filepath"
 
 	"HyPrism/internal/env"
+	"HyPrism/internal/logging"
 )
 
+// logger writes the mods subsystem's part of the launcher log
+var logger = logging.For(logging.Mods)
+
 // Mod represents a mod
 type Mod struct {
 	ID           string `json:"id"`
@@ -53,6 +57,20 @@ func GetModManifestPath() string {
 	return filepath.Join(GetModsDir(), "manifest.json")
 }
 
+// GetModsDirByID returns the mods directory of the instance with the given ID
+func GetModsDirByID(id string) (string, error) {
+	inst, err := env.GetInstance(id)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(inst.UserDataDir(), "Mods"), nil
+}
+
+// modsManifestPath returns the manifest kept in a mods directory
+func modsManifestPath(modsDir string) string {
+	return filepath.Join(modsDir, "manifest.json")
+}
+
 // GetInstanceModManifestPath returns the mod manifest path for a specific instance
 func GetInstanceModManifestPath(branch string, version int) string {
 	return filepath.Join(GetInstanceModsDir(branch, version), "manifest.json")
@@ -125,7 +143,33 @@ func GetInstalledMods() ([]Mod, error) {
 
 // GetInstanceInstalledMods returns all installed mods for a specific instance
 func GetInstanceInstalledMods(branch string, version int) ([]Mod, error) {
-	manifest, err := LoadInstanceManifest(branch, version)
+	return installedModsIn(GetInstanceModsDir(branch, version))
+}
+
+// GetInstalledModsByID returns all installed mods of the instance with the given ID
+func GetInstalledModsByID(id string) ([]Mod, error) {
+	modsDir, err := GetModsDirByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return installedModsIn(modsDir)
+}
+
+// SaveModsByID replaces the mod manifest of the instance with the given ID
+func SaveModsByID(id string, installed []Mod) error {
+	modsDir, err := GetModsDirByID(id)
+	if err != nil {
+		return err
+	}
+	if installed == nil {
+		installed = []Mod{}
+	}
+	return saveManifestToPath(&ModManifest{Mods: installed, Version: "1.0"}, modsManifestPath(modsDir))
+}
+
+// installedModsIn returns the mods recorded in the manifest of a mods directory
+func installedModsIn(modsDir string) ([]Mod, error) {
+	manifest, err := loadManifestFromPath(modsManifestPath(modsDir))
 	if err != nil {
 		return nil, err
 	}
@@ -153,7 +197,12 @@ func AddMod(mod Mod) error {
 
 // AddInstanceMod adds a mod to an instance's manifest
 func AddInstanceMod(mod Mod, branch string, version int) error {
-	manifest, err := LoadInstanceManifest(branch, version)
+	return addModIn(GetInstanceModsDir(branch, version), mod)
+}
+
+// addModIn adds a mod to the manifest of a mods directory
+func addModIn(modsDir string, mod Mod) error {
+	manifest, err := loadManifestFromPath(modsManifestPath(modsDir))
 	if err != nil {
 		return err
 	}
@@ -162,12 +211,12 @@ func AddInstanceMod(mod Mod, branch string, version int) error {
 	for i, m := range manifest.Mods {
 		if m.ID == mod.ID {
 			manifest.Mods[i] = mod
-			return SaveInstanceManifest(manifest, branch, version)
+			return saveManifestToPath(manifest, modsManifestPath(modsDir))
 		}
 	}
 
 	manifest.Mods = append(manifest.Mods, mod)
-	return SaveInstanceManifest(manifest, branch, version)
+	return saveManifestToPath(manifest, modsManifestPath(modsDir))
 }
 
 // RemoveMod removes a mod from manifest and deletes files (legacy)
@@ -204,7 +253,21 @@ func RemoveMod(modID string) error {
 
 // RemoveInstanceMod removes a mod from an instance's manifest and deletes files
 func RemoveInstanceMod(modID string, branch string, version int) error {
-	manifest, err := LoadInstanceManifest(branch, version)
+	return removeModIn(GetInstanceModsDir(branch, version), modID)
+}
+
+// RemoveModByID removes a mod from the instance with the given ID and deletes its file
+func RemoveModByID(modID string, id string) error {
+	modsDir, err := GetModsDirByID(id)
+	if err != nil {
+		return err
+	}
+	return removeModIn(modsDir, modID)
+}
+
+// removeModIn removes a mod from the manifest of a mods directory and deletes its file
+func removeModIn(modsDir string, modID string) error {
+	manifest, err := loadManifestFromPath(modsManifestPath(modsDir))
 	if err != nil {
 		return err
 	}
@@ -232,7 +295,11 @@ func RemoveInstanceMod(modID string, branch string, version int) error {
 	}
 
 	manifest.Mods = newMods
-	return SaveInstanceManifest(manifest, branch, version)
+	if err := saveManifestToPath(manifest, modsManifestPath(modsDir)); err != nil {
+		return err
+	}
+	logger.Info("Removed mod", "mod", modToRemove.Name, "dir", modsDir)
+	return nil
 }
 
 // ToggleMod enables or disables a mod (legacy)
@@ -272,7 +339,21 @@ func ToggleMod(modID string, enabled bool) error {
 
 // ToggleInstanceMod enables or disables a mod in an instance
 func ToggleInstanceMod(modID string, enabled bool, branch string, version int) error {
-	manifest, err := LoadInstanceManifest(branch, version)
+	return toggleModIn(GetInstanceModsDir(branch, version), modID, enabled)
+}
+
+// ToggleModByID enables or disables a mod in the instance with the given ID
+func ToggleModByID(modID string, enabled bool, id string) error {
+	modsDir, err := GetModsDirByID(id)
+	if err != nil {
+		return err
+	}
+	return toggleModIn(modsDir, modID, enabled)
+}
+
+// toggleModIn enables or disables a mod in a mods directory
+func toggleModIn(modsDir string, modID string, enabled bool) error {
+	manifest, err := loadManifestFromPath(modsManifestPath(modsDir))
 	if err != nil {
 		return err
 	}
@@ -298,7 +379,7 @@ func ToggleInstanceMod(modID string, enabled bool, branch string, version int) e
 				manifest.Mods[i].FilePath = newPath
 			}
 			
-			return SaveInstanceManifest(manifest, branch, version)
+			return saveManifestToPath(manifest, modsManifestPath(modsDir))
 		}
 	}
 
@@ -0,0 +1,504 @@
+// Package portable exports instances to archives that can be handed to someone else
+// and imports them again. Archives carry the instance settings and UserData and only
+// a reference to the game build; the build is installed by the normal installer on import.
+package portable
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"HyPrism/internal/env"
+	"HyPrism/internal/logging"
+	"HyPrism/internal/mods"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// logger writes instance import and export records to the launcher log
+var logger = logging.For(logging.Launcher)
+
+// manifestName is the first entry of every archive
+const manifestName = "hyprism-instance.json"
+
+// formatVersion is bumped when the archive layout changes incompatibly
+const formatVersion = 1
+
+// Archive formats
+const (
+	FormatZip    = "zip"
+	FormatTarZst = "tar.zst"
+)
+
+// ExportOptions selects what goes into an exported instance
+type ExportOptions struct {
+	Format   string `json:"format"`   // "zip" (default) or "tar.zst"
+	Settings bool   `json:"settings"` // Game settings and other UserData files
+	Worlds   bool   `json:"worlds"`   // Saved worlds
+	Mods     bool   `json:"mods"`     // Mod jars; without them mods are downloaded again on import
+}
+
+// Manifest describes an exported instance
+type Manifest struct {
+	FormatVersion int           `json:"formatVersion"`
+	ExportedAt    string        `json:"exportedAt"` // ISO 8601 format
+	Instance      env.Instance  `json:"instance"`
+	Branch        string        `json:"branch"`
+	Build         int           `json:"build"` // Game build the instance ran when exported
+	Options       ExportOptions `json:"options"`
+	Mods          []mods.Mod    `json:"mods"`
+}
+
+// userDataKind sorts a path below UserData into the export option that covers it.
+// Logs are never exported.
+func userDataKind(rel string) string {
+	first := strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]
+	switch strings.ToLower(first) {
+	case "saves":
+		return "worlds"
+	case "mods":
+		return "mods"
+	case "logs", "crashreports":
+		return ""
+	default:
+		return "settings"
+	}
+}
+
+// FormatFromPath returns the archive format implied by a file name, or "" if it has none
+func FormatFromPath(path string) string {
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return FormatZip
+	case strings.HasSuffix(lower, ".tar.zst"), strings.HasSuffix(lower, ".tzst"):
+		return FormatTarZst
+	default:
+		return ""
+	}
+}
+
+// Export writes an instance to an archive at path and returns the path written.
+// The extension of the archive is added if path does not have one.
+func Export(ctx context.Context, id string, path string, opts ExportOptions, progressCallback func(stage string, progress float64, message string, currentFile string, speed string, downloaded, total int64)) (string, error) {
+	inst, err := env.GetInstance(id)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.Format == "" {
+		opts.Format = FormatFromPath(path)
+	}
+	switch opts.Format {
+	case "", FormatZip:
+		opts.Format = FormatZip
+	case FormatTarZst:
+	default:
+		return "", fmt.Errorf("unsupported archive format: %s", opts.Format)
+	}
+	if FormatFromPath(path) != opts.Format {
+		path += "." + opts.Format
+	}
+
+	installed, err := mods.GetInstalledModsByID(id)
+	if err != nil {
+		return "", fmt.Errorf("failed to read mod manifest: %w", err)
+	}
+
+	descriptor := *inst
+	descriptor.GameFrom = ""
+	descriptor.InstalledBuild = 0
+	manifest := Manifest{
+		FormatVersion: formatVersion,
+		ExportedAt:    time.Now().Format(time.RFC3339),
+		Instance:      descriptor,
+		Branch:        inst.Branch,
+		Build:         inst.InstalledBuild,
+		Options:       opts,
+		Mods:          installed,
+	}
+
+	// Collect the UserData files the options select
+	userData := inst.UserDataDir()
+	var files []string
+	var totalSize int64
+	filepath.WalkDir(userData, func(p string, d os.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		rel, _ := filepath.Rel(userData, p)
+		include := false
+		switch userDataKind(rel) {
+		case "settings":
+			include = opts.Settings
+		case "worlds":
+			include = opts.Worlds
+		case "mods":
+			// The manifest always travels so mods can be downloaded again
+			include = opts.Mods || strings.EqualFold(filepath.Base(rel), "manifest.json")
+		}
+		if include {
+			if info, err := d.Info(); err == nil {
+				totalSize += info.Size()
+			}
+			files = append(files, p)
+		}
+		return nil
+	})
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	tmpPath := path + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create archive: %w", err)
+	}
+	w, err := newArchiveWriter(out, opts.Format)
+	if err != nil {
+		out.Close()
+		os.Remove(tmpPath)
+		return "", err
+	}
+
+	fail := func(err error) (string, error) {
+		w.Close()
+		out.Close()
+		os.Remove(tmpPath)
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fail(err)
+	}
+	if err := w.addBytes(manifestName, data); err != nil {
+		return fail(err)
+	}
+	descriptorData, _ := json.MarshalIndent(descriptor, "", "  ")
+	if err := w.addBytes(env.InstanceFileName, descriptorData); err != nil {
+		return fail(err)
+	}
+
+	var written int64
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return fail(err)
+		}
+		rel, _ := filepath.Rel(inst.Dir(), file)
+		if progressCallback != nil && totalSize > 0 {
+			progressCallback("export", float64(written)/float64(totalSize)*100, "Exporting instance...", rel, "", written, totalSize)
+		}
+		n, err := w.addFile(filepath.ToSlash(rel), file)
+		if err != nil {
+			return fail(fmt.Errorf("failed to add %s: %w", rel, err))
+		}
+		written += n
+	}
+
+	if err := w.Close(); err != nil {
+		return fail(err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+
+	if progressCallback != nil {
+		progressCallback("complete", 100, "Instance exported", "", "", totalSize, totalSize)
+	}
+	logger.Info("Exported instance", "instance", id, "path", path, "files", len(files))
+	return path, nil
+}
+
+// Import recreates an instance from an archive. The game build is not installed;
+// the caller installs it for the returned instance through the normal installer.
+// Mods whose jars were not bundled are downloaded from CurseForge again.
+func Import(ctx context.Context, path string, progressCallback func(stage string, progress float64, message string, currentFile string, speed string, downloaded, total int64)) (*env.Instance, *Manifest, error) {
+	format := FormatFromPath(path)
+	if format == "" {
+		return nil, nil, fmt.Errorf("unsupported archive format: %s", filepath.Base(path))
+	}
+
+	// Unpack next to the instances so the result can be moved into place
+	staging, err := os.MkdirTemp(env.GetInstancesDir(), ".import-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create import directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	if progressCallback != nil {
+		progressCallback("import", 0, "Unpacking instance...", filepath.Base(path), "", 0, 0)
+	}
+	if err := extractArchive(ctx, path, format, staging); err != nil {
+		return nil, nil, fmt.Errorf("failed to unpack %s: %w", filepath.Base(path), err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(staging, manifestName))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s is not a HyPrism instance archive", filepath.Base(path))
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("invalid instance archive: %w", err)
+	}
+	if manifest.FormatVersion > formatVersion {
+		return nil, nil, fmt.Errorf("instance archive was made by a newer launcher (format %d)", manifest.FormatVersion)
+	}
+
+	src := manifest.Instance
+	version := src.Version
+	if src.AutoUpdate {
+		version = 0
+	}
+	inst, err := env.CreateInstance(src.Name, manifest.Branch, version)
+	if err != nil {
+		return nil, nil, err
+	}
+	inst, err = env.UpdateInstance(inst.ID, func(i *env.Instance) {
+		i.Icon = src.Icon
+		i.Notes = src.Notes
+		i.Launch = src.Launch
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Move the unpacked UserData over the empty one of the new instance
+	if _, err := os.Stat(filepath.Join(staging, "UserData")); err == nil {
+		os.RemoveAll(inst.UserDataDir())
+		if err := os.Rename(filepath.Join(staging, "UserData"), inst.UserDataDir()); err != nil {
+			return inst, &manifest, fmt.Errorf("failed to move imported files into place: %w", err)
+		}
+	}
+	os.MkdirAll(filepath.Join(inst.UserDataDir(), "Mods"), 0755)
+
+	if err := restoreMods(ctx, inst, manifest.Mods, progressCallback); err != nil {
+		return inst, &manifest, err
+	}
+
+	logger.Info("Imported instance", "instance", inst.ID, "name", inst.Name, "path", path)
+	return inst, &manifest, nil
+}
+
+// restoreMods rewrites the mod manifest for the new instance and downloads mods
+// whose files were not in the archive
+func restoreMods(ctx context.Context, inst *env.Instance, exported []mods.Mod, progressCallback func(stage string, progress float64, message string, currentFile string, speed string, downloaded, total int64)) error {
+	modsDir := filepath.Join(inst.UserDataDir(), "Mods")
+	var present, missing []mods.Mod
+	for _, mod := range exported {
+		if mod.FilePath != "" {
+			mod.FilePath = filepath.Join(modsDir, filepath.Base(mod.FilePath))
+		}
+		if _, err := os.Stat(mod.FilePath); err == nil && mod.FilePath != "" {
+			present = append(present, mod)
+		} else {
+			missing = append(missing, mod)
+		}
+	}
+	if err := mods.SaveModsByID(inst.ID, present); err != nil {
+		return fmt.Errorf("failed to write mod manifest: %w", err)
+	}
+
+	for i, mod := range missing {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if mod.CurseForgeID == 0 || mod.FileID == 0 {
+			logger.Warn("Skipping mod: not bundled and not from CurseForge", "mod", mod.Name)
+			continue
+		}
+		if progressCallback != nil {
+			progressCallback("mods", float64(i)/float64(len(missing))*100, fmt.Sprintf("Downloading mod %s (%d/%d)...", mod.Name, i+1, len(missing)), "", "", 0, 0)
+		}
+		err := mods.DownloadModFileByID(ctx, mod.CurseForgeID, mod.FileID, inst.ID, nil)
+		if err != nil {
+			logger.Warn("Failed to download mod", "mod", mod.Name, "error", err)
+			continue
+		}
+		if !mod.Enabled {
+			mods.ToggleModByID(mod.ID, false, inst.ID)
+		}
+	}
+	return nil
+}
+
+// archiveWriter writes entries to a zip or tar.zst archive
+type archiveWriter struct {
+	zw  *zip.Writer
+	tw  *tar.Writer
+	enc *zstd.Encoder
+}
+
+func newArchiveWriter(out io.Writer, format string) (*archiveWriter, error) {
+	if format == FormatZip {
+		return &archiveWriter{zw: zip.NewWriter(out)}, nil
+	}
+	enc, err := zstd.NewWriter(out)
+	if err != nil {
+		return nil, err
+	}
+	return &archiveWriter{tw: tar.NewWriter(enc), enc: enc}, nil
+}
+
+func (w *archiveWriter) addBytes(name string, data []byte) error {
+	if w.zw != nil {
+		f, err := w.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+		return err
+	}
+	if err := w.tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: time.Now()}); err != nil {
+		return err
+	}
+	_, err := w.tw.Write(data)
+	return err
+}
+
+func (w *archiveWriter) addFile(name string, path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	var dst io.Writer
+	if w.zw != nil {
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return 0, err
+		}
+		header.Name = name
+		header.Method = zip.Deflate
+		if dst, err = w.zw.CreateHeader(header); err != nil {
+			return 0, err
+		}
+	} else {
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return 0, err
+		}
+		header.Name = name
+		if err := w.tw.WriteHeader(header); err != nil {
+			return 0, err
+		}
+		dst = w.tw
+	}
+	return io.Copy(dst, f)
+}
+
+// Close finishes the archive; it is safe to call more than once
+func (w *archiveWriter) Close() error {
+	if w.zw != nil {
+		err := w.zw.Close()
+		w.zw = nil
+		return err
+	}
+	if w.tw == nil {
+		return nil
+	}
+	err := w.tw.Close()
+	if cerr := w.enc.Close(); err == nil {
+		err = cerr
+	}
+	w.tw = nil
+	return err
+}
+
+// extractArchive unpacks a zip or tar.zst archive into dest
+func extractArchive(ctx context.Context, path string, format string, dest string) error {
+	if format == FormatZip {
+		r, err := zip.OpenReader(path)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		for _, f := range r.File {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+			err = extractEntry(dest, f.Name, f.Mode(), rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	dec, err := zstd.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer dec.Close()
+
+	tr := tar.NewReader(dec)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := extractEntry(dest, header.Name, os.FileMode(header.Mode), tr); err != nil {
+			return err
+		}
+	}
+}
+
+// extractEntry writes one archive entry below dest, refusing paths that escape it
+func extractEntry(dest string, name string, mode os.FileMode, r io.Reader) error {
+	target := filepath.Join(dest, filepath.FromSlash(name))
+	rel, err := filepath.Rel(dest, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(name) {
+		return fmt.Errorf("archive entry %q points outside the instance", name)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm()|0600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, r)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
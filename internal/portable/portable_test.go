@@ -0,0 +1,42 @@
+package portable
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractEntry(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   string
+		wantErr bool
+	}{
+		{name: "nested file", entry: "UserData/Saves/world.dat"},
+		{name: "dot segments that stay inside", entry: "UserData/../UserData/options.json"},
+		{name: "parent directory", entry: "../escaped", wantErr: true},
+		{name: "parent below a directory", entry: "UserData/../../escaped", wantErr: true},
+		{name: "absolute path", entry: "/tmp/escaped", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			dest := filepath.Join(root, "instance")
+			err := extractEntry(dest, tt.entry, 0644, strings.NewReader("data"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("extractEntry(%q) error = %v, want error %v", tt.entry, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if _, err := os.Stat(filepath.Join(root, "escaped")); err == nil {
+					t.Error("the entry was written outside the instance")
+				}
+				return
+			}
+			data, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(tt.entry)))
+			if err != nil || string(data) != "data" {
+				t.Errorf("entry not extracted: %q, %v", data, err)
+			}
+		})
+	}
+}
@@ -7,12 +7,17 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 
 	"HyPrism/internal/env"
+	"HyPrism/internal/logging"
 	"HyPrism/internal/util"
 	"HyPrism/internal/util/download"
 )
 
+// logger writes to the pwr subsystem's part of the launcher log
+var logger = logging.For(logging.Pwr)
+
 const (
 	butlerVersion = "15.21.0"
 	brothURL      = "https://broth.itch.zone/butler/%s-%s/LATEST/archive/default"
@@ -24,7 +29,7 @@ func InstallButler(ctx context.Context, progressCallback func(stage string, prog
 	butlerPath, err := GetButlerPath()
 	if err == nil {
 		if _, statErr := os.Stat(butlerPath); statErr == nil {
-			fmt.Println("Butler already installed")
+			logger.Debug("Butler already installed", "path", butlerPath)
 			if progressCallback != nil {
 				progressCallback("butler", 100, "Butler ready", "", "", 0, 0)
 			}
@@ -50,10 +55,10 @@ func InstallButler(ctx context.Context, progressCallback func(stage string, prog
 	}
 
 	url := fmt.Sprintf(brothURL, osName, arch)
-	fmt.Printf("Butler download URL: %s\n", url)
+	logger.Info("Downloading butler", "url", url)
 	archivePath := filepath.Join(env.GetCacheDir(), "butler.zip")
 
-	if err := download.DownloadWithProgress(archivePath, url, "butler", 0.8, progressCallback); err != nil {
+	if err := download.DownloadWithProgress(ctx, archivePath, url, "butler", 0.8, progressCallback); err != nil {
 		return "", fmt.Errorf("failed to download butler: %w", err)
 	}
 
@@ -85,7 +90,7 @@ func InstallButler(ctx context.Context, progressCallback func(stage string, prog
 		return "", fmt.Errorf("butler verification failed: %w\nOutput: %s", err, string(output))
 	}
 
-	fmt.Printf("Butler installed: %s\n", string(output))
+	logger.Info("Butler installed", "version", strings.TrimSpace(string(output)))
 
 	if progressCallback != nil {
 		progressCallback("butler", 100, "Butler installed", "", "", 0, 0)
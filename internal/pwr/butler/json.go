@@ -0,0 +1,174 @@
+package butler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"HyPrism/internal/util"
+)
+
+// Event types printed by butler in --json mode
+const (
+	EventLog      = "log"
+	EventProgress = "progress"
+	EventError    = "error"
+	EventResult   = "result"
+)
+
+// Event is a single machine-readable message printed by butler --json
+type Event struct {
+	Type       string          `json:"type"`
+	Level      string          `json:"level,omitempty"`
+	Message    string          `json:"message,omitempty"`
+	Progress   float64         `json:"progress,omitempty"`   // 0..1
+	Percentage float64         `json:"percentage,omitempty"` // 0..100
+	ETA        float64         `json:"eta,omitempty"`        // Seconds left
+	BPS        float64         `json:"bps,omitempty"`        // Bytes per second
+	Stack      string          `json:"stack,omitempty"`
+	Value      json.RawMessage `json:"value,omitempty"`
+}
+
+// Fraction returns the progress of a progress event as a value between 0 and 1
+func (e Event) Fraction() float64 {
+	fraction := e.Progress
+	if fraction == 0 && e.Percentage > 0 {
+		fraction = e.Percentage / 100
+	}
+	if fraction < 0 {
+		return 0
+	}
+	if fraction > 1 {
+		return 1
+	}
+	return fraction
+}
+
+// Error is a failure reported by butler
+type Error struct {
+	Command  string   `json:"command"`
+	Message  string   `json:"message"`
+	Stack    string   `json:"stack,omitempty"`
+	ExitCode int      `json:"exitCode"`
+	Log      []string `json:"log,omitempty"` // Last log lines before the failure
+}
+
+func (e *Error) Error() string {
+	if e.ExitCode != 0 {
+		return fmt.Sprintf("butler %s failed (exit code %d): %s", e.Command, e.ExitCode, e.Message)
+	}
+	return fmt.Sprintf("butler %s failed: %s", e.Command, e.Message)
+}
+
+// maxLoggedLines is how many log lines are kept for error reports
+const maxLoggedLines = 20
+
+// Run runs butler with --json and reports every event to onEvent as it is printed.
+// Failures are returned as *Error carrying butler's own message and stack when available.
+func Run(ctx context.Context, args []string, onEvent func(Event)) error {
+	butlerPath, err := GetButlerPath()
+	if err != nil {
+		return fmt.Errorf("butler not found: %w", err)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("no butler command given")
+	}
+
+	command := args[0]
+	cmd := exec.CommandContext(ctx, butlerPath, append([]string{"--json"}, args...)...)
+	util.HideConsoleWindow(cmd)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	var stderr lockedBuffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start butler: %w", err)
+	}
+
+	var reported *Error
+	var logTail []string
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var event Event
+		if err := json.Unmarshal([]byte(line), &event); err != nil || event.Type == "" {
+			// Not every line is JSON (e.g. panics); treat it as a plain log line
+			event = Event{Type: EventLog, Level: "info", Message: line}
+		}
+
+		switch event.Type {
+		case EventLog:
+			logTail = append(logTail, event.Message)
+			if len(logTail) > maxLoggedLines {
+				logTail = logTail[len(logTail)-maxLoggedLines:]
+			}
+		case EventError:
+			reported = &Error{Command: command, Message: event.Message, Stack: event.Stack}
+		}
+
+		if onEvent != nil {
+			onEvent(event)
+		}
+	}
+
+	waitErr := cmd.Wait()
+	if waitErr == nil && reported == nil {
+		return nil
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if reported == nil {
+		reported = &Error{Command: command}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			reported.Message = msg
+		} else if len(logTail) > 0 {
+			reported.Message = logTail[len(logTail)-1]
+		} else if waitErr != nil {
+			reported.Message = waitErr.Error()
+		}
+	}
+	reported.Log = logTail
+
+	var exitErr *exec.ExitError
+	if errors.As(waitErr, &exitErr) {
+		reported.ExitCode = exitErr.ExitCode()
+	}
+	return reported
+}
+
+// lockedBuffer collects output written from the exec goroutine
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,131 @@
+//go:build !windows
+
+package butler
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"HyPrism/internal/env"
+)
+
+// fakeButler installs a script in place of butler that prints stdout and stderr and exits with code
+func fakeButler(t *testing.T, stdout, stderr string, code int) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("LOCALAPPDATA", dir)
+
+	if err := os.MkdirAll(env.GetButlerDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	outPath := filepath.Join(dir, "stdout")
+	errPath := filepath.Join(dir, "stderr")
+	os.WriteFile(outPath, []byte(stdout), 0644)
+	os.WriteFile(errPath, []byte(stderr), 0644)
+
+	script := "#!/bin/sh\ncat '" + outPath + "'\ncat '" + errPath + "' >&2\nexit " + strings.TrimSpace(string(rune('0'+code))) + "\n"
+	path, _ := GetButlerPath()
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunEvents(t *testing.T) {
+	fakeButler(t, strings.Join([]string{
+		`{"type":"log","level":"info","message":"Patching"}`,
+		``,
+		`{"type":"progress","progress":0.5,"eta":3,"bps":1024}`,
+		`{"type":"progress","percentage":75}`,
+		`panic: not json`,
+	}, "\n"), "", 0)
+
+	var got []Event
+	if err := Run(context.Background(), []string{"apply", "a.pwr", "dir"}, func(e Event) { got = append(got, e) }); err != nil {
+		t.Fatal(err)
+	}
+	want := []Event{
+		{Type: EventLog, Level: "info", Message: "Patching"},
+		{Type: EventProgress, Progress: 0.5, ETA: 3, BPS: 1024},
+		{Type: EventProgress, Percentage: 75},
+		{Type: EventLog, Level: "info", Message: "panic: not json"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("events %+v, want %+v", got, want)
+	}
+	if got[1].Fraction() != 0.5 || got[2].Fraction() != 0.75 {
+		t.Errorf("fractions %v, %v", got[1].Fraction(), got[2].Fraction())
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		stdout string
+		stderr string
+		code   int
+		want   Error
+	}{
+		{
+			name:   "error event",
+			stdout: `{"type":"log","level":"info","message":"Patching"}` + "\n" + `{"type":"error","message":"corrupt patch","stack":"at wharf"}`,
+			code:   1,
+			want:   Error{Command: "apply", Message: "corrupt patch", Stack: "at wharf", ExitCode: 1, Log: []string{"Patching"}},
+		},
+		{
+			name:   "error event with a clean exit",
+			stdout: `{"type":"error","message":"disk full"}`,
+			want:   Error{Command: "apply", Message: "disk full"},
+		},
+		{
+			name:   "stderr explains the exit",
+			stdout: `{"type":"log","level":"info","message":"Patching"}`,
+			stderr: "permission denied\n",
+			code:   2,
+			want:   Error{Command: "apply", Message: "permission denied", ExitCode: 2, Log: []string{"Patching"}},
+		},
+		{
+			name:   "last log line explains the exit",
+			stdout: `{"type":"log","level":"info","message":"one"}` + "\n" + `{"type":"log","level":"error","message":"two"}`,
+			code:   3,
+			want:   Error{Command: "apply", Message: "two", ExitCode: 3, Log: []string{"one", "two"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeButler(t, tt.stdout, tt.stderr, tt.code)
+
+			err := Run(context.Background(), []string{"apply", "a.pwr", "dir"}, nil)
+			var butlerErr *Error
+			if !errors.As(err, &butlerErr) {
+				t.Fatalf("error %v is not a butler error", err)
+			}
+			if !reflect.DeepEqual(*butlerErr, tt.want) {
+				t.Errorf("error %+v, want %+v", *butlerErr, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunKeepsLastLogLines(t *testing.T) {
+	var lines []string
+	for i := 0; i < maxLoggedLines+5; i++ {
+		lines = append(lines, `{"type":"log","level":"info","message":"line `+strconv.Itoa(i)+`"}`)
+	}
+	fakeButler(t, strings.Join(lines, "\n"), "", 1)
+
+	err := Run(context.Background(), []string{"verify"}, nil)
+	var butlerErr *Error
+	if !errors.As(err, &butlerErr) {
+		t.Fatalf("error %v is not a butler error", err)
+	}
+	if len(butlerErr.Log) != maxLoggedLines || butlerErr.Log[0] != "line 5" {
+		t.Errorf("kept %d lines starting with %q", len(butlerErr.Log), butlerErr.Log[0])
+	}
+}
@@ -0,0 +1,36 @@
+package butler
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"HyPrism/internal/util"
+)
+
+// Version runs butler version and returns what it reports, such as "v15.21.0, built on ..."
+func Version(ctx context.Context) (string, error) {
+	path, err := GetButlerPath()
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path, "version")
+	util.HideConsoleWindow(cmd)
+	out, err := cmd.CombinedOutput()
+	text := strings.TrimSpace(string(out))
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("butler version did not finish: %w", ctx.Err())
+		}
+		return "", fmt.Errorf("butler version failed: %w: %s", err, text)
+	}
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	return text, nil
+}
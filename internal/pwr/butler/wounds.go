@@ -0,0 +1,245 @@
+package butler
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// woundsMagic is wharf's pwr.WoundsMagic, written little-endian at the start of a wounds file
+const woundsMagic int32 = 0xFEF5F03
+
+// Kinds of wounds, as in wharf's pwr.WoundKind
+const (
+	WoundFile       = 0
+	WoundSymlink    = 1
+	WoundDir        = 2
+	WoundClosedFile = 3 // Marks the end of a file's wounds; not damage itself
+)
+
+// Wound is an entry that butler verify --wounds found damaged or missing
+type Wound struct {
+	Path  string
+	Kind  int
+	Start int64 // Damaged byte range of a file
+	End   int64
+}
+
+// maxWoundsMessage bounds a single message so a corrupt file cannot make us allocate gigabytes
+const maxWoundsMessage = 256 * 1024 * 1024
+
+// ReadWounds reads the file written by butler verify --wounds: the magic number followed by
+// length-prefixed protobuf messages, a header, the container of the signature and one message
+// per wound. Wounds on the same entry are merged; closed-file markers are skipped.
+func ReadWounds(path string) ([]Wound, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+
+	var magic int32
+	if err := binary.Read(r, binary.LittleEndian, &magic); err != nil {
+		return nil, fmt.Errorf("wounds file is truncated: %w", err)
+	}
+	if magic != woundsMagic {
+		return nil, fmt.Errorf("not a wounds file (magic %#x)", magic)
+	}
+
+	if _, err := readMessage(r); err != nil {
+		return nil, fmt.Errorf("failed to read wounds header: %w", err)
+	}
+	containerMsg, err := readMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wounds container: %w", err)
+	}
+	c, err := parseContainer(containerMsg)
+	if err != nil {
+		return nil, err
+	}
+
+	var wounds []Wound
+	seen := make(map[[2]int64]int)
+	for {
+		msg, err := readMessage(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read wound: %w", err)
+		}
+		w, index, err := parseWound(msg, c)
+		if err != nil {
+			return nil, err
+		}
+		if w.Kind == WoundClosedFile {
+			continue
+		}
+		key := [2]int64{int64(w.Kind), index}
+		if i, ok := seen[key]; ok {
+			wounds[i].Start = min(wounds[i].Start, w.Start)
+			wounds[i].End = max(wounds[i].End, w.End)
+			continue
+		}
+		seen[key] = len(wounds)
+		wounds = append(wounds, w)
+	}
+	return wounds, nil
+}
+
+// container holds the entry paths of a tlc.Container, by kind
+type container struct {
+	files, dirs, symlinks []string
+}
+
+// parseContainer reads the paths of a tlc.Container: files = 1, dirs = 2, symlinks = 3
+func parseContainer(msg []byte) (*container, error) {
+	c := &container{}
+	err := eachField(msg, func(field int, wireType int, varint uint64, data []byte) error {
+		if wireType != wireBytes || field < 1 || field > 3 {
+			return nil
+		}
+		path, err := entryPath(data)
+		if err != nil {
+			return err
+		}
+		switch field {
+		case 1:
+			c.files = append(c.files, path)
+		case 2:
+			c.dirs = append(c.dirs, path)
+		case 3:
+			c.symlinks = append(c.symlinks, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wounds container: %w", err)
+	}
+	return c, nil
+}
+
+// entryPath reads field 1, the path, of a tlc File, Dir or Symlink
+func entryPath(msg []byte) (string, error) {
+	var path string
+	err := eachField(msg, func(field int, wireType int, varint uint64, data []byte) error {
+		if field == 1 && wireType == wireBytes {
+			path = string(data)
+		}
+		return nil
+	})
+	return path, err
+}
+
+// parseWound reads a pwr.Wound: index = 1, start = 2, end = 3, kind = 4
+func parseWound(msg []byte, c *container) (Wound, int64, error) {
+	var w Wound
+	var index int64
+	err := eachField(msg, func(field int, wireType int, varint uint64, data []byte) error {
+		if wireType != wireVarint {
+			return nil
+		}
+		switch field {
+		case 1:
+			index = int64(varint)
+		case 2:
+			w.Start = int64(varint)
+		case 3:
+			w.End = int64(varint)
+		case 4:
+			w.Kind = int(varint)
+		}
+		return nil
+	})
+	if err != nil {
+		return w, 0, fmt.Errorf("failed to read wound: %w", err)
+	}
+
+	entries := c.files
+	switch w.Kind {
+	case WoundSymlink:
+		entries = c.symlinks
+	case WoundDir:
+		entries = c.dirs
+	}
+	if index < 0 || index >= int64(len(entries)) {
+		return w, 0, fmt.Errorf("wound refers to entry %d of %d", index, len(entries))
+	}
+	w.Path = entries[index]
+	return w, index, nil
+}
+
+// Protobuf wire types used by wharf's messages
+const (
+	wireVarint = 0
+	wire64     = 1
+	wireBytes  = 2
+	wire32     = 5
+)
+
+// readMessage reads one message written by wharf's wire package: a uvarint length and the bytes
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > maxWoundsMessage {
+		return nil, fmt.Errorf("message of %d bytes is too large", n)
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return msg, nil
+}
+
+// eachField calls fn for every field of a protobuf message
+func eachField(msg []byte, fn func(field int, wireType int, varint uint64, data []byte) error) error {
+	for len(msg) > 0 {
+		key, n := binary.Uvarint(msg)
+		if n <= 0 {
+			return errors.New("malformed field key")
+		}
+		msg = msg[n:]
+		field, wireType := int(key>>3), int(key&7)
+
+		var varint uint64
+		var data []byte
+		switch wireType {
+		case wireVarint:
+			varint, n = binary.Uvarint(msg)
+			if n <= 0 {
+				return errors.New("malformed varint")
+			}
+			msg = msg[n:]
+		case wire64:
+			if len(msg) < 8 {
+				return io.ErrUnexpectedEOF
+			}
+			msg = msg[8:]
+		case wire32:
+			if len(msg) < 4 {
+				return io.ErrUnexpectedEOF
+			}
+			msg = msg[4:]
+		case wireBytes:
+			length, n := binary.Uvarint(msg)
+			if n <= 0 || uint64(len(msg)-n) < length {
+				return errors.New("malformed length")
+			}
+			data = msg[n : n+int(length)]
+			msg = msg[n+int(length):]
+		default:
+			return fmt.Errorf("unsupported wire type %d", wireType)
+		}
+
+		if err := fn(field, wireType, varint, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package butler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// field appends a protobuf field key
+func field(buf []byte, num int, wireType int) []byte {
+	return binary.AppendUvarint(buf, uint64(num<<3|wireType))
+}
+
+func bytesField(buf []byte, num int, data []byte) []byte {
+	buf = field(buf, num, wireBytes)
+	buf = binary.AppendUvarint(buf, uint64(len(data)))
+	return append(buf, data...)
+}
+
+func varintField(buf []byte, num int, v uint64) []byte {
+	return binary.AppendUvarint(field(buf, num, wireVarint), v)
+}
+
+// entry encodes a tlc File, Dir or Symlink with a path and a mode
+func entry(path string) []byte {
+	return varintField(bytesField(nil, 1, []byte(path)), 2, 0644)
+}
+
+func wound(index, start, end uint64, kind int) []byte {
+	msg := varintField(nil, 1, index)
+	msg = varintField(msg, 2, start)
+	msg = varintField(msg, 3, end)
+	return varintField(msg, 4, uint64(kind))
+}
+
+// writeWounds writes a wounds file the way wharf's wire package does
+func writeWounds(t *testing.T, magic int32, messages ...[]byte) string {
+	t.Helper()
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, magic)
+	for _, msg := range messages {
+		buf.Write(binary.AppendUvarint(nil, uint64(len(msg))))
+		buf.Write(msg)
+	}
+	path := filepath.Join(t.TempDir(), "wounds")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadWounds(t *testing.T) {
+	header := varintField(nil, 1, 0)
+	var c []byte
+	c = bytesField(c, 1, entry("Client/HytaleClient"))
+	c = bytesField(c, 1, entry("Client/Data/assets.zip"))
+	c = bytesField(c, 2, entry("Client/Data"))
+	c = bytesField(c, 3, entry("Client/libfoo.so"))
+	// Unknown fields such as size and mode are skipped
+	c = varintField(c, 4, 1234)
+
+	tests := []struct {
+		name    string
+		magic   int32
+		wounds  [][]byte
+		want    []Wound
+		wantErr bool
+	}{
+		{name: "intact", magic: woundsMagic, want: nil},
+		{
+			name:  "file ranges merge and closed markers are skipped",
+			magic: woundsMagic,
+			wounds: [][]byte{
+				wound(1, 0, 100, WoundFile),
+				wound(1, 500, 600, WoundFile),
+				wound(1, 0, 0, WoundClosedFile),
+				wound(0, 10, 20, WoundFile),
+			},
+			want: []Wound{
+				{Path: "Client/Data/assets.zip", Kind: WoundFile, Start: 0, End: 600},
+				{Path: "Client/HytaleClient", Kind: WoundFile, Start: 10, End: 20},
+			},
+		},
+		{
+			name:   "dirs and symlinks index their own lists",
+			magic:  woundsMagic,
+			wounds: [][]byte{wound(0, 0, 0, WoundDir), wound(0, 0, 0, WoundSymlink)},
+			want: []Wound{
+				{Path: "Client/Data", Kind: WoundDir},
+				{Path: "Client/libfoo.so", Kind: WoundSymlink},
+			},
+		},
+		{name: "index out of range", magic: woundsMagic, wounds: [][]byte{wound(5, 0, 1, WoundFile)}, wantErr: true},
+		{name: "wrong magic", magic: 0xFEF5F01, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := append([][]byte{header, c}, tt.wounds...)
+			got, err := ReadWounds(writeWounds(t, tt.magic, messages...))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("wound %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReadWoundsTruncated(t *testing.T) {
+	path := writeWounds(t, woundsMagic, varintField(nil, 1, 0))
+	data, _ := os.ReadFile(path)
+	// A container length with no body behind it
+	os.WriteFile(path, append(data, 0x10), 0644)
+	if _, err := ReadWounds(path); err == nil {
+		t.Fatal("a truncated wounds file was accepted")
+	}
+}
@@ -0,0 +1,311 @@
+package pwr
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"HyPrism/internal/env"
+)
+
+// defaultCacheBudget is how many bytes of patches are kept when nothing is configured
+const defaultCacheBudget = 8 * 1024 * 1024 * 1024
+
+// CacheEntry describes one patch kept in the cache
+type CacheEntry struct {
+	Key      string    `json:"key"`    // Mirror-relative patch path, e.g. /linux/amd64/release/0/4.pwr
+	URL      string    `json:"url"`    // Where the patch was downloaded from
+	Size     int64     `json:"size"`   // Size in bytes
+	SHA256   string    `json:"sha256"` // Content hash, also the file name in the cache
+	AddedAt  time.Time `json:"addedAt"`
+	LastUsed time.Time `json:"lastUsed"` // Drives LRU eviction
+}
+
+// CacheUsage summarizes the patch cache
+type CacheUsage struct {
+	Entries   []CacheEntry `json:"entries"` // Most recently used first
+	TotalSize int64        `json:"totalSize"`
+	Budget    int64        `json:"budget"`
+	Dir       string       `json:"dir"`
+}
+
+// patchCache is a content-addressed store of downloaded patches.
+// Files live in <cache>/pwr/<sha256>.pwr and index.json maps patch paths to them.
+type patchCache struct {
+	mu      sync.Mutex
+	loaded  bool
+	budget  int64
+	entries map[string]*CacheEntry
+}
+
+var cache = &patchCache{budget: defaultCacheBudget}
+
+// SetCacheBudget sets how many bytes of patches are kept; 0 keeps only the most recent patch
+func SetCacheBudget(bytes int64) {
+	if bytes < 0 {
+		bytes = 0
+	}
+	cache.mu.Lock()
+	cache.budget = bytes
+	cache.mu.Unlock()
+
+	cache.evict("")
+}
+
+func patchCacheDir() string {
+	return filepath.Join(env.GetCacheDir(), "pwr")
+}
+
+func (c *patchCache) indexPath() string {
+	return filepath.Join(patchCacheDir(), "index.json")
+}
+
+func (c *patchCache) blobPath(sum string) string {
+	return filepath.Join(patchCacheDir(), sum+".pwr")
+}
+
+// load reads the index once. c.mu must be held.
+func (c *patchCache) load() {
+	if c.loaded {
+		return
+	}
+	c.loaded = true
+	c.entries = make(map[string]*CacheEntry)
+
+	data, err := os.ReadFile(c.indexPath())
+	if err != nil {
+		return
+	}
+	var entries []*CacheEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		logger.Warn("Patch cache index is corrupt, starting empty", "error", err)
+		return
+	}
+	for _, e := range entries {
+		c.entries[e.Key] = e
+	}
+}
+
+// save writes the index. c.mu must be held.
+func (c *patchCache) save() error {
+	if err := os.MkdirAll(patchCacheDir(), 0755); err != nil {
+		return err
+	}
+	entries := make([]*CacheEntry, 0, len(c.entries))
+	for _, e := range c.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := c.indexPath() + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, c.indexPath())
+}
+
+// lookup returns the cached file for a patch and marks it as used
+func (c *patchCache) lookup(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	path := c.blobPath(e.SHA256)
+	info, err := os.Stat(path)
+	if err != nil || info.Size() != e.Size {
+		// The file went missing or was truncated behind our back
+		delete(c.entries, key)
+		c.save()
+		return "", false
+	}
+
+	e.LastUsed = time.Now()
+	c.save()
+	return path, true
+}
+
+// add moves a verified download into the cache and returns its new path
+func (c *patchCache) add(key, url, downloadPath, sum string) (string, error) {
+	info, err := os.Stat(downloadPath)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.load()
+	if err := os.MkdirAll(patchCacheDir(), 0755); err != nil {
+		c.mu.Unlock()
+		return "", err
+	}
+
+	path := c.blobPath(sum)
+	if _, err := os.Stat(path); err == nil {
+		// Same content is already stored under another key
+		os.Remove(downloadPath)
+	} else if err := os.Rename(downloadPath, path); err != nil {
+		c.mu.Unlock()
+		return "", fmt.Errorf("failed to store patch in cache: %w", err)
+	}
+
+	now := time.Now()
+	c.entries[key] = &CacheEntry{
+		Key:      key,
+		URL:      url,
+		Size:     info.Size(),
+		SHA256:   sum,
+		AddedAt:  now,
+		LastUsed: now,
+	}
+	err = c.save()
+	c.mu.Unlock()
+	if err != nil {
+		return "", err
+	}
+
+	c.evict(key)
+	return path, nil
+}
+
+// remove drops the entry (if any) whose file is at path and deletes the file
+func (c *patchCache) remove(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+
+	for key, e := range c.entries {
+		if c.blobPath(e.SHA256) == path {
+			delete(c.entries, key)
+		}
+	}
+	os.Remove(path)
+	c.save()
+}
+
+// evict removes least recently used patches until the cache fits its budget.
+// keep is never evicted, so the patch that is about to be applied stays available.
+func (c *patchCache) evict(keep string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+
+	entries := c.sortedLocked()
+	total := int64(0)
+	blobs := make(map[string]int) // Keys sharing a file only count it once
+	for _, e := range entries {
+		if blobs[e.SHA256] == 0 {
+			total += e.Size
+		}
+		blobs[e.SHA256]++
+	}
+
+	changed := false
+	for i := len(entries) - 1; i >= 0 && total > c.budget; i-- {
+		e := entries[i]
+		if e.Key == keep {
+			continue
+		}
+		delete(c.entries, e.Key)
+		changed = true
+		blobs[e.SHA256]--
+		if blobs[e.SHA256] == 0 {
+			os.Remove(c.blobPath(e.SHA256))
+			total -= e.Size
+			logger.Info("Evicted patch from cache", "key", e.Key, "bytes", e.Size)
+		}
+	}
+	if changed {
+		c.save()
+	}
+}
+
+// sortedLocked returns entries most recently used first. c.mu must be held.
+func (c *patchCache) sortedLocked() []*CacheEntry {
+	entries := make([]*CacheEntry, 0, len(c.entries))
+	for _, e := range c.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[j].LastUsed.Before(entries[i].LastUsed)
+	})
+	return entries
+}
+
+// GetCacheUsage returns the patches kept in the cache and how much space they use
+func GetCacheUsage() CacheUsage {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.load()
+
+	usage := CacheUsage{Entries: []CacheEntry{}, Budget: cache.budget, Dir: patchCacheDir()}
+	counted := make(map[string]bool)
+	for _, e := range cache.sortedLocked() {
+		usage.Entries = append(usage.Entries, *e)
+		if !counted[e.SHA256] {
+			counted[e.SHA256] = true
+			usage.TotalSize += e.Size
+		}
+	}
+	return usage
+}
+
+// ClearCache deletes every cached patch, plus leftover partial downloads and signatures
+func ClearCache() error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if err := os.RemoveAll(patchCacheDir()); err != nil {
+		return fmt.Errorf("failed to clear patch cache: %w", err)
+	}
+	cache.entries = make(map[string]*CacheEntry)
+	cache.loaded = true
+
+	entries, _ := os.ReadDir(env.GetCacheDir())
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasSuffix(name, ".pwr") || strings.HasSuffix(name, ".sig") ||
+			strings.HasSuffix(name, ".partial") || strings.HasSuffix(name, ".segments.json") {
+			os.Remove(filepath.Join(env.GetCacheDir(), name))
+		}
+	}
+
+	logger.Info("Patch cache cleared")
+	return nil
+}
+
+// VerifyCache re-hashes every cached patch and drops the ones that no longer match
+// Returns the number of patches removed
+func VerifyCache() int {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.load()
+
+	removed := 0
+	for key, e := range cache.entries {
+		path := cache.blobPath(e.SHA256)
+		sum, err := hashFile(path)
+		if err == nil && fmt.Sprintf("%x", sum) == e.SHA256 {
+			continue
+		}
+		logger.Warn("Cached patch is damaged, removing it", "key", key)
+		delete(cache.entries, key)
+		os.Remove(path)
+		removed++
+	}
+	if removed > 0 {
+		cache.save()
+	}
+	return removed
+}
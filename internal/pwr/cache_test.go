@@ -0,0 +1,103 @@
+package pwr
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	tests := []struct {
+		name   string
+		budget int64
+		ops    []string // "add <key>" stores a 10 byte patch, "use <key>" looks it up
+		want   []string // Keys left in the cache, most recently used first
+	}{
+		{
+			name:   "oldest is evicted first",
+			budget: 20,
+			ops:    []string{"add a", "add b", "add c"},
+			want:   []string{"c", "b"},
+		},
+		{
+			name:   "a lookup keeps a patch",
+			budget: 20,
+			ops:    []string{"add a", "add b", "use a", "add c"},
+			want:   []string{"c", "a"},
+		},
+		{
+			name:   "the patch just added survives a zero budget",
+			budget: 0,
+			ops:    []string{"add a", "add b"},
+			want:   []string{"b"},
+		},
+		{
+			name:   "everything fits",
+			budget: 100,
+			ops:    []string{"add a", "add b", "use a", "add c", "use b"},
+			want:   []string{"b", "c", "a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempAppDir(t)
+			c := &patchCache{budget: tt.budget}
+
+			for _, op := range tt.ops {
+				var verb, key string
+				fmt.Sscan(op, &verb, &key)
+				switch verb {
+				case "add":
+					download := filepath.Join(t.TempDir(), key+".pwr")
+					if err := os.WriteFile(download, []byte("patch-"+key+"..."), 0644); err != nil {
+						t.Fatal(err)
+					}
+					if _, err := c.add(key, "https://example.invalid/"+key, download, "sum-"+key); err != nil {
+						t.Fatal(err)
+					}
+				case "use":
+					if _, ok := c.lookup(key); !ok {
+						t.Fatalf("%s is not cached", key)
+					}
+				}
+			}
+
+			var got []string
+			c.mu.Lock()
+			for _, e := range c.sortedLocked() {
+				got = append(got, e.Key)
+			}
+			c.mu.Unlock()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cache holds %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// RFC 3339 drops trailing zeros from fractions, so these sort the wrong way as text
+func TestCacheIndexLoadsTimestamps(t *testing.T) {
+	useTempAppDir(t)
+	if err := os.MkdirAll(patchCacheDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	index := `[
+  {"key": "old", "size": 1, "sha256": "x", "addedAt": "2025-01-02T03:04:05Z", "lastUsed": "2025-01-02T03:04:05Z"},
+  {"key": "new", "size": 1, "sha256": "y", "addedAt": "2025-01-02T03:04:05Z", "lastUsed": "2025-01-02T03:04:05.5Z"}
+]`
+	if err := os.WriteFile(filepath.Join(patchCacheDir(), "index.json"), []byte(index), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &patchCache{budget: defaultCacheBudget}
+	c.mu.Lock()
+	c.load()
+	entries := c.sortedLocked()
+	c.mu.Unlock()
+	if len(entries) != 2 || entries[0].Key != "new" || entries[1].Key != "old" {
+		t.Fatalf("entries not ordered by last use: %+v", entries)
+	}
+}
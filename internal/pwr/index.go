@@ -0,0 +1,431 @@
+package pwr
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"HyPrism/internal/env"
+)
+
+// versionIndexTTL is how long a branch's index is trusted before the patch server is asked again
+const versionIndexTTL = 30 * time.Minute
+
+// BuildInfo describes a single game build on the patch server
+type BuildInfo struct {
+	Version   int   `json:"version"`
+	Size      int64 `json:"size"`                // Size of the full build (/0/{version}.pwr)
+	HasPatch  bool  `json:"hasPatch"`            // Whether an incremental patch from the previous build exists
+	PatchSize int64 `json:"patchSize,omitempty"` // Size of that incremental patch
+}
+
+// BranchIndex is the indexed view of one branch (release or pre-release)
+type BranchIndex struct {
+	Latest    int         `json:"latest"`
+	Builds    []BuildInfo `json:"builds"`
+	CheckedAt time.Time   `json:"checkedAt"`
+}
+
+// Build returns the info for a specific build if it is indexed
+func (b *BranchIndex) Build(version int) (BuildInfo, bool) {
+	for _, info := range b.Builds {
+		if info.Version == version {
+			return info, true
+		}
+	}
+	return BuildInfo{}, false
+}
+
+// versionIndexFile is the on-disk format of the version index
+type versionIndexFile struct {
+	Branches map[string]*BranchIndex `json:"branches"`
+}
+
+// VersionIndex discovers game builds on the patch server and keeps a persisted index of them
+// so version queries are cheap and keep working while offline
+type VersionIndex struct {
+	mu       sync.Mutex
+	path     string
+	ttl      time.Duration
+	backoff  time.Duration // Wait after the first failed refresh; doubles with every further failure
+	loaded   bool
+	branches map[string]*BranchIndex
+	// lastChecked keeps the URLs probed by the most recent refresh of each branch
+	lastChecked map[string][]string
+	// refreshing holds a channel per branch that is closed when its refresh finishes
+	refreshing map[string]chan struct{}
+	failures   map[string]*refreshFailure
+	// scan asks the patch server about a branch; replaced in tests
+	scan func(apiVersionType string, hint int, known map[int]BuildInfo) (*BranchIndex, []string, error)
+}
+
+// refreshFailure records failed refreshes of a branch, so the server is not asked again
+// on every query while it is down
+type refreshFailure struct {
+	err     error
+	count   int
+	retryAt time.Time
+}
+
+// Bounds of the wait between refreshes of a branch after they failed
+const (
+	refreshBackoff    = time.Minute
+	maxRefreshBackoff = versionIndexTTL
+)
+
+var (
+	defaultIndex     *VersionIndex
+	defaultIndexOnce sync.Once
+)
+
+// GetVersionIndex returns the shared version index stored under the app directory
+func GetVersionIndex() *VersionIndex {
+	defaultIndexOnce.Do(func() {
+		defaultIndex = NewVersionIndex(filepath.Join(env.GetDefaultAppDir(), "versions.json"))
+	})
+	return defaultIndex
+}
+
+// NewVersionIndex creates a version index persisted at path
+func NewVersionIndex(path string) *VersionIndex {
+	return &VersionIndex{
+		path:        path,
+		ttl:         versionIndexTTL,
+		backoff:     refreshBackoff,
+		branches:    make(map[string]*BranchIndex),
+		lastChecked: make(map[string][]string),
+		refreshing:  make(map[string]chan struct{}),
+		failures:    make(map[string]*refreshFailure),
+		scan:        scanBranch,
+	}
+}
+
+// Latest returns the newest build of a branch, refreshing the index if it is stale.
+// If the patch server cannot be reached, the last known value is returned.
+func (idx *VersionIndex) Latest(versionType string) (int, error) {
+	branch, err := idx.Branch(versionType)
+	if branch == nil {
+		return 0, err
+	}
+	return branch.Latest, err
+}
+
+// Branch returns a copy of a branch's index, refreshing it if it is stale.
+// When a refresh fails but cached data exists, the cached data is returned along with the error.
+// After a failed refresh the server is not asked again until a backoff has passed; until then
+// the last error is returned with the cached data.
+func (idx *VersionIndex) Branch(versionType string) (*BranchIndex, error) {
+	apiVersionType := normalizeVersionType(versionType)
+
+	idx.mu.Lock()
+	idx.load()
+	cached := idx.branches[apiVersionType]
+	if cached != nil && time.Since(cached.CheckedAt) < idx.ttl {
+		idx.mu.Unlock()
+		return copyBranch(cached), nil
+	}
+	if failure := idx.failures[apiVersionType]; failure != nil && time.Now().Before(failure.retryAt) {
+		idx.mu.Unlock()
+		return idx.stale(cached, failure.err)
+	}
+	idx.mu.Unlock()
+
+	err := idx.refresh(apiVersionType)
+
+	idx.mu.Lock()
+	branch := idx.branches[apiVersionType]
+	idx.mu.Unlock()
+	if err != nil {
+		return idx.stale(branch, err)
+	}
+	return copyBranch(branch), nil
+}
+
+// stale returns cached data, if there is any, along with the error that kept it from being refreshed
+func (idx *VersionIndex) stale(cached *BranchIndex, err error) (*BranchIndex, error) {
+	if cached == nil {
+		return nil, err
+	}
+	return copyBranch(cached), err
+}
+
+// Refresh forces a new scan of the patch server for a branch
+func (idx *VersionIndex) Refresh(versionType string) error {
+	idx.mu.Lock()
+	idx.load()
+	idx.mu.Unlock()
+	return idx.refresh(normalizeVersionType(versionType))
+}
+
+// CheckedURLs returns the URLs probed by the most recent refresh of a branch
+func (idx *VersionIndex) CheckedURLs(versionType string) []string {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	return append([]string(nil), idx.lastChecked[normalizeVersionType(versionType)]...)
+}
+
+// refresh scans the patch server and updates the index. The scan runs without idx.mu held;
+// callers arriving while a branch is being refreshed wait for that refresh instead of starting another.
+func (idx *VersionIndex) refresh(apiVersionType string) error {
+	idx.mu.Lock()
+	if done, ok := idx.refreshing[apiVersionType]; ok {
+		idx.mu.Unlock()
+		<-done
+		idx.mu.Lock()
+		defer idx.mu.Unlock()
+		if failure := idx.failures[apiVersionType]; failure != nil {
+			return failure.err
+		}
+		return nil
+	}
+
+	done := make(chan struct{})
+	idx.refreshing[apiVersionType] = done
+	previous := idx.branches[apiVersionType]
+	idx.mu.Unlock()
+
+	hint := 1
+	known := make(map[int]BuildInfo)
+	if previous != nil {
+		if previous.Latest > 0 {
+			hint = previous.Latest
+		}
+		for _, info := range previous.Builds {
+			known[info.Version] = info
+		}
+	}
+	branch, checked, err := idx.scan(apiVersionType, hint, known)
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	delete(idx.refreshing, apiVersionType)
+	defer close(done)
+	idx.lastChecked[apiVersionType] = checked
+
+	if err != nil {
+		failure := idx.failures[apiVersionType]
+		if failure == nil {
+			failure = &refreshFailure{}
+			idx.failures[apiVersionType] = failure
+		}
+		failure.err = err
+		failure.count++
+		wait := idx.backoff
+		for i := 1; i < failure.count && wait < maxRefreshBackoff; i++ {
+			wait *= 2
+		}
+		if wait > maxRefreshBackoff {
+			wait = maxRefreshBackoff
+		}
+		failure.retryAt = time.Now().Add(wait)
+		logger.Warn("Version check failed", "branch", apiVersionType, "failures", failure.count, "retryIn", wait, "error", err)
+		return err
+	}
+
+	delete(idx.failures, apiVersionType)
+	idx.branches[apiVersionType] = branch
+	logger.Info("Latest version found", "branch", apiVersionType, "version", branch.Latest)
+
+	if err := idx.save(); err != nil {
+		logger.Warn("Failed to save version index", "error", err)
+	}
+	return nil
+}
+
+// scanBranch asks the patch server for the newest build of a branch and describes every build
+func scanBranch(apiVersionType string, hint int, known map[int]BuildInfo) (*BranchIndex, []string, error) {
+	scan := newBuildScanner(apiVersionType)
+	latest, err := scan.findLatest(hint)
+	if err != nil {
+		return nil, scan.checkedURLs(), err
+	}
+	builds := scan.describeBuilds(latest, known)
+	return &BranchIndex{Latest: latest, Builds: builds, CheckedAt: time.Now()}, scan.checkedURLs(), nil
+}
+
+// load reads the persisted index once. idx.mu must be held.
+func (idx *VersionIndex) load() {
+	if idx.loaded {
+		return
+	}
+	idx.loaded = true
+
+	data, err := os.ReadFile(idx.path)
+	if err != nil {
+		return
+	}
+
+	var file versionIndexFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		logger.Warn("Ignoring unreadable version index", "error", err)
+		return
+	}
+	for branch, index := range file.Branches {
+		if index != nil {
+			idx.branches[branch] = index
+		}
+	}
+}
+
+// save writes the index to disk. idx.mu must be held.
+func (idx *VersionIndex) save() error {
+	data, err := json.MarshalIndent(versionIndexFile{Branches: idx.branches}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0755); err != nil {
+		return err
+	}
+
+	tmpPath := idx.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, idx.path)
+}
+
+func copyBranch(b *BranchIndex) *BranchIndex {
+	c := *b
+	c.Builds = append([]BuildInfo(nil), b.Builds...)
+	return &c
+}
+
+// buildScanner probes the patch server for builds of one branch
+type buildScanner struct {
+	apiVersionType string
+	mu             sync.Mutex
+	checked        []string
+}
+
+func newBuildScanner(apiVersionType string) *buildScanner {
+	return &buildScanner{apiVersionType: apiVersionType}
+}
+
+func (s *buildScanner) checkedURLs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.checked...)
+}
+
+// probe sends a HEAD request for a patch and returns whether it exists and its size.
+// An error means the server could not be asked, not that the patch is missing.
+func (s *buildScanner) probe(fromVer, toVer int) (bool, int64, error) {
+	resp, url, err := headPatch(patchPath(s.apiVersionType, fromVer, toVer))
+	if err != nil {
+		return false, 0, err
+	}
+	resp.Body.Close()
+
+	s.mu.Lock()
+	s.checked = append(s.checked, url)
+	s.mu.Unlock()
+
+	switch {
+	case resp.StatusCode == 200:
+		return true, resp.ContentLength, nil
+	case resp.StatusCode >= 500:
+		return false, 0, fmt.Errorf("patch server returned HTTP %d for %s", resp.StatusCode, url)
+	default:
+		return false, 0, nil
+	}
+}
+
+// findLatest finds the newest full build, assuming builds are numbered 1..N without gaps.
+// It gallops upward from hint until a build is missing, then binary searches the gap,
+// so it keeps working however far the game moves past the hint.
+func (s *buildScanner) findLatest(hint int) (int, error) {
+	if hint < 1 {
+		hint = 1
+	}
+
+	exists, _, err := s.probe(0, hint)
+	if err != nil {
+		return 0, err
+	}
+
+	// lo is known to exist (or 0 for none), hi is known to be missing
+	var lo, hi int
+	if exists {
+		lo = hint
+		step := 1
+		for {
+			candidate := lo + step
+			found, _, err := s.probe(0, candidate)
+			if err != nil {
+				return 0, err
+			}
+			if !found {
+				hi = candidate
+				break
+			}
+			lo = candidate
+			step *= 2
+		}
+	} else {
+		lo, hi = 0, hint
+	}
+
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		found, _, err := s.probe(0, mid)
+		if err != nil {
+			return 0, err
+		}
+		if found {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	return lo, nil
+}
+
+// describeBuilds collects size and patch availability for builds 1..latest,
+// reusing entries from known and only asking the server about new builds
+func (s *buildScanner) describeBuilds(latest int, known map[int]BuildInfo) []BuildInfo {
+	builds := make([]BuildInfo, 0, latest)
+	var missing []int
+	for v := 1; v <= latest; v++ {
+		if info, ok := known[v]; ok && info.Size > 0 {
+			builds = append(builds, info)
+		} else {
+			missing = append(missing, v)
+		}
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sem := make(chan struct{}, 4)
+	for _, v := range missing {
+		wg.Add(1)
+		go func(ver int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			info := BuildInfo{Version: ver}
+			if _, size, err := s.probe(0, ver); err == nil {
+				info.Size = size
+			}
+			if ver > 1 {
+				if ok, size, err := s.probe(ver-1, ver); err == nil && ok {
+					info.HasPatch = true
+					info.PatchSize = size
+				}
+			}
+
+			mu.Lock()
+			builds = append(builds, info)
+			mu.Unlock()
+		}(v)
+	}
+	wg.Wait()
+
+	sort.Slice(builds, func(i, j int) bool { return builds[i].Version < builds[j].Version })
+	return builds
+}
@@ -0,0 +1,151 @@
+package pwr
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeScan serves a branch without the network and counts how often it is asked
+type fakeScan struct {
+	calls  atomic.Int32
+	latest atomic.Int32
+	err    atomic.Value // error
+	gate   chan struct{}
+}
+
+func (f *fakeScan) scan(apiVersionType string, hint int, known map[int]BuildInfo) (*BranchIndex, []string, error) {
+	f.calls.Add(1)
+	if f.gate != nil {
+		<-f.gate
+	}
+	if err, _ := f.err.Load().(error); err != nil {
+		return nil, nil, err
+	}
+	latest := int(f.latest.Load())
+	builds := make([]BuildInfo, 0, latest)
+	for v := 1; v <= latest; v++ {
+		builds = append(builds, BuildInfo{Version: v, Size: 1, HasPatch: v > 1})
+	}
+	return &BranchIndex{Latest: latest, Builds: builds, CheckedAt: time.Now()}, nil, nil
+}
+
+func newTestIndex(t *testing.T, f *fakeScan) *VersionIndex {
+	idx := NewVersionIndex(filepath.Join(t.TempDir(), "versions.json"))
+	idx.scan = f.scan
+	return idx
+}
+
+var errOffline = errors.New("offline")
+
+func TestVersionIndexTTL(t *testing.T) {
+	tests := []struct {
+		name      string
+		ttl       time.Duration
+		backoff   time.Duration
+		failAfter bool // The server fails from the second query on
+		wantCalls int32
+		wantErr   bool
+		wantBuild int
+	}{
+		{name: "fresh data is served from the index", ttl: time.Hour, backoff: time.Hour, wantCalls: 1, wantBuild: 3},
+		{name: "stale data is refreshed", ttl: 0, backoff: time.Hour, wantCalls: 3, wantBuild: 3},
+		{name: "failed refresh serves stale data during the backoff", ttl: 0, backoff: time.Hour, failAfter: true, wantCalls: 2, wantErr: true, wantBuild: 3},
+		{name: "failed refresh is retried after the backoff", ttl: 0, backoff: 0, failAfter: true, wantCalls: 3, wantErr: true, wantBuild: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeScan{}
+			f.latest.Store(3)
+			idx := newTestIndex(t, f)
+			idx.ttl = tt.ttl
+			idx.backoff = tt.backoff
+
+			if latest, err := idx.Latest("release"); err != nil || latest != 3 {
+				t.Fatalf("first query: %d, %v", latest, err)
+			}
+			if tt.failAfter {
+				f.err.Store(errOffline)
+			}
+
+			var latest int
+			var err error
+			for i := 0; i < 2; i++ {
+				latest, err = idx.Latest("release")
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if latest != tt.wantBuild {
+				t.Fatalf("latest = %d, want %d", latest, tt.wantBuild)
+			}
+			if got := f.calls.Load(); got != tt.wantCalls {
+				t.Fatalf("server asked %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestVersionIndexPersists(t *testing.T) {
+	f := &fakeScan{}
+	f.latest.Store(5)
+	idx := newTestIndex(t, f)
+	if _, err := idx.Latest("release"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new index on the same file answers from disk while the server is down
+	offline := &fakeScan{}
+	offline.err.Store(errOffline)
+	reopened := NewVersionIndex(idx.path)
+	reopened.scan = offline.scan
+	branch, err := reopened.Branch("release")
+	if err != nil || branch.Latest != 5 || len(branch.Builds) != 5 {
+		t.Fatalf("reopened index: %+v, %v", branch, err)
+	}
+	if offline.calls.Load() != 0 {
+		t.Fatal("a fresh persisted index asked the server")
+	}
+}
+
+func TestVersionIndexRefreshOutsideLock(t *testing.T) {
+	f := &fakeScan{gate: make(chan struct{})}
+	f.latest.Store(2)
+	idx := newTestIndex(t, f)
+
+	// Other branches and queries that need no refresh are not held up by a slow scan
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if latest, err := idx.Latest("release"); err != nil || latest != 2 {
+				t.Errorf("latest = %d, %v", latest, err)
+			}
+		}()
+	}
+	for f.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	checked := make(chan struct{})
+	go func() {
+		idx.CheckedURLs("release")
+		close(checked)
+	}()
+	select {
+	case <-checked:
+	case <-time.After(time.Second):
+		t.Fatal("the index lock is held during the scan")
+	}
+
+	close(f.gate)
+	wg.Wait()
+	// Concurrent queries share one scan
+	if got := f.calls.Load(); got != 1 {
+		t.Fatalf("server asked %d times, want 1", got)
+	}
+}
@@ -0,0 +1,367 @@
+package pwr
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"HyPrism/internal/config"
+	"HyPrism/internal/env"
+)
+
+const (
+	// maxMirrorFailures is how many failures in a row demote a mirror
+	maxMirrorFailures = 3
+	// failureDemotion is how long a mirror that keeps failing is tried last
+	failureDemotion = 10 * time.Minute
+	// badContentDemotion is how long a mirror that served a broken file is tried last
+	badContentDemotion = 6 * time.Hour
+)
+
+// patchMagic is wharf's pwr.PatchMagic, the int32 every patch file starts with
+const patchMagic int32 = 0xFEF5F00
+
+// pwrMagic is patchMagic as it appears on disk; wharf writes its headers little-endian
+var pwrMagic = binary.LittleEndian.AppendUint32(nil, uint32(patchMagic))
+
+// MirrorStatus is the health of one patch mirror as shown to the user
+type MirrorStatus struct {
+	URL          string `json:"url"`
+	Healthy      bool   `json:"healthy"`
+	Failures     int    `json:"failures"`               // Failures in a row
+	Successes    int    `json:"successes"`              // Successful requests since startup
+	LastError    string `json:"lastError,omitempty"`    // Most recent failure
+	DemotedUntil string `json:"demotedUntil,omitempty"` // ISO 8601 format
+}
+
+type mirror struct {
+	baseURL      string
+	failures     int
+	successes    int
+	lastError    string
+	demotedUntil time.Time
+}
+
+func (m *mirror) demoted(now time.Time) bool {
+	return now.Before(m.demotedUntil)
+}
+
+var (
+	mirrorsMu sync.Mutex
+	mirrors   = []*mirror{{baseURL: config.DefaultPatchMirror}}
+
+	// patchSources remembers which mirror served each cached patch and its hash,
+	// so a patch that fails to apply can be blamed on the right mirror
+	patchSources = make(map[string]patchSource)
+)
+
+type patchSource struct {
+	mirror string
+	path   string // Mirror-relative path of the patch
+	sha256 string
+}
+
+// SetMirrors sets the ordered list of patch base URLs; an empty list means the official server.
+// Health of mirrors that stay in the list is kept.
+func SetMirrors(urls []string) {
+	mirrorsMu.Lock()
+	defer mirrorsMu.Unlock()
+
+	existing := make(map[string]*mirror)
+	for _, m := range mirrors {
+		existing[m.baseURL] = m
+	}
+
+	var updated []*mirror
+	seen := make(map[string]bool)
+	for _, url := range urls {
+		url = normalizeMirrorURL(url)
+		if url == "" || seen[url] {
+			continue
+		}
+		seen[url] = true
+		if m, ok := existing[url]; ok {
+			updated = append(updated, m)
+		} else {
+			updated = append(updated, &mirror{baseURL: url})
+		}
+	}
+	if len(updated) == 0 {
+		updated = []*mirror{{baseURL: config.DefaultPatchMirror}}
+	}
+	mirrors = updated
+}
+
+// GetMirrorStatus returns the configured mirrors in configured order with their health
+func GetMirrorStatus() []MirrorStatus {
+	mirrorsMu.Lock()
+	defer mirrorsMu.Unlock()
+
+	now := time.Now()
+	status := make([]MirrorStatus, 0, len(mirrors))
+	for _, m := range mirrors {
+		s := MirrorStatus{
+			URL:       m.baseURL,
+			Healthy:   !m.demoted(now),
+			Failures:  m.failures,
+			Successes: m.successes,
+			LastError: m.lastError,
+		}
+		if m.demoted(now) {
+			s.DemotedUntil = m.demotedUntil.Format(time.RFC3339)
+		}
+		status = append(status, s)
+	}
+	return status
+}
+
+// PrimaryMirror returns the mirror that is currently tried first
+func PrimaryMirror() string {
+	return orderedMirrors()[0]
+}
+
+// normalizeMirrorURL trims a mirror URL so it can be joined with patch paths
+func normalizeMirrorURL(url string) string {
+	return strings.TrimRight(strings.TrimSpace(url), "/")
+}
+
+// orderedMirrors returns mirror base URLs to try: healthy mirrors in configured order,
+// then demoted ones, soonest to recover first
+func orderedMirrors() []string {
+	mirrorsMu.Lock()
+	defer mirrorsMu.Unlock()
+
+	now := time.Now()
+	var healthy, demoted []*mirror
+	for _, m := range mirrors {
+		if m.demoted(now) {
+			demoted = append(demoted, m)
+		} else {
+			healthy = append(healthy, m)
+		}
+	}
+	for i := 1; i < len(demoted); i++ {
+		for j := i; j > 0 && demoted[j].demotedUntil.Before(demoted[j-1].demotedUntil); j-- {
+			demoted[j], demoted[j-1] = demoted[j-1], demoted[j]
+		}
+	}
+
+	urls := make([]string, 0, len(mirrors))
+	for _, m := range append(healthy, demoted...) {
+		urls = append(urls, m.baseURL)
+	}
+	return urls
+}
+
+func findMirror(baseURL string) *mirror {
+	for _, m := range mirrors {
+		if m.baseURL == baseURL {
+			return m
+		}
+	}
+	return nil
+}
+
+// markMirrorSuccess records a good answer from a mirror
+func markMirrorSuccess(baseURL string) {
+	mirrorsMu.Lock()
+	defer mirrorsMu.Unlock()
+
+	if m := findMirror(baseURL); m != nil {
+		m.failures = 0
+		m.successes++
+	}
+}
+
+// markMirrorFailure records a mirror that could not be reached or returned a server error.
+// After a few failures in a row it is tried after the others for a while.
+func markMirrorFailure(baseURL string, err error) {
+	mirrorsMu.Lock()
+	defer mirrorsMu.Unlock()
+
+	m := findMirror(baseURL)
+	if m == nil {
+		return
+	}
+	m.failures++
+	m.lastError = err.Error()
+	if m.failures >= maxMirrorFailures && !m.demoted(time.Now()) {
+		m.demotedUntil = time.Now().Add(failureDemotion)
+		logger.Warn("Patch mirror demoted", "mirror", baseURL, "failures", m.failures, "error", err)
+	}
+}
+
+// demoteMirror pushes a mirror that served a broken file to the back of the list
+func demoteMirror(baseURL string, reason string) {
+	mirrorsMu.Lock()
+	defer mirrorsMu.Unlock()
+
+	m := findMirror(baseURL)
+	if m == nil {
+		return
+	}
+	m.failures++
+	m.lastError = reason
+	m.demotedUntil = time.Now().Add(badContentDemotion)
+	logger.Warn("Patch mirror demoted", "mirror", baseURL, "reason", reason)
+}
+
+// patchPath returns the mirror-relative path of a patch between two builds
+// A fromVer of 0 addresses the full game build
+func patchPath(apiVersionType string, fromVer, toVer int) string {
+	return fmt.Sprintf("/%s/%s/%s/%d/%d.pwr", getOS(), getArch(), apiVersionType, fromVer, toVer)
+}
+
+// patchURL builds the URL of a patch on the mirror currently tried first
+func patchURL(apiVersionType string, fromVer, toVer int) string {
+	return PrimaryMirror() + patchPath(apiVersionType, fromVer, toVer)
+}
+
+// headPatch sends a HEAD request for a patch, failing over between mirrors.
+// The first mirror that gives a definite answer wins; a 404 is a definite answer.
+// It returns the response and the full URL that answered.
+func headPatch(path string) (*http.Response, string, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	var lastErr error
+	for _, base := range orderedMirrors() {
+		url := base + path
+		resp, err := client.Head(url)
+		if err != nil {
+			markMirrorFailure(base, err)
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode >= 500 {
+			resp.Body.Close()
+			err = fmt.Errorf("HTTP %d from %s", resp.StatusCode, url)
+			markMirrorFailure(base, err)
+			lastErr = err
+			continue
+		}
+		markMirrorSuccess(base)
+		return resp, url, nil
+	}
+	return nil, "", fmt.Errorf("no patch mirror reachable: %w", lastErr)
+}
+
+// checkDownloadedPatch checks a freshly downloaded patch against what we know about it.
+// It returns the file's SHA-256 or an error describing why the mirror's copy is bad.
+func checkDownloadedPatch(pwrPath string, path string, expectedSize int64) (string, error) {
+	f, err := os.Open(pwrPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if expectedSize > 0 && info.Size() != expectedSize {
+		return "", fmt.Errorf("served %d bytes for %s, expected %d", info.Size(), path, expectedSize)
+	}
+
+	header := make([]byte, len(pwrMagic))
+	if _, err := io.ReadFull(f, header); err != nil || string(header) != string(pwrMagic) {
+		return "", fmt.Errorf("served a file for %s that is not a patch", path)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+
+	if known := knownPatchChecksum(path); known != "" && known != sum {
+		return "", fmt.Errorf("served %s with checksum %s, expected %s", path, sum[:12], known[:12])
+	}
+	return sum, nil
+}
+
+// checksumsPath is where checksums of patches that applied cleanly are kept
+func checksumsPath() string {
+	return filepath.Join(env.GetCacheDir(), "patch-checksums.json")
+}
+
+func loadPatchChecksums() map[string]string {
+	checksums := make(map[string]string)
+	data, err := os.ReadFile(checksumsPath())
+	if err == nil {
+		json.Unmarshal(data, &checksums)
+	}
+	return checksums
+}
+
+func knownPatchChecksum(path string) string {
+	mirrorsMu.Lock()
+	defer mirrorsMu.Unlock()
+	return loadPatchChecksums()[path]
+}
+
+// rememberPatchSource records which mirror served a cached patch
+func rememberPatchSource(pwrPath, baseURL, path, sum string) {
+	mirrorsMu.Lock()
+	defer mirrorsMu.Unlock()
+	patchSources[pwrPath] = patchSource{mirror: baseURL, path: path, sha256: sum}
+}
+
+// confirmPatch stores the checksum of a patch that applied cleanly,
+// so copies served later by any mirror can be checked against it
+func confirmPatch(pwrPath string) {
+	mirrorsMu.Lock()
+	defer mirrorsMu.Unlock()
+
+	source, ok := patchSources[pwrPath]
+	if !ok || source.sha256 == "" {
+		return
+	}
+	delete(patchSources, pwrPath)
+
+	checksums := loadPatchChecksums()
+	if checksums[source.path] == source.sha256 {
+		return
+	}
+	checksums[source.path] = source.sha256
+	if data, err := json.MarshalIndent(checksums, "", "  "); err == nil {
+		os.WriteFile(checksumsPath(), data, 0644)
+	}
+}
+
+// rejectPatch blames the mirror that served a patch butler found to be corrupt
+// and drops the cached copy so the next attempt downloads it again
+func rejectPatch(pwrPath string, reason string) {
+	mirrorsMu.Lock()
+	source, ok := patchSources[pwrPath]
+	delete(patchSources, pwrPath)
+	mirrorsMu.Unlock()
+
+	cache.remove(pwrPath)
+	if ok {
+		demoteMirror(source.mirror, fmt.Sprintf("served a corrupt %s: %s", source.path, reason))
+	}
+}
+
+// looksCorrupt reports whether a butler failure points at a damaged patch file
+// rather than at the local disk or game tree
+func looksCorrupt(message string) bool {
+	message = strings.ToLower(message)
+	for _, hint := range []string{"corrupt", "unexpected eof", "magic", "checksum", "hash mismatch", "invalid patch", "decompress", "zstd", "brotli"} {
+		if strings.Contains(message, hint) {
+			return true
+		}
+	}
+	return false
+}
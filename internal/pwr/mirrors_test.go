@@ -0,0 +1,77 @@
+package pwr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useTempAppDir points the app directory at a fresh temporary directory
+func useTempAppDir(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("LOCALAPPDATA", dir)
+}
+
+// wharfPatchHeader is the start of a patch as wharf writes it: PatchMagic as a
+// little-endian int32, followed by the compressed header
+func wharfPatchHeader() []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, int32(0xFEF5F00))
+	buf.Write([]byte{0x0a, 0x04, 0x08, 0x02, 0x10, 0x01})
+	return buf.Bytes()
+}
+
+func TestPwrMagicOnDisk(t *testing.T) {
+	want := []byte{0x00, 0x5F, 0xEF, 0x0F}
+	if !bytes.Equal(pwrMagic, want) {
+		t.Fatalf("pwrMagic = % x, want % x", pwrMagic, want)
+	}
+	if !bytes.HasPrefix(wharfPatchHeader(), pwrMagic) {
+		t.Fatalf("a patch written by wharf does not start with pwrMagic")
+	}
+}
+
+func TestCheckDownloadedPatch(t *testing.T) {
+	useTempAppDir(t)
+	patch := wharfPatchHeader()
+
+	tests := []struct {
+		name         string
+		data         []byte
+		expectedSize int64
+		wantErr      string
+	}{
+		{name: "patch", data: patch},
+		{name: "patch of the expected size", data: patch, expectedSize: int64(len(patch))},
+		{name: "wrong size", data: patch, expectedSize: int64(len(patch)) + 1, wantErr: "expected"},
+		{name: "html error page", data: []byte("<html><body>404</body></html>"), wantErr: "not a patch"},
+		{name: "signature instead of patch", data: binary.LittleEndian.AppendUint32(nil, 0xFEF5F01), wantErr: "not a patch"},
+		{name: "truncated", data: patch[:2], wantErr: "not a patch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "0.pwr")
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			sum, err := checkDownloadedPatch(path, "linux/amd64/release/0/1.pwr", tt.expectedSize)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(sum) != 64 {
+					t.Fatalf("checksum %q is not a SHA-256", sum)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,18 +1,23 @@
 package pwr
 
 import (
-	"HyPrism/internal/util"
+	"HyPrism/internal/logging"
 	"HyPrism/internal/pwr/butler"
+	"HyPrism/internal/store"
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
 )
 
+// logger writes the pwr subsystem's part of the launcher log
+var logger = logging.For(logging.Pwr)
+
 // cleanStagingDirectory removes staging directory and any leftover temp files
 // This fixes "Access Denied" errors on Windows where previous installations left locked files
 func cleanStagingDirectory(gameDir string) error {
@@ -51,16 +56,22 @@ func ApplyPWR(ctx context.Context, pwrFile string, progressCallback func(stage s
 	return fmt.Errorf("ApplyPWR is deprecated - use ApplyPWRToDir with instance path")
 }
 
-// ApplyPWRToDir applies a PWR patch file to a specific directory
+// ApplyPWRToDir applies a PWR patch file to a specific directory in place
 func ApplyPWRToDir(ctx context.Context, pwrFile string, targetDir string, progressCallback func(stage string, progress float64, message string, currentFile string, speed string, downloaded, total int64)) error {
+	return applyPWR(ctx, pwrFile, targetDir, targetDir, progressCallback)
+}
+
+// ApplyPWRToNewDir applies a PWR patch to oldDir and writes the patched tree into newDir,
+// leaving oldDir untouched
+func ApplyPWRToNewDir(ctx context.Context, pwrFile string, oldDir string, newDir string, progressCallback func(stage string, progress float64, message string, currentFile string, speed string, downloaded, total int64)) error {
+	return applyPWR(ctx, pwrFile, oldDir, newDir, progressCallback)
+}
+
+// applyPWR runs butler apply against oldDir; when newDir differs from oldDir the result
+// is written there instead of patching oldDir in place
+func applyPWR(ctx context.Context, pwrFile string, oldDir string, targetDir string, progressCallback func(stage string, progress float64, message string, currentFile string, speed string, downloaded, total int64)) error {
 	stagingDir := filepath.Join(targetDir, "staging-temp")
 	
-	// Get Butler path
-	butlerPath, err := butler.GetButlerPath()
-	if err != nil {
-		return fmt.Errorf("butler not found: %w", err)
-	}
-	
 	// Clean staging directory
 	if progressCallback != nil {
 		progressCallback("install", 0, "Preparing installation...", "", "", 0, 0)
@@ -75,37 +86,80 @@ func ApplyPWRToDir(ctx context.Context, pwrFile string, targetDir string, progre
 		return fmt.Errorf("failed to create staging directory: %w", err)
 	}
 
+	// Patching in place must not write through links into the shared game file store
+	if oldDir == targetDir {
+		if err := store.Detach(targetDir); err != nil {
+			return err
+		}
+	}
+
 	if progressCallback != nil {
 		progressCallback("install", 5, "Installing game...", "", "", 0, 0)
 	}
 
-	fmt.Printf("Applying PWR patch with Butler: %s -> %s\n", pwrFile, targetDir)
+	logger.Info("Applying patch with butler", "patch", pwrFile, "dir", targetDir)
 	
-	var cmd *exec.Cmd
+	args := []string{"apply", "--staging-dir", stagingDir}
 	if runtime.GOOS == "windows" {
-		cmd = exec.CommandContext(ctx, butlerPath, "apply", "--staging-dir", stagingDir, "--save-interval=60", pwrFile, targetDir)
-	} else {
-		cmd = exec.CommandContext(ctx, butlerPath, "apply", "--staging-dir", stagingDir, pwrFile, targetDir)
+		args = append(args, "--save-interval=60")
 	}
-	util.HideConsoleWindow(cmd)
-	
-	output, err := cmd.CombinedOutput()
+	if oldDir != targetDir {
+		args = append(args, "--dir", targetDir)
+	}
+	args = append(args, pwrFile, oldDir)
+
+	// Stream butler's --json output into real progress updates
+	lastUpdate := time.Time{}
+	err := butler.Run(ctx, args, func(event butler.Event) {
+		switch event.Type {
+		case butler.EventLog:
+			if event.Level == "debug" {
+				logger.Debug("butler: " + event.Message)
+			} else {
+				logger.Info("butler: " + event.Message)
+			}
+		case butler.EventProgress:
+			if progressCallback == nil || time.Since(lastUpdate) < 100*time.Millisecond {
+				return
+			}
+			lastUpdate = time.Now()
+
+			// Keep the first 5% for preparation so the bar never moves backwards
+			fraction := event.Fraction()
+			message := "Installing game..."
+			if event.ETA > 0 {
+				message = fmt.Sprintf("Installing game... %s left", formatETA(event.ETA))
+			}
+			speed := ""
+			if event.BPS > 0 {
+				speed = formatSpeed(event.BPS)
+			}
+			// Butler's progress does not name the file being patched, and its log lines are not file names
+			progressCallback("install", 5+fraction*94, message, "", speed, 0, 0)
+		}
+	})
 	if err != nil {
-		fmt.Printf("Butler error output: %s\n", string(output))
+		logger.Error("Butler apply failed", "error", err)
+		var butlerErr *butler.Error
+		if errors.As(err, &butlerErr) {
+			for _, line := range butlerErr.Log {
+				logger.Error("butler: " + line)
+			}
+			if looksCorrupt(butlerErr.Message) {
+				rejectPatch(pwrFile, butlerErr.Message)
+			}
+		}
 		cleanStagingDirectory(targetDir)
-		return fmt.Errorf("butler apply failed: %w\nOutput: %s", err, string(output))
+		return fmt.Errorf("butler apply failed: %w", err)
 	}
 
-	fmt.Printf("Butler output: %s\n", string(output))
-
 	// Clean up staging directory
 	cleanStagingDirectory(targetDir)
 
-	// Clean up patch file
-	go func() {
-		time.Sleep(2 * time.Second)
-		os.Remove(pwrFile)
-	}()
+	// The patch is known good now; later copies from any mirror are checked against it
+	confirmPatch(pwrFile)
+
+	// The patch stays in the cache so other instances can reuse it
 
 	if progressCallback != nil {
 		progressCallback("install", 100, "Installation complete", "", "", 0, 0)
@@ -123,6 +177,18 @@ func ApplyPWRToDir(ctx context.Context, pwrFile string, targetDir string, progre
 		os.Chmod(clientPath, 0755)
 	}
 
-	fmt.Println("Installation to directory complete")
+	logger.Info("Installation to directory complete", "dir", targetDir)
 	return nil
 }
+
+// formatETA formats a number of seconds as a short duration like "1m05s"
+func formatETA(seconds float64) string {
+	d := time.Duration(math.Round(seconds)) * time.Second
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+	if d < time.Hour {
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -0,0 +1,207 @@
+package pwr
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"HyPrism/internal/env"
+	"HyPrism/internal/pwr/butler"
+)
+
+// signatureMagic is wharf's pwr.SignatureMagic, the int32 every signature file starts with
+const signatureMagic int32 = 0xFEF5F01
+
+// ErrNoSignature is returned when no usable signature was recorded for a build
+var ErrNoSignature = errors.New("no signature recorded for this build")
+
+// buildRecord is kept next to a build's signature. It lets the signature be checked before
+// it is trusted, and says which content every file of the build had, so damaged files can
+// be restored from the game file store.
+type buildRecord struct {
+	Signature string               `json:"signature"` // SHA-256 of the .sig file
+	Files     map[string]buildFile `json:"files"`     // Slash-separated path relative to the game directory
+}
+
+// buildFile is one entry of a build as it was installed
+type buildFile struct {
+	SHA256 string      `json:"sha256,omitempty"`
+	Mode   fs.FileMode `json:"mode"`
+	Link   string      `json:"link,omitempty"` // Target of a symlink
+}
+
+// signaturesDir holds the signatures recorded when builds are installed
+func signaturesDir() string {
+	return filepath.Join(env.GetDefaultAppDir(), "signatures")
+}
+
+// signaturePaths returns where a build's signature and record are kept
+func signaturePaths(versionType string, build int) (sigPath string, recordPath string) {
+	base := filepath.Join(signaturesDir(), fmt.Sprintf("%s-%d", normalizeVersionType(versionType), build))
+	return base + ".sig", base + ".json"
+}
+
+// RecordBuild signs a freshly installed game tree with butler sign and records the content
+// of its files, so the build can later be verified and healed without downloading it again.
+// dir must hold exactly the build, as it does right after the patches applied.
+func RecordBuild(ctx context.Context, versionType string, build int, dir string) error {
+	sigPath, recordPath := signaturePaths(versionType, build)
+	if err := os.MkdirAll(filepath.Dir(sigPath), 0755); err != nil {
+		return err
+	}
+
+	tmpSig := sigPath + ".tmp"
+	os.Remove(tmpSig)
+	defer os.Remove(tmpSig)
+	if err := butler.Run(ctx, []string{"sign", dir, tmpSig}, nil); err != nil {
+		return fmt.Errorf("failed to sign build %d: %w", build, err)
+	}
+	if err := checkSignatureHeader(tmpSig); err != nil {
+		return err
+	}
+	sigSum, err := fileSHA256(tmpSig)
+	if err != nil {
+		return err
+	}
+
+	files, err := describeTree(ctx, dir)
+	if err != nil {
+		return fmt.Errorf("failed to record files of build %d: %w", build, err)
+	}
+	data, err := json.Marshal(buildRecord{Signature: sigSum, Files: files})
+	if err != nil {
+		return err
+	}
+
+	tmpRecord := recordPath + ".tmp"
+	defer os.Remove(tmpRecord)
+	if err := os.WriteFile(tmpRecord, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpSig, sigPath); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpRecord, recordPath); err != nil {
+		return err
+	}
+	logger.Info("Recorded build signature", "branch", normalizeVersionType(versionType), "build", build, "files", len(files))
+	return nil
+}
+
+// loadSignature returns a build's signature and record after checking that the signature is
+// whole and unchanged since it was recorded. A signature that fails the check is removed.
+func loadSignature(versionType string, build int) (string, *buildRecord, error) {
+	sigPath, recordPath := signaturePaths(versionType, build)
+	data, err := os.ReadFile(recordPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil, ErrNoSignature
+		}
+		return "", nil, err
+	}
+	var record buildRecord
+	if err := json.Unmarshal(data, &record); err != nil || record.Signature == "" {
+		os.Remove(sigPath)
+		os.Remove(recordPath)
+		return "", nil, ErrNoSignature
+	}
+
+	if err := checkSignatureHeader(sigPath); err != nil {
+		logger.Warn("Discarding damaged signature", "path", sigPath, "error", err)
+		os.Remove(sigPath)
+		os.Remove(recordPath)
+		return "", nil, ErrNoSignature
+	}
+	sum, err := fileSHA256(sigPath)
+	if err != nil {
+		return "", nil, err
+	}
+	if sum != record.Signature {
+		logger.Warn("Discarding signature that changed since it was recorded", "path", sigPath)
+		os.Remove(sigPath)
+		os.Remove(recordPath)
+		return "", nil, ErrNoSignature
+	}
+	return sigPath, &record, nil
+}
+
+// checkSignatureHeader checks that a file starts like a wharf signature
+func checkSignatureHeader(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var magic int32
+	if err := binary.Read(f, binary.LittleEndian, &magic); err != nil || magic != signatureMagic {
+		return fmt.Errorf("%s is not a signature", filepath.Base(path))
+	}
+	return nil
+}
+
+// describeTree records the content and mode of every file, symlink and directory below dir
+func describeTree(ctx context.Context, dir string) (map[string]buildFile, error) {
+	files := make(map[string]buildFile)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		entry := buildFile{Mode: info.Mode()}
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			if entry.Link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		case info.Mode().IsRegular():
+			if entry.SHA256, err = fileSHA256(path); err != nil {
+				return err
+			}
+		}
+		files[filepath.ToSlash(rel)] = entry
+		return nil
+	})
+	return files, err
+}
+
+// fileSHA256 returns the hex SHA-256 of a file
+func fileSHA256(path string) (string, error) {
+	sum, err := hashFile(path)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum), nil
+}
+
+// copyTo writes a file's content to w
+func copyTo(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,149 @@
+package pwr
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"HyPrism/internal/pwr/butler"
+	"HyPrism/internal/store"
+)
+
+// writeSignature records a fake signature for a build and returns its paths
+func writeSignature(t *testing.T, build int, files map[string]buildFile) (string, string) {
+	t.Helper()
+	sigPath, recordPath := signaturePaths("release", build)
+	if err := os.MkdirAll(filepath.Dir(sigPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	sig := binary.LittleEndian.AppendUint32(nil, uint32(signatureMagic))
+	sig = append(sig, "signature body"...)
+	if err := os.WriteFile(sigPath, sig, 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum, err := fileSHA256(sigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(buildRecord{Signature: sum, Files: files})
+	if err := os.WriteFile(recordPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return sigPath, recordPath
+}
+
+func TestLoadSignature(t *testing.T) {
+	tests := []struct {
+		name    string
+		damage  func(t *testing.T, sigPath, recordPath string)
+		wantErr error
+	}{
+		{name: "recorded", damage: func(t *testing.T, sigPath, recordPath string) {}},
+		{name: "missing", damage: func(t *testing.T, sigPath, recordPath string) {
+			os.Remove(recordPath)
+		}, wantErr: ErrNoSignature},
+		{name: "empty signature", damage: func(t *testing.T, sigPath, recordPath string) {
+			os.WriteFile(sigPath, nil, 0644)
+		}, wantErr: ErrNoSignature},
+		{name: "not a signature", damage: func(t *testing.T, sigPath, recordPath string) {
+			os.WriteFile(sigPath, []byte("<html>404</html>"), 0644)
+		}, wantErr: ErrNoSignature},
+		{name: "changed since recorded", damage: func(t *testing.T, sigPath, recordPath string) {
+			f, _ := os.OpenFile(sigPath, os.O_APPEND|os.O_WRONLY, 0644)
+			f.Write([]byte("x"))
+			f.Close()
+		}, wantErr: ErrNoSignature},
+		{name: "corrupt record", damage: func(t *testing.T, sigPath, recordPath string) {
+			os.WriteFile(recordPath, []byte("{"), 0644)
+		}, wantErr: ErrNoSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempAppDir(t)
+			sigPath, recordPath := writeSignature(t, 7, map[string]buildFile{})
+			tt.damage(t, sigPath, recordPath)
+
+			got, record, err := loadSignature("release", 7)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil {
+				if got != sigPath || record == nil {
+					t.Fatalf("got %q, %v", got, record)
+				}
+				return
+			}
+			// A signature that failed the check is not kept around to fail again
+			if _, err := os.Stat(recordPath); !os.IsNotExist(err) {
+				t.Fatal("the rejected record was kept")
+			}
+		})
+	}
+}
+
+func TestWriteHealArchiveNeedsIntactCopies(t *testing.T) {
+	useTempAppDir(t)
+	record := &buildRecord{Files: map[string]buildFile{
+		"Client/HytaleClient": {SHA256: strings.Repeat("ab", 32), Mode: 0755},
+	}}
+	wounds := []butler.Wound{
+		{Path: "Client/HytaleClient", Kind: butler.WoundFile},
+		{Path: "Client/unknown.dat", Kind: butler.WoundFile},
+	}
+
+	path := filepath.Join(t.TempDir(), "heal.zip")
+	err := writeHealArchive(path, wounds, record)
+	if !errors.Is(err, errNoIntactCopy) {
+		t.Fatalf("error = %v, want errNoIntactCopy so the build is downloaded instead", err)
+	}
+	if !strings.Contains(err.Error(), "Client/HytaleClient") || !strings.Contains(err.Error(), "Client/unknown.dat") {
+		t.Fatalf("error does not name the files: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("a partial archive was left behind")
+	}
+}
+
+func TestWriteHealArchiveFromStore(t *testing.T) {
+	useTempAppDir(t)
+	tree := filepath.Join(os.Getenv("HOME"), "tree")
+	os.MkdirAll(filepath.Join(tree, "Client"), 0755)
+	if err := os.WriteFile(filepath.Join(tree, "Client", "HytaleClient"), []byte("client"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files, err := describeTree(context.Background(), tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Ingest(context.Background(), tree, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "heal.zip")
+	wounds := []butler.Wound{{Path: "Client/HytaleClient", Kind: butler.WoundFile}}
+	if err := writeHealArchive(path, wounds, &buildRecord{Files: files}); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	if len(zr.File) != 1 || zr.File[0].Name != "Client/HytaleClient" || zr.File[0].Mode().Perm() != 0755 {
+		t.Fatalf("archive holds %+v", zr.File)
+	}
+	rc, _ := zr.File[0].Open()
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "client" {
+		t.Fatalf("content = %q", data)
+	}
+}
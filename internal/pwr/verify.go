@@ -0,0 +1,277 @@
+package pwr
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"HyPrism/internal/pwr/butler"
+	"HyPrism/internal/store"
+)
+
+// VerifyResult is the outcome of checking a game tree against its build signature
+type VerifyResult struct {
+	Build   int      `json:"build"`
+	Intact  bool     `json:"intact"`
+	Wounded []string `json:"wounded"` // Paths (relative to the game directory) butler reported as damaged or missing
+	Summary string   `json:"summary"`
+}
+
+// HealResult is the outcome of repairing a game tree
+type HealResult struct {
+	Build    int      `json:"build"`
+	Repaired []string `json:"repaired"` // Paths (relative to the game directory) that were replaced
+}
+
+// VerifyDir checks a game tree against the signature recorded when its build was installed
+func VerifyDir(ctx context.Context, versionType string, build int, gameDir string, progressCallback func(stage string, progress float64, message string, currentFile string, speed string, downloaded, total int64)) (*VerifyResult, error) {
+	if build <= 0 {
+		return nil, fmt.Errorf("installed build is unknown; reinstall the instance instead")
+	}
+	sigPath, _, err := loadSignature(versionType, build)
+	if err != nil {
+		return nil, signatureError(build, err)
+	}
+
+	wounds, err := findWounds(ctx, sigPath, gameDir, progressCallback)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &VerifyResult{Build: build, Intact: len(wounds) == 0, Wounded: woundedPaths(wounds)}
+	if result.Intact {
+		result.Summary = fmt.Sprintf("All files match build %d", build)
+	} else {
+		result.Summary = fmt.Sprintf("%d damaged or missing file(s)", len(result.Wounded))
+	}
+
+	if progressCallback != nil {
+		progressCallback("verify", 100, result.Summary, "", "", 0, 0)
+	}
+	return result, nil
+}
+
+// HealDir repairs the damaged and missing entries of a game tree in place with butler's heal.
+// Intact copies of the damaged files are taken from the game file store when it has all of
+// them; otherwise the full build is fetched from the patch mirrors and healed from.
+func HealDir(ctx context.Context, versionType string, build int, gameDir string, progressCallback func(stage string, progress float64, message string, currentFile string, speed string, downloaded, total int64)) (*HealResult, error) {
+	if build <= 0 {
+		return nil, fmt.Errorf("installed build is unknown; reinstall the instance instead")
+	}
+	sigPath, record, err := loadSignature(versionType, build)
+	if err != nil {
+		return nil, signatureError(build, err)
+	}
+
+	wounds, err := findWounds(ctx, sigPath, gameDir, progressCallback)
+	if err != nil {
+		return nil, err
+	}
+	result := &HealResult{Build: build, Repaired: []string{}}
+	if len(wounds) == 0 {
+		return result, nil
+	}
+
+	// Damaged files share their inode with the store when the tree was ingested; repair private
+	// copies so the fix cannot write through to the store or to instances sharing the files,
+	// and drop blobs that were damaged along with them
+	var damaged []string
+	for _, w := range wounds {
+		if w.Kind != butler.WoundFile {
+			continue
+		}
+		damaged = append(damaged, filepath.Join(gameDir, filepath.FromSlash(w.Path)))
+		if entry, ok := record.Files[w.Path]; ok {
+			store.DropDamaged(entry.SHA256, entry.Mode)
+		}
+	}
+	if err := store.DetachFiles(damaged); err != nil {
+		return nil, err
+	}
+
+	healSource := gameDir + ".heal.zip"
+	defer os.Remove(healSource)
+	if err := writeHealArchive(healSource, wounds, record); err != nil {
+		if !errors.Is(err, errNoIntactCopy) {
+			return nil, err
+		}
+		logger.Info("Downloading build to repair game files", "build", build, "reason", err)
+		if healSource, err = DownloadPWR(ctx, versionType, 0, build, progressCallback); err != nil {
+			return nil, fmt.Errorf("failed to download build %d to repair from: %w", build, err)
+		}
+	}
+
+	if progressCallback != nil {
+		progressCallback("heal", 0, "Repairing game files...", "", "", 0, 0)
+	}
+	err = butler.Run(ctx, []string{"verify", "--heal=archive," + healSource, sigPath, gameDir}, func(event butler.Event) {
+		if event.Type == butler.EventProgress && progressCallback != nil {
+			progressCallback("heal", event.Fraction()*100, "Repairing game files...", "", "", 0, 0)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to repair build %d: %w", build, err)
+	}
+
+	result.Repaired = woundedPaths(wounds)
+	if progressCallback != nil {
+		progressCallback("heal", 100, fmt.Sprintf("Repaired %d file(s)", len(result.Repaired)), "", "", 0, 0)
+	}
+	return result, nil
+}
+
+// signatureError explains how to get a signature for a build that has none
+func signatureError(build int, err error) error {
+	if errors.Is(err, ErrNoSignature) {
+		return fmt.Errorf("%w %d; update or reinstall the instance once so the launcher can record one", err, build)
+	}
+	return err
+}
+
+// findWounds runs butler verify and returns the entries it found damaged or missing
+func findWounds(ctx context.Context, sigPath string, gameDir string, progressCallback func(stage string, progress float64, message string, currentFile string, speed string, downloaded, total int64)) ([]butler.Wound, error) {
+	if progressCallback != nil {
+		progressCallback("verify", 0, "Verifying game files...", "", "", 0, 0)
+	}
+
+	woundsFile, err := os.CreateTemp("", "hyprism-wounds-*")
+	if err != nil {
+		return nil, err
+	}
+	woundsPath := woundsFile.Name()
+	woundsFile.Close()
+	defer os.Remove(woundsPath)
+
+	runErr := butler.Run(ctx, []string{"verify", "--wounds=" + woundsPath, sigPath, gameDir}, func(event butler.Event) {
+		if event.Type == butler.EventProgress && progressCallback != nil {
+			progressCallback("verify", event.Fraction()*100, "Verifying game files...", "", "", 0, 0)
+		}
+	})
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	wounds, err := butler.ReadWounds(woundsPath)
+	if err != nil {
+		if runErr != nil {
+			return nil, runErr
+		}
+		return nil, fmt.Errorf("failed to read verify results: %w", err)
+	}
+	// butler exits non-zero when it finds wounds; any other failure leaves none behind
+	if runErr != nil && len(wounds) == 0 {
+		return nil, runErr
+	}
+	return wounds, nil
+}
+
+// woundedPaths returns the sorted paths of wounded entries
+func woundedPaths(wounds []butler.Wound) []string {
+	paths := make([]string, 0, len(wounds))
+	for _, w := range wounds {
+		paths = append(paths, w.Path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// maxListedUnrepairable is how many files without an intact copy an error names
+const maxListedUnrepairable = 10
+
+// errNoIntactCopy is returned by writeHealArchive when the store lacks a damaged file
+var errNoIntactCopy = errors.New("no intact local copy")
+
+// writeHealArchive writes a zip holding the intact content of every wounded entry, for
+// butler's archive healer. It fails with errNoIntactCopy, without writing anything, if an
+// entry has no intact copy.
+func writeHealArchive(path string, wounds []butler.Wound, record *buildRecord) error {
+	type source struct {
+		wound butler.Wound
+		entry buildFile
+		blob  string
+	}
+	var sources []source
+	var missing []string
+	for _, w := range wounds {
+		entry, ok := record.Files[w.Path]
+		if !ok {
+			missing = append(missing, w.Path)
+			continue
+		}
+		s := source{wound: w, entry: entry}
+		if w.Kind == butler.WoundFile {
+			blob, ok := store.Lookup(entry.SHA256, entry.Mode)
+			if !ok {
+				missing = append(missing, w.Path)
+				continue
+			}
+			s.blob = blob
+		}
+		sources = append(sources, s)
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		listed := missing
+		if len(listed) > maxListedUnrepairable {
+			listed = listed[:maxListedUnrepairable]
+		}
+		return fmt.Errorf("%d damaged file(s) (%s): %w", len(missing), strings.Join(listed, ", "), errNoIntactCopy)
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(out)
+	err = func() error {
+		for _, s := range sources {
+			header := &zip.FileHeader{Name: s.wound.Path, Method: zip.Store}
+			header.SetMode(s.entry.Mode)
+			if s.wound.Kind == butler.WoundDir {
+				header.Name += "/"
+			}
+			w, err := zw.CreateHeader(header)
+			if err != nil {
+				return err
+			}
+			switch s.wound.Kind {
+			case butler.WoundSymlink:
+				_, err = w.Write([]byte(s.entry.Link))
+			case butler.WoundFile:
+				err = copyTo(w, s.blob)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to add %s to the repair archive: %w", s.wound.Path, err)
+			}
+		}
+		return zw.Close()
+	}()
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+func hashFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
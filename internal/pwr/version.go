@@ -3,7 +3,6 @@ package pwr
 import (
 	"context"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -60,76 +59,34 @@ type VersionCheckResult struct {
 	Error         error
 }
 
-// FindLatestVersion finds the latest game version
+// FindLatestVersion finds the latest game version using the version index
 func FindLatestVersion(versionType string) int {
-	result := performVersionCheck(versionType)
-	return result.LatestVersion
+	latest, _ := GetVersionIndex().Latest(versionType)
+	return latest
 }
 
 // FindLatestVersionWithDetails returns detailed version check results
 func FindLatestVersionWithDetails(versionType string) VersionCheckResult {
-	return performVersionCheck(versionType)
-}
-
-func performVersionCheck(versionType string) VersionCheckResult {
 	result := VersionCheckResult{}
-	
-	osName := getOS()
-	arch := getArch()
-	apiVersionType := normalizeVersionType(versionType)
-	
-	if osName == "unknown" {
+
+	if getOS() == "unknown" {
 		result.Error = fmt.Errorf("unsupported operating system")
 		return result
 	}
 
-	// Use known latest versions as starting points for faster checking
-	// Release is around v3, Pre-release is around v7
-	var startVersion int
-	if apiVersionType == "pre-release" {
-		startVersion = 10 // Start checking from v10 down
-	} else {
-		startVersion = 5 // Start checking from v5 down
+	index := GetVersionIndex()
+	branch, err := index.Branch(versionType)
+	result.CheckedURLs = index.CheckedURLs(versionType)
+	if branch == nil {
+		result.Error = err
+		return result
 	}
 
-	client := download.GetSharedClient()
-
-	// Check versions in parallel from startVersion down to 1
-	type versionCheck struct {
-		version int
-		exists  bool
-		url     string
-	}
-	
-	checkChan := make(chan versionCheck, startVersion)
-	
-	// Launch parallel checks
-	for v := 1; v <= startVersion; v++ {
-		go func(ver int) {
-			url := fmt.Sprintf("https://game-patches.hytale.com/patches/%s/%s/%s/0/%d.pwr",
-				osName, arch, apiVersionType, ver)
-			
-			resp, err := client.Head(url)
-			exists := err == nil && resp.StatusCode == http.StatusOK
-			if resp != nil {
-				resp.Body.Close()
-			}
-			
-			checkChan <- versionCheck{version: ver, exists: exists, url: url}
-		}(v)
-	}
-	
-	// Collect results
-	for i := 0; i < startVersion; i++ {
-		check := <-checkChan
-		result.CheckedURLs = append(result.CheckedURLs, check.url)
-		if check.exists && check.version > result.LatestVersion {
-			result.LatestVersion = check.version
-			result.SuccessURL = check.url
-		}
+	// A stale index is still a usable answer when the patch server is unreachable
+	result.LatestVersion = branch.Latest
+	if branch.Latest > 0 {
+		result.SuccessURL = patchURL(normalizeVersionType(versionType), 0, branch.Latest)
 	}
-
-	fmt.Printf("Latest %s version found: %d\n", apiVersionType, result.LatestVersion)
 	return result
 }
 
@@ -172,243 +129,310 @@ func SaveLocalVersion(version int) error {
 	return os.WriteFile(versionFile, []byte(strconv.Itoa(version)), 0644)
 }
 
+// PatchStep is a single patch in an upgrade chain
+type PatchStep struct {
+	From int    `json:"from"`
+	To   int    `json:"to"`
+	URL  string `json:"url"`
+}
+
+// IsFullBuild reports whether the step installs a complete build rather than patching an existing one
+func (s PatchStep) IsFullBuild() bool {
+	return s.From == 0
+}
+
+// PlanPatchChain plans the patches that bring an install from build fromVer up to build toVer.
+// The candidates are the chain of incremental patches the version index knows of, a direct
+// patch from fromVer to toVer, and the full build of toVer; the smallest download wins.
+// Only the direct patch is asked for on the patch server, since the index does not track
+// patches between builds further apart than one.
+// Returns an empty plan when fromVer is already at or past toVer.
+func PlanPatchChain(versionType string, fromVer, toVer int) ([]PatchStep, error) {
+	if getOS() == "unknown" {
+		return nil, fmt.Errorf("unsupported operating system")
+	}
+	if toVer <= 0 {
+		return nil, fmt.Errorf("invalid target version %d", toVer)
+	}
+	if fromVer >= toVer {
+		return []PatchStep{}, nil
+	}
+	apiVersionType := normalizeVersionType(versionType)
+	if fromVer <= 0 {
+		return planPatchChain(apiVersionType, nil, fromVer, toVer, directPatch{}), nil
+	}
+
+	// A failed refresh still returns what the index knew; that is enough to plan with
+	branch, err := GetVersionIndex().Branch(versionType)
+	if branch == nil && err != nil {
+		logger.Warn("Version index unavailable", "error", err)
+	}
+
+	// A single hop is already indexed when the index is available
+	var direct directPatch
+	if toVer-fromVer > 1 || branch == nil {
+		direct = probeDirectPatch(apiVersionType, fromVer, toVer)
+	}
+	return planPatchChain(apiVersionType, branch, fromVer, toVer, direct), nil
+}
+
+// directPatch describes the patch going straight from one build to another
+type directPatch struct {
+	exists bool
+	size   int64 // 0 when unknown
+}
+
+// probeDirectPatch asks the patch server for the patch from fromVer to toVer; replaced in tests
+var probeDirectPatch = func(apiVersionType string, fromVer, toVer int) directPatch {
+	exists, size, err := newBuildScanner(apiVersionType).probe(fromVer, toVer)
+	if err != nil {
+		logger.Warn("Could not check for a direct patch", "from", fromVer, "to", toVer, "error", err)
+	}
+	return directPatch{exists: exists, size: size}
+}
+
+// patchPlan is one way of getting from one build to another, with the bytes it downloads
+type patchPlan struct {
+	name  string
+	steps []PatchStep
+	size  int64 // 0 when unknown
+}
+
+// planPatchChain picks the smallest of the plans that branch and direct allow between fromVer
+// and toVer. Sizes are only compared when every plan's size is known; otherwise a direct
+// patch is preferred over a chain, and either over the full build.
+func planPatchChain(apiVersionType string, branch *BranchIndex, fromVer, toVer int, direct directPatch) []PatchStep {
+	full := patchPlan{name: "full build", steps: []PatchStep{{From: 0, To: toVer, URL: patchURL(apiVersionType, 0, toVer)}}}
+	if branch != nil {
+		if info, ok := branch.Build(toVer); ok {
+			full.size = info.Size
+		}
+	}
+	if fromVer <= 0 {
+		return full.steps
+	}
+
+	// Candidates in order of preference when sizes cannot decide
+	var plans []patchPlan
+	if direct.exists {
+		plans = append(plans, patchPlan{
+			name:  "direct patch",
+			steps: []PatchStep{{From: fromVer, To: toVer, URL: patchURL(apiVersionType, fromVer, toVer)}},
+			size:  direct.size,
+		})
+	}
+	if chain, ok := incrementalChain(apiVersionType, branch, fromVer, toVer); ok && (!direct.exists || len(chain.steps) > 1) {
+		plans = append(plans, chain)
+	}
+	plans = append(plans, full)
+
+	best := plans[0]
+	sized := true
+	for _, plan := range plans {
+		sized = sized && plan.size > 0
+	}
+	if sized {
+		for _, plan := range plans[1:] {
+			if plan.size < best.size {
+				best = plan
+			}
+		}
+	}
+
+	logger.Info("Planned game update", "plan", best.name, "steps", len(best.steps), "from", fromVer, "to", toVer, "bytes", best.size)
+	return best.steps
+}
+
+// incrementalChain chains the incremental patches between fromVer and toVer that branch
+// knows of. It reports false when a hop has no patch.
+func incrementalChain(apiVersionType string, branch *BranchIndex, fromVer, toVer int) (patchPlan, bool) {
+	if branch == nil {
+		return patchPlan{}, false
+	}
+	plan := patchPlan{name: "incremental patches", steps: make([]PatchStep, 0, toVer-fromVer)}
+	sized := true
+	for v := fromVer + 1; v <= toVer; v++ {
+		info, ok := branch.Build(v)
+		if !ok || !info.HasPatch {
+			logger.Info("No incremental patch chain", "from", fromVer, "to", toVer, "stuckAt", v-1)
+			return patchPlan{}, false
+		}
+		plan.steps = append(plan.steps, PatchStep{From: v - 1, To: v, URL: patchURL(apiVersionType, v-1, v)})
+		plan.size += info.PatchSize
+		sized = sized && info.PatchSize > 0
+	}
+	if !sized {
+		plan.size = 0
+	}
+	return plan, true
+}
+
 // DownloadPWR downloads a PWR patch file - matches Hytale-F2P implementation
+// Mirrors are tried in order of health; a mirror that serves a file of the wrong size,
+// something that is not a patch, or a patch whose checksum differs from a copy that
+// applied cleanly before is demoted and the next one is used.
 func DownloadPWR(ctx context.Context, versionType string, fromVer, toVer int, progressCallback func(stage string, progress float64, message string, currentFile string, speed string, downloaded, total int64)) (string, error) {
-	osName := getOS()
-	arch := getArch()
 	apiVersionType := normalizeVersionType(versionType)
 
 	// If toVer is 0, it means "latest" - fetch the latest version
 	if toVer == 0 {
-		fmt.Println("Version 0 requested, fetching latest version...")
+		logger.Debug("Version 0 requested, fetching latest version")
 		toVer = FindLatestVersion(versionType)
 		if toVer == 0 {
 			return "", fmt.Errorf("could not determine latest version for %s", versionType)
 		}
-		fmt.Printf("Latest version is: %d\n", toVer)
+		logger.Info("Latest version found", "branch", apiVersionType, "version", toVer)
 	}
 
-	// Try patch URL - for fresh install always use 0 as fromVer
-	// The Hytale patch server provides full game at /0/{version}.pwr
-	var url string
-	var useFromZero bool
-	
-	// First try the incremental patch if we have a previous version
-	if fromVer > 0 {
-		url = fmt.Sprintf("https://game-patches.hytale.com/patches/%s/%s/%s/%d/%d.pwr",
-			osName, arch, apiVersionType, fromVer, toVer)
-		
-		// Quick check if incremental patch exists
-		client := &http.Client{Timeout: 10 * time.Second}
-		resp, err := client.Head(url)
-		if err != nil || resp.StatusCode != http.StatusOK {
-			// Incremental patch not available, use full install from 0
-			fmt.Printf("Incremental patch %d->%d not available, using full install\n", fromVer, toVer)
-			useFromZero = true
-		}
-		if resp != nil {
-			resp.Body.Close()
-		}
-	} else {
-		useFromZero = true
-	}
-	
-	// Use full game patch from version 0
-	if useFromZero {
-		url = fmt.Sprintf("https://game-patches.hytale.com/patches/%s/%s/%s/0/%d.pwr",
-			osName, arch, apiVersionType, toVer)
-	}
-
-	fmt.Printf("Downloading PWR from: %s\n", url)
+	// The Hytale patch server provides the full game at /0/{version}.pwr. Incremental
+	// steps come from PlanPatchChain, which already knows they exist, so they are not probed again.
+	path := patchPath(apiVersionType, fromVer, toVer)
 
 	cacheDir := env.GetCacheDir()
 	if err := os.MkdirAll(cacheDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create cache directory: %w", err)
 	}
 	
-	pwrPath := filepath.Join(cacheDir, fmt.Sprintf("%d.pwr", toVer))
-
-	// First do a HEAD request to get expected file size
-	headReq, err := http.NewRequestWithContext(ctx, "HEAD", url, nil)
-	if err != nil {
-		return "", fmt.Errorf("failed to create HEAD request: %w", err)
+	// Patches already in the cache are reused, whichever instance downloaded them
+	if cachedPath, ok := cache.lookup(path); ok {
+		logger.Info("Patch found in cache", "path", cachedPath)
+		return cachedPath, nil
+	}
+
+	// Downloads land here and move into the cache once verified. Name them by branch
+	// and both ends so full builds and incremental patches never overwrite each other.
+	pwrPath := filepath.Join(cacheDir, fmt.Sprintf("%s-%d-%d.pwr", apiVersionType, fromVer, toVer))
+
+	// Prefer the size recorded in the version index: a mirror's own HEAD answer
+	// cannot be used to catch that mirror serving the wrong file
+	expectedSize := indexedPatchSize(versionType, fromVer, toVer)
+	if expectedSize == 0 {
+		if resp, _, err := headPatch(path); err == nil {
+			if resp.StatusCode == http.StatusOK {
+				expectedSize = resp.ContentLength
+			}
+			resp.Body.Close()
+		}
 	}
-	headReq.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-	
-	headClient := &http.Client{Timeout: 30 * time.Second}
-	headResp, err := headClient.Do(headReq)
-	var expectedSize int64
-	if err == nil && headResp.StatusCode == http.StatusOK {
-		expectedSize = headResp.ContentLength
-		headResp.Body.Close()
-		fmt.Printf("Expected PWR file size: %d bytes\n", expectedSize)
+	if expectedSize > 0 {
+		logger.Debug("Expected patch size", "bytes", expectedSize)
 	}
 
-	// Check if already cached AND complete
+	// A complete download left over from before the cache existed is adopted rather than fetched again
 	if info, err := os.Stat(pwrPath); err == nil && info.Size() > 0 {
-		// Verify file is complete (matches expected size or at least > 1GB for a full game patch)
 		if expectedSize > 0 && info.Size() == expectedSize {
-			fmt.Printf("PWR file found in cache (verified): %s (%d bytes)\n", pwrPath, info.Size())
-			return pwrPath, nil
-		} else if expectedSize > 0 && info.Size() < expectedSize {
-			fmt.Printf("PWR file in cache is incomplete (%d of %d bytes), re-downloading...\n", info.Size(), expectedSize)
-			os.Remove(pwrPath)
-		} else if expectedSize == 0 && info.Size() > 1024*1024*1024 {
-			// If we couldn't get expected size, assume files > 1GB are complete
-			fmt.Printf("PWR file found in cache: %s (%d bytes)\n", pwrPath, info.Size())
-			return pwrPath, nil
-		} else {
-			fmt.Printf("PWR file in cache may be incomplete (%d bytes), re-downloading...\n", info.Size())
-			os.Remove(pwrPath)
+			if sum, err := checkDownloadedPatch(pwrPath, path, expectedSize); err == nil {
+				if cachedPath, err := cache.add(path, patchURL(apiVersionType, fromVer, toVer), pwrPath, sum); err == nil {
+					logger.Info("Adopted existing patch into cache", "path", cachedPath, "bytes", info.Size())
+					return cachedPath, nil
+				}
+			}
 		}
+		logger.Warn("Patch file is incomplete or unverifiable, downloading it again", "path", pwrPath, "bytes", info.Size())
+		os.Remove(pwrPath)
 	}
 
 	if progressCallback != nil {
 		progressCallback("download", 0, "Downloading Hytale...", filepath.Base(pwrPath), "", 0, 0)
 	}
 
-	// Download with retries and resume capability
+	// Download with retries, failing over between mirrors on every attempt
 	maxRetries := 5
 	var lastErr error
 	
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		if attempt > 1 {
-			fmt.Printf("Retry attempt %d/%d for PWR download...\n", attempt, maxRetries)
+			logger.Warn("Retrying patch download", "attempt", attempt, "maxAttempts", maxRetries)
 			if progressCallback != nil {
 				progressCallback("download", 0, fmt.Sprintf("Retrying download (attempt %d/%d)...", attempt, maxRetries), filepath.Base(pwrPath), "", 0, 0)
 			}
-			time.Sleep(2 * time.Second)
-		}
-		
-		err := downloadPWRFile(ctx, url, pwrPath, expectedSize, progressCallback)
-		if err == nil {
-			return pwrPath, nil
+			select {
+			case <-ctx.Done():
+				return "", ctx.Err()
+			case <-time.After(2 * time.Second):
+			}
 		}
-		
-		lastErr = err
-		fmt.Printf("Download attempt %d failed: %v\n", attempt, err)
-	}
-	
-	return "", fmt.Errorf("failed to download after %d attempts: %w", maxRetries, lastErr)
-}
-
-func downloadPWRFile(ctx context.Context, url, pwrPath string, expectedSize int64, progressCallback func(stage string, progress float64, message string, currentFile string, speed string, downloaded, total int64)) error {
-	// Check if partial file exists
-	var resumeFrom int64 = 0
-	if stat, err := os.Stat(pwrPath); err == nil {
-		resumeFrom = stat.Size()
-		fmt.Printf("Resuming download from %d bytes\n", resumeFrom)
-	}
-
-	// Create HTTP request with proper headers (like Hytale-F2P)
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-	req.Header.Set("Accept", "*/*")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
-	req.Header.Set("Referer", "https://launcher.hytale.com/")
-	
-	if resumeFrom > 0 {
-		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", resumeFrom))
-	}
 
-	client := &http.Client{
-		Timeout: 60 * time.Minute, // Increased timeout for large files
-	}
+		for _, base := range orderedMirrors() {
+			url := base + path
+			logger.Info("Downloading patch", "url", url)
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to download patch: %w", err)
-	}
-	defer resp.Body.Close()
+			err := downloadPWRFile(ctx, url, path, pwrPath, expectedSize, progressCallback)
+			if ctx.Err() != nil {
+				// Paused or cancelled: keep the partial download for next time
+				return "", ctx.Err()
+			}
+			if err != nil {
+				markMirrorFailure(base, err)
+				lastErr = err
+				logger.Warn("Patch download failed", "attempt", attempt, "mirror", base, "error", err)
+				continue
+			}
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
-		return fmt.Errorf("patch not available: HTTP %d from %s", resp.StatusCode, url)
-	}
+			sum, err := checkDownloadedPatch(pwrPath, path, expectedSize)
+			if err != nil {
+				os.Remove(pwrPath)
+				demoteMirror(base, err.Error())
+				lastErr = err
+				continue
+			}
 
-	total := resp.ContentLength
-	if resp.StatusCode == http.StatusPartialContent {
-		total += resumeFrom
+			markMirrorSuccess(base)
+			cachedPath, err := cache.add(path, url, pwrPath, sum)
+			if err != nil {
+				return "", err
+			}
+			rememberPatchSource(cachedPath, base, path, sum)
+			return cachedPath, nil
+		}
 	}
 	
-	if resumeFrom == 0 {
-		fmt.Printf("PWR file size: %d bytes (%.2f GB)\n", total, float64(total)/(1024*1024*1024))
-	}
+	return "", fmt.Errorf("failed to download after %d attempts: %w", maxRetries, lastErr)
+}
 
-	// Open file for writing (append if resuming)
-	var file *os.File
-	if resp.StatusCode == http.StatusPartialContent && resumeFrom > 0 {
-		file, err = os.OpenFile(pwrPath, os.O_APPEND|os.O_WRONLY, 0644)
-	} else {
-		file, err = os.Create(pwrPath)
-		resumeFrom = 0
+// indexedPatchSize returns the size of a patch as recorded in the version index, or 0 if unknown
+func indexedPatchSize(versionType string, fromVer, toVer int) int64 {
+	branch, _ := GetVersionIndex().Branch(versionType)
+	if branch == nil {
+		return 0
 	}
-	if err != nil {
-		return fmt.Errorf("failed to create patch file: %w", err)
+	build, ok := branch.Build(toVer)
+	if !ok {
+		return 0
 	}
-	defer file.Close()
-
-	buf := make([]byte, 32*1024)
-	downloaded := resumeFrom
-	lastUpdate := time.Now()
-	lastDownloaded := downloaded
-
-	for {
-		n, err := resp.Body.Read(buf)
-		if n > 0 {
-			if _, writeErr := file.Write(buf[:n]); writeErr != nil {
-				return writeErr
-			}
-			downloaded += int64(n)
-
-			// Update progress every 100ms
-			if time.Since(lastUpdate) >= 100*time.Millisecond {
-				speed := float64(downloaded-lastDownloaded) / time.Since(lastUpdate).Seconds()
-				speedStr := formatSpeed(speed)
-				progress := float64(downloaded) / float64(total) * 100
-
-				if progressCallback != nil {
-					progressCallback("download", progress, "Downloading game patch...", filepath.Base(pwrPath), speedStr, downloaded, total)
-				}
-
-				lastUpdate = time.Now()
-				lastDownloaded = downloaded
-			}
-		}
-		if err != nil {
-			if err != io.EOF {
-				return fmt.Errorf("read error: %w", err)
-			}
-			break
-		}
+	if fromVer == 0 {
+		return build.Size
 	}
-
-	fmt.Printf("Download complete: %d bytes\n", downloaded)
-
-	// Verify download is complete
-	if total > 0 && downloaded < total {
-		return fmt.Errorf("download incomplete: got %d of %d bytes (%.1f%%), will retry", 
-			downloaded, total, float64(downloaded)/float64(total)*100)
+	if fromVer == toVer-1 && build.HasPatch {
+		return build.PatchSize
 	}
+	return 0
+}
 
-	// Final size verification
-	info, err := os.Stat(pwrPath)
-	if err != nil {
-		return fmt.Errorf("failed to verify downloaded file: %w", err)
+// downloadPWRFile downloads a patch from one mirror. A partial download is kept under the
+// mirror-relative path and expected size, so another mirror can finish it.
+func downloadPWRFile(ctx context.Context, url, path, pwrPath string, expectedSize int64, progressCallback func(stage string, progress float64, message string, currentFile string, speed string, downloaded, total int64)) error {
+	// Browser-like headers, as the patch server expects (like Hytale-F2P)
+	opts := download.SegmentedOptions{
+		Stage:   "download",
+		Message: "Downloading game patch...",
+		Headers: map[string]string{
+			"User-Agent":      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			"Accept":          "*/*",
+			"Accept-Language": "en-US,en;q=0.9",
+			"Referer":         "https://launcher.hytale.com/",
+		},
+		ExpectedSize: expectedSize,
+		ResumeKey:    fmt.Sprintf("%s#%d", path, expectedSize),
 	}
-	if total > 0 && info.Size() != total {
-		return fmt.Errorf("downloaded file size mismatch: expected %d, got %d bytes", total, info.Size())
+
+	if err := download.DownloadSegmented(ctx, url, pwrPath, opts, progressCallback); err != nil {
+		return err
 	}
 
-	fmt.Printf("Download verified: %d bytes\n", info.Size())
-	
-	if progressCallback != nil {
-		progressCallback("download", 100, "Download complete", "", "", downloaded, total)
+	if info, err := os.Stat(pwrPath); err == nil {
+		logger.Debug("Download verified", "bytes", info.Size())
 	}
-	
 	return nil
 }
 
@@ -0,0 +1,84 @@
+package pwr
+
+import (
+	"testing"
+)
+
+// indexOf builds a branch index where the listed builds have an incremental patch from the build before
+func indexOf(latest int, patched ...int) *BranchIndex {
+	hasPatch := make(map[int]bool)
+	for _, v := range patched {
+		hasPatch[v] = true
+	}
+	b := &BranchIndex{Latest: latest}
+	for v := 1; v <= latest; v++ {
+		b.Builds = append(b.Builds, BuildInfo{Version: v, Size: 100, HasPatch: hasPatch[v]})
+	}
+	return b
+}
+
+// sized sets the size of every full build to full and of every incremental patch to patch
+func sized(b *BranchIndex, full, patch int64) *BranchIndex {
+	for i := range b.Builds {
+		b.Builds[i].Size = full
+		if b.Builds[i].HasPatch {
+			b.Builds[i].PatchSize = patch
+		}
+	}
+	return b
+}
+
+func TestPlanPatchChain(t *testing.T) {
+	type hop struct{ from, to int }
+	tests := []struct {
+		name   string
+		branch *BranchIndex
+		from   int
+		to     int
+		direct directPatch
+		want   []hop
+	}{
+		{name: "fresh install", branch: indexOf(5, 2, 3, 4, 5), from: 0, to: 5, want: []hop{{0, 5}}},
+		{name: "one hop", branch: indexOf(5, 5), from: 4, to: 5, want: []hop{{4, 5}}},
+		{name: "chain", branch: indexOf(5, 2, 3, 4, 5), from: 2, to: 5, want: []hop{{2, 3}, {3, 4}, {4, 5}}},
+		{name: "missing patch in the middle", branch: indexOf(5, 2, 3, 5), from: 2, to: 5, want: []hop{{0, 5}}},
+		{name: "missing patch before the range is irrelevant", branch: indexOf(5, 4, 5), from: 3, to: 5, want: []hop{{3, 4}, {4, 5}}},
+		{name: "target not indexed yet", branch: indexOf(4, 2, 3, 4), from: 3, to: 5, want: []hop{{0, 5}}},
+		{name: "no index", branch: nil, from: 3, to: 5, want: []hop{{0, 5}}},
+		{name: "no index but a direct patch", branch: nil, from: 3, to: 5, direct: directPatch{true, 0}, want: []hop{{3, 5}}},
+		{name: "direct patch bridges a broken chain", branch: indexOf(5, 2, 3, 5), from: 2, to: 5, direct: directPatch{true, 0}, want: []hop{{2, 5}}},
+		{name: "direct patch preferred when sizes are unknown", branch: indexOf(5, 4, 5), from: 3, to: 5, direct: directPatch{true, 0}, want: []hop{{3, 5}}},
+		{name: "direct patch smaller than the chain", branch: sized(indexOf(5, 4, 5), 1000, 300), from: 3, to: 5, direct: directPatch{true, 400}, want: []hop{{3, 5}}},
+		{name: "chain smaller than the direct patch", branch: sized(indexOf(5, 4, 5), 1000, 100), from: 3, to: 5, direct: directPatch{true, 400}, want: []hop{{3, 4}, {4, 5}}},
+		{name: "full build smaller than the chain", branch: sized(indexOf(5, 2, 3, 4, 5), 250, 100), from: 2, to: 5, want: []hop{{0, 5}}},
+		{name: "full build smaller than the direct patch", branch: sized(indexOf(5), 250, 0), from: 2, to: 5, direct: directPatch{true, 900}, want: []hop{{0, 5}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps := planPatchChain("release", tt.branch, tt.from, tt.to, tt.direct)
+			if len(steps) != len(tt.want) {
+				t.Fatalf("planned %+v, want %v", steps, tt.want)
+			}
+			for i, step := range steps {
+				if step.From != tt.want[i].from || step.To != tt.want[i].to {
+					t.Fatalf("step %d is %d->%d, want %d->%d", i, step.From, step.To, tt.want[i].from, tt.want[i].to)
+				}
+				if step.URL != patchURL("release", step.From, step.To) {
+					t.Fatalf("step %d URL = %s", i, step.URL)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanPatchChainUpToDate(t *testing.T) {
+	for _, from := range []int{5, 6} {
+		steps, err := PlanPatchChain("release", from, 5)
+		if err != nil || len(steps) != 0 {
+			t.Fatalf("from %d: %+v, %v", from, steps, err)
+		}
+	}
+	if _, err := PlanPatchChain("release", 0, 0); err == nil {
+		t.Fatal("planned an install of build 0")
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package store
+
+// reflink is not available on this platform; callers fall back to hard links or copies
+func reflink(src, dst string) error {
+	return errUnsupported
+}
@@ -0,0 +1,390 @@
+// Package store keeps every unique game file once and assembles instance trees from it.
+//
+// Blobs live in <app>/store/objects/<aa>/<sha256>-<mode>. A tree is ingested by
+// replacing each of its files with a reflink or hard link to the matching blob.
+// A blob is garbage once no hard link besides its own points at it; trees that
+// were reflinked never depend on the blob, so removing it costs only future sharing.
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"HyPrism/internal/env"
+	"HyPrism/internal/logging"
+	"HyPrism/internal/util"
+)
+
+// logger writes game file store records to the launcher log
+var logger = logging.For(logging.Launcher)
+
+// errUnsupported is returned by reflink where the platform or filesystem cannot clone files
+var errUnsupported = errors.New("reflinks are not supported")
+
+// mu lets ingests and detaches run together while garbage collection runs alone
+var mu sync.RWMutex
+
+// Usage summarizes the store
+type Usage struct {
+	Blobs      int    `json:"blobs"`
+	Size       int64  `json:"size"`       // Bytes the blobs take on disk
+	SavedBytes int64  `json:"savedBytes"` // Bytes hard-linked trees would take without sharing
+	Dir        string `json:"dir"`
+}
+
+// IngestResult reports what an ingest did to a tree
+type IngestResult struct {
+	Files      int   `json:"files"`
+	Linked     int   `json:"linked"`     // Files replaced by a link to an existing blob
+	Added      int   `json:"added"`      // Files that became new blobs
+	SavedBytes int64 `json:"savedBytes"` // Bytes freed by linking to existing blobs
+}
+
+// GCResult reports what a garbage collection removed
+type GCResult struct {
+	Removed    int   `json:"removed"`
+	FreedBytes int64 `json:"freedBytes"`
+}
+
+// Dir returns the root of the store
+func Dir() string {
+	return filepath.Join(env.GetDefaultAppDir(), "store")
+}
+
+func objectsDir() string {
+	return filepath.Join(Dir(), "objects")
+}
+
+// blobPath returns where the blob for a hash and file mode lives.
+// The mode is part of the name so an executable never shares an inode with a plain file.
+func blobPath(sum string, mode os.FileMode) string {
+	return filepath.Join(objectsDir(), sum[:2], fmt.Sprintf("%s-%o", sum, mode.Perm()))
+}
+
+// Lookup returns the blob holding content with the given SHA-256 and file mode.
+// The blob is hashed first, since a blob shares its inode with hard-linked trees and
+// damage to one of them damages it too.
+func Lookup(sum string, mode os.FileMode) (string, bool) {
+	if len(sum) < 2 {
+		return "", false
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+
+	blob := blobPath(sum, mode)
+	actual, err := hashFile(blob)
+	if err != nil || actual != sum {
+		return "", false
+	}
+	return blob, true
+}
+
+// DropDamaged removes the blob for a hash and file mode if its content no longer matches
+// the hash, so a later ingest cannot link an intact file to it. Trees linked to the blob
+// keep their copy. Reports whether a blob was removed.
+func DropDamaged(sum string, mode os.FileMode) bool {
+	if len(sum) < 2 {
+		return false
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+
+	blob := blobPath(sum, mode)
+	actual, err := hashFile(blob)
+	if err != nil || actual == sum {
+		return false
+	}
+	if err := os.Remove(blob); err != nil {
+		logger.Warn("Failed to remove damaged blob", "blob", blob, "error", err)
+		return false
+	}
+	logger.Info("Removed damaged blob", "blob", blob)
+	return true
+}
+
+// Ingest moves the files of a game tree into the store and links them back.
+// Trees on a different volume than the store are left alone.
+func Ingest(ctx context.Context, dir string, progress func(done, total int)) (*IngestResult, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if err := os.MkdirAll(objectsDir(), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create game file store: %w", err)
+	}
+	if !sameVolume(dir, objectsDir()) {
+		logger.Info("Skipping game file store: not on the same volume", "dir", dir, "store", Dir())
+		return &IngestResult{}, nil
+	}
+
+	files, err := listFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &IngestResult{Files: len(files)}
+	for i, path := range files {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if progress != nil {
+			progress(i, len(files))
+		}
+
+		linked, added, size, err := ingestFile(path)
+		if err != nil {
+			// Sharing is an optimization; the tree stays valid with a private copy
+			logger.Warn("Failed to store file", "path", path, "error", err)
+			continue
+		}
+		if linked {
+			result.Linked++
+			result.SavedBytes += size
+		}
+		if added {
+			result.Added++
+		}
+	}
+	if progress != nil {
+		progress(len(files), len(files))
+	}
+
+	logger.Info("Stored game files", "dir", dir, "files", result.Files, "shared", result.Linked, "new", result.Added, "savedBytes", result.SavedBytes)
+	return result, nil
+}
+
+// ingestFile links one file with its blob. It reports whether the file now shares an
+// existing blob, whether it became a new blob, and its size.
+func ingestFile(path string) (linked bool, added bool, size int64, err error) {
+	info, err := os.Lstat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false, false, 0, err
+	}
+	size = info.Size()
+
+	sum, err := hashFile(path)
+	if err != nil {
+		return false, false, size, err
+	}
+	blob := blobPath(sum, info.Mode())
+
+	blobInfo, err := os.Stat(blob)
+	if os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(blob), 0755); err != nil {
+			return false, false, size, err
+		}
+		if err := os.Link(path, blob); err != nil {
+			return false, false, size, err
+		}
+		return false, true, size, nil
+	}
+	if err != nil {
+		return false, false, size, err
+	}
+	if os.SameFile(info, blobInfo) {
+		return false, false, size, nil
+	}
+
+	if err := linkInto(blob, path); err != nil {
+		return false, false, size, err
+	}
+	return true, false, size, nil
+}
+
+// linkInto replaces dst with a reflink of blob, or a hard link where reflinks are not available
+func linkInto(blob, dst string) error {
+	tmp := dst + ".store-tmp"
+	os.Remove(tmp)
+	if err := reflink(blob, tmp); err != nil {
+		os.Remove(tmp)
+		if err := os.Link(blob, tmp); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Detach gives every file in dir that shares its inode with the store a private copy,
+// so a patch applied in place cannot change the blob or other instances.
+func Detach(dir string) error {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	files, err := listFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	detached, err := detachFiles(files)
+	if detached > 0 {
+		logger.Info("Made private copies of shared files", "count", detached, "dir", dir)
+	}
+	return err
+}
+
+// DetachFiles is Detach for single files, such as the damaged files of a tree about to be repaired
+func DetachFiles(paths []string) error {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	_, err := detachFiles(paths)
+	return err
+}
+
+// detachFiles gives each hard-linked file of files a private copy and returns how many it copied
+func detachFiles(files []string) (int, error) {
+	detached := 0
+	for _, path := range files {
+		n, err := linkCount(path)
+		if err != nil || n <= 1 {
+			continue
+		}
+		if err := detachFile(path); err != nil {
+			return detached, fmt.Errorf("failed to make a private copy of %s: %w", path, err)
+		}
+		detached++
+	}
+	return detached, nil
+}
+
+// detachFile replaces a hard-linked file with its own copy, cloned where possible
+func detachFile(path string) error {
+	tmp := path + ".store-tmp"
+	os.Remove(tmp)
+	if err := reflink(path, tmp); err != nil {
+		os.Remove(tmp)
+		if err := util.CopyFile(path, tmp); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// CollectGarbage removes blobs that no tree links to anymore
+func CollectGarbage() (*GCResult, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	result := &GCResult{}
+	blobs, err := listFiles(objectsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return result, nil
+		}
+		return nil, err
+	}
+
+	for _, blob := range blobs {
+		n, err := linkCount(blob)
+		if err != nil || n > 1 {
+			continue
+		}
+		info, err := os.Stat(blob)
+		if err != nil {
+			continue
+		}
+		if err := os.Remove(blob); err != nil {
+			logger.Warn("Failed to remove unused blob", "blob", blob, "error", err)
+			continue
+		}
+		result.Removed++
+		result.FreedBytes += info.Size()
+	}
+
+	// Drop fan-out directories that are now empty
+	entries, _ := os.ReadDir(objectsDir())
+	for _, entry := range entries {
+		if entry.IsDir() {
+			os.Remove(filepath.Join(objectsDir(), entry.Name()))
+		}
+	}
+
+	if result.Removed > 0 {
+		logger.Info("Removed unused files from game file store", "count", result.Removed, "freedBytes", result.FreedBytes)
+	}
+	return result, nil
+}
+
+// GetUsage returns how much the store holds and how much sharing saves
+func GetUsage() Usage {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	usage := Usage{Dir: Dir()}
+	blobs, _ := listFiles(objectsDir())
+	for _, blob := range blobs {
+		info, err := os.Stat(blob)
+		if err != nil {
+			continue
+		}
+		usage.Blobs++
+		usage.Size += info.Size()
+		// One link is the store's own; every tree beyond the first is a saved copy
+		if n, err := linkCount(blob); err == nil && n > 2 {
+			usage.SavedBytes += int64(n-2) * info.Size()
+		}
+	}
+	return usage
+}
+
+// sameVolume reports whether hard links can be made from dir into storeDir
+func sameVolume(dir, storeDir string) bool {
+	probe := filepath.Join(dir, ".store-probe")
+	if err := os.WriteFile(probe, nil, 0644); err != nil {
+		return false
+	}
+	defer os.Remove(probe)
+
+	target := filepath.Join(storeDir, ".store-probe")
+	os.Remove(target)
+	if err := os.Link(probe, target); err != nil {
+		return false
+	}
+	os.Remove(target)
+	return true
+}
+
+// listFiles returns every regular file below root
+func listFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() && !strings.HasSuffix(path, ".store-tmp") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
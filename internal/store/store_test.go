@@ -0,0 +1,209 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFiles creates a tree of files, keyed by relative path, with the given contents
+func writeFiles(t *testing.T, root string, files map[string]string, mode os.FileMode) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), mode); err != nil {
+			t.Fatal(err)
+		}
+		os.Chmod(path, mode)
+	}
+}
+
+func links(t *testing.T, path string) uint64 {
+	t.Helper()
+	n, err := linkCount(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// skipIfReflinks skips tests of hard-linked trees where the store would reflink files instead
+func skipIfReflinks(t *testing.T, dir string) {
+	t.Helper()
+	probe := filepath.Join(dir, "probe")
+	os.WriteFile(probe, []byte("x"), 0644)
+	defer os.Remove(probe)
+	if reflink(probe, probe+".clone") == nil {
+		os.Remove(probe + ".clone")
+		t.Skip("the file system supports reflinks")
+	}
+}
+
+func TestIngestAndCollectGarbage(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("LOCALAPPDATA", home)
+	skipIfReflinks(t, home)
+
+	a := filepath.Join(home, "a")
+	b := filepath.Join(home, "b")
+	writeFiles(t, a, map[string]string{"Client/data": "same", "Client/a-only": "a"}, 0644)
+	writeFiles(t, a, map[string]string{"Client/run": "same"}, 0755)
+	writeFiles(t, b, map[string]string{"Client/data": "same", "Client/b-only": "bb"}, 0644)
+
+	steps := []struct {
+		name       string
+		ingest     string // Tree to ingest
+		remove     string // Tree to delete before collecting garbage
+		want       IngestResult
+		wantGC     GCResult
+		wantLinks  map[string]uint64 // Link counts of files afterwards
+		wantBlobs  int
+		wantShared int64 // Usage.SavedBytes
+	}{
+		{
+			name:   "first tree becomes blobs",
+			ingest: a,
+			// The executable keeps its own blob although its content matches
+			want:      IngestResult{Files: 3, Added: 3},
+			wantLinks: map[string]uint64{"a/Client/data": 2, "a/Client/run": 2},
+			wantBlobs: 3,
+		},
+		{
+			name:       "second tree links to the shared file",
+			ingest:     b,
+			want:       IngestResult{Files: 2, Linked: 1, Added: 1, SavedBytes: 4},
+			wantLinks:  map[string]uint64{"a/Client/data": 3, "b/Client/data": 3, "b/Client/b-only": 2},
+			wantBlobs:  4,
+			wantShared: 4,
+		},
+		{
+			name:       "ingesting again changes nothing",
+			ingest:     a,
+			want:       IngestResult{Files: 3},
+			wantLinks:  map[string]uint64{"a/Client/data": 3},
+			wantBlobs:  4,
+			wantShared: 4,
+		},
+		{
+			name:      "deleting a tree frees only its own blobs",
+			remove:    b,
+			wantGC:    GCResult{Removed: 1, FreedBytes: 2},
+			wantLinks: map[string]uint64{"a/Client/data": 2},
+			wantBlobs: 3,
+		},
+		{
+			name:   "deleting the last tree frees everything",
+			remove: a,
+			wantGC: GCResult{Removed: 3, FreedBytes: 9},
+		},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			if step.ingest != "" {
+				got, err := Ingest(context.Background(), step.ingest, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if *got != step.want {
+					t.Errorf("Ingest = %+v, want %+v", *got, step.want)
+				}
+			}
+			if step.remove != "" {
+				os.RemoveAll(step.remove)
+				got, err := CollectGarbage()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if *got != step.wantGC {
+					t.Errorf("CollectGarbage = %+v, want %+v", *got, step.wantGC)
+				}
+			}
+			for rel, want := range step.wantLinks {
+				if n := links(t, filepath.Join(home, rel)); n != want {
+					t.Errorf("%s has %d links, want %d", rel, n, want)
+				}
+			}
+			usage := GetUsage()
+			if usage.Blobs != step.wantBlobs || usage.SavedBytes != step.wantShared {
+				t.Errorf("usage %d blobs, %d bytes saved; want %d, %d", usage.Blobs, usage.SavedBytes, step.wantBlobs, step.wantShared)
+			}
+		})
+	}
+}
+
+func TestDetach(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("LOCALAPPDATA", home)
+	tree := filepath.Join(home, "game")
+	writeFiles(t, tree, map[string]string{"Client/data": "same"}, 0644)
+	if _, err := Ingest(context.Background(), tree, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Detach(tree); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(tree, "Client", "data")
+	if n := links(t, path); n != 1 {
+		t.Fatalf("detached file has %d links", n)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "same" {
+		t.Fatalf("detached file holds %q", data)
+	}
+
+	// The private copy can change without touching the blob
+	os.WriteFile(path, []byte("changed"), 0644)
+	if got, _ := CollectGarbage(); got.Removed != 1 {
+		t.Fatalf("the unused blob was not collected: %+v", got)
+	}
+}
+
+func TestRepairDamagedFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("LOCALAPPDATA", home)
+	skipIfReflinks(t, home)
+	tree := filepath.Join(home, "game")
+	writeFiles(t, tree, map[string]string{"Client/data": "intact", "Client/other": "other"}, 0644)
+	if _, err := Ingest(context.Background(), tree, nil); err != nil {
+		t.Fatal(err)
+	}
+	sum, _ := hashFile(filepath.Join(tree, "Client", "data"))
+	otherSum, _ := hashFile(filepath.Join(tree, "Client", "other"))
+
+	// Writing into a hard-linked file damages the blob as well
+	path := filepath.Join(tree, "Client", "data")
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("broken")
+	f.Close()
+	if _, ok := Lookup(sum, 0644); ok {
+		t.Fatal("Lookup returned a damaged blob")
+	}
+
+	if DropDamaged(otherSum, 0644) {
+		t.Error("an intact blob was dropped")
+	}
+	if !DropDamaged(sum, 0644) {
+		t.Fatal("the damaged blob was kept")
+	}
+	if _, err := os.Stat(blobPath(sum, 0644)); !os.IsNotExist(err) {
+		t.Fatal("the damaged blob is still in the store")
+	}
+
+	if err := DetachFiles([]string{filepath.Join(tree, "Client", "other")}); err != nil {
+		t.Fatal(err)
+	}
+	if n := links(t, filepath.Join(tree, "Client", "other")); n != 1 {
+		t.Fatalf("detached file has %d links", n)
+	}
+}
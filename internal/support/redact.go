@@ -0,0 +1,67 @@
+package support
+
+import (
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"HyPrism/internal/config"
+	"HyPrism/internal/logging"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// redactor removes personal details from the text of a bundle
+type redactor struct {
+	replacer *strings.Replacer
+}
+
+// newRedactor replaces the home directory, the account name and the player name
+func newRedactor(nick string) *redactor {
+	var pairs []string
+	if home, err := os.UserHomeDir(); err == nil && len(home) > 1 {
+		pairs = append(pairs, home, "~")
+		if slashed := filepath.ToSlash(home); slashed != home {
+			pairs = append(pairs, slashed, "~")
+		}
+	}
+	// Short names would match inside unrelated words
+	if u, err := user.Current(); err == nil {
+		name := u.Username
+		if i := strings.LastIndexAny(name, `\/`); i >= 0 {
+			name = name[i+1:] // DOMAIN\name on Windows
+		}
+		if len(name) >= 3 {
+			pairs = append(pairs, name, "<user>")
+		}
+	}
+	if len(nick) >= 3 && nick != config.Default().Nick && nick != "Player" {
+		pairs = append(pairs, nick, "<player>")
+	}
+	return &redactor{replacer: strings.NewReplacer(pairs...)}
+}
+
+// redact removes secrets and personal details from s
+func (r *redactor) redact(s string) string {
+	return r.replacer.Replace(logging.Redact(s))
+}
+
+// redactConfig returns the settings as TOML without the player name, custom paths or mirror credentials
+func redactConfig(cfg config.Config) ([]byte, error) {
+	cfg.Nick = logging.Redacted
+	if cfg.CustomInstanceDir != "" {
+		cfg.CustomInstanceDir = "[REDACTED PATH]"
+	}
+	mirrors := make([]string, len(cfg.PatchMirrors))
+	for i, mirror := range cfg.PatchMirrors {
+		if u, err := url.Parse(mirror); err == nil && u.User != nil {
+			u.User = nil
+			mirror = u.String()
+		}
+		mirrors[i] = mirror
+	}
+	cfg.PatchMirrors = mirrors
+	return toml.Marshal(cfg)
+}
@@ -0,0 +1,340 @@
+// Package support writes support bundles: one zip holding the diagnostics, logs, crash reports,
+// settings and instance details needed to look into a broken install, with personal details redacted.
+package support
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"HyPrism/internal/config"
+	"HyPrism/internal/env"
+	"HyPrism/internal/game"
+)
+
+// DefaultMaxBytes caps the contents of a bundle before compression
+const DefaultMaxBytes = 50 * 1024 * 1024
+
+const (
+	maxFileBytes      = 8 * 1024 * 1024 // Larger logs keep their end
+	minPartialBytes   = 64 * 1024       // A log is left out rather than cut below this
+	recentSessions    = 3               // Session logs included per instance
+	recentCrashes     = 10              // Crash reports included
+	maxListingEntries = 20000           // Lines in an instance's directory listing
+)
+
+// Input is what the launcher knows that goes into a bundle
+type Input struct {
+	Dir             string         // Directory the bundle is written to
+	MaxBytes        int64          // Cap on the contents before compression; 0 uses DefaultMaxBytes
+	DiagnosticsJSON []byte         // Diagnostic report as JSON
+	DiagnosticsText string         // Diagnostic report for people
+	Config          *config.Config // Current settings; written redacted
+	LauncherLogs    []string       // launcher.log and rotated parts, newest first
+}
+
+// Result describes a written bundle
+type Result struct {
+	Path    string   `json:"path"`
+	Size    int64    `json:"size"`              // Size of the zip
+	Files   int      `json:"files"`             // Entries in the zip
+	Skipped []string `json:"skipped,omitempty"` // Entries left out to stay under the size cap
+}
+
+// entry is one file of a bundle; data is read only when the entry is written
+type entry struct {
+	name string
+	data func() ([]byte, error)
+	// tail keeps the end of the data when it does not fit, as for logs
+	tail bool
+}
+
+// Write collects a bundle into a timestamped zip in in.Dir
+func Write(ctx context.Context, in Input, progressCallback func(stage string, progress float64, message string, currentFile string, speed string, downloaded, total int64)) (*Result, error) {
+	if in.MaxBytes <= 0 {
+		in.MaxBytes = DefaultMaxBytes
+	}
+	nick := ""
+	if in.Config != nil {
+		nick = in.Config.Nick
+	}
+	r := newRedactor(nick)
+
+	// Most useful first, so the size cap drops the least useful
+	entries := []entry{
+		{name: "diagnostics.json", data: bytesOf(in.DiagnosticsJSON)},
+		{name: "diagnostics.txt", data: bytesOf([]byte(in.DiagnosticsText))},
+	}
+	if in.Config != nil {
+		entries = append(entries, entry{name: "config.toml", data: func() ([]byte, error) { return redactConfig(*in.Config) }})
+	}
+	instances := env.ListInstanceDescriptors()
+	for _, inst := range instances {
+		entries = append(entries, instanceEntries(inst)...)
+	}
+	entries = append(entries, crashEntries()...)
+	for i, path := range in.LauncherLogs {
+		name := "logs/" + filepath.Base(path)
+		if i == 0 {
+			name = "logs/launcher.log"
+		}
+		entries = append(entries, entry{name: name, data: fileOf(path), tail: true})
+	}
+	for _, inst := range instances {
+		entries = append(entries, sessionEntries(inst)...)
+	}
+
+	if err := os.MkdirAll(in.Dir, 0755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(in.Dir, fmt.Sprintf("hyprism-support-%s.zip", time.Now().Format("2006-01-02_15-04-05")))
+	tmpPath := path + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create support bundle: %w", err)
+	}
+	zw := zip.NewWriter(out)
+	fail := func(err error) (*Result, error) {
+		zw.Close()
+		out.Close()
+		os.Remove(tmpPath)
+		return nil, err
+	}
+
+	result := &Result{}
+	var written int64
+	for i, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return fail(err)
+		}
+		if progressCallback != nil {
+			progressCallback("support", float64(i)/float64(len(entries))*100, "Collecting support bundle...", e.name, "", written, in.MaxBytes)
+		}
+
+		data, err := e.data()
+		if err != nil {
+			if !os.IsNotExist(err) {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("%s (%v)", e.name, err))
+			}
+			continue
+		}
+		data = []byte(r.redact(string(data)))
+
+		room := in.MaxBytes - written
+		if e.tail && room > maxFileBytes {
+			room = maxFileBytes
+		}
+		if int64(len(data)) > room {
+			if !e.tail || room < minPartialBytes {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("%s (%d bytes, over the size cap)", e.name, len(data)))
+				continue
+			}
+			data = tailOf(data, room)
+		}
+
+		if err := addBytes(zw, e.name, data); err != nil {
+			return fail(fmt.Errorf("failed to add %s: %w", e.name, err))
+		}
+		written += int64(len(data))
+		result.Files++
+	}
+
+	if err := addBytes(zw, "README.txt", []byte(readme(result, in.MaxBytes))); err != nil {
+		return fail(err)
+	}
+	result.Files++
+
+	if err := zw.Close(); err != nil {
+		return fail(err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	if info, err := os.Stat(path); err == nil {
+		result.Size = info.Size()
+	}
+	result.Path = path
+
+	if progressCallback != nil {
+		progressCallback("complete", 100, "Support bundle written", "", "", written, written)
+	}
+	return result, nil
+}
+
+// crashEntries returns the newest crash reports
+func crashEntries() []entry {
+	dir := env.GetCrashesDir()
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	type report struct {
+		name    string
+		modTime time.Time
+	}
+	var reports []report
+	for _, f := range files {
+		if info, err := f.Info(); err == nil && info.Mode().IsRegular() {
+			reports = append(reports, report{f.Name(), info.ModTime()})
+		}
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].modTime.After(reports[j].modTime) })
+	if len(reports) > recentCrashes {
+		reports = reports[:recentCrashes]
+	}
+
+	var entries []entry
+	for _, rep := range reports {
+		entries = append(entries, entry{name: "crashes/" + rep.name, data: fileOf(filepath.Join(dir, rep.name)), tail: true})
+	}
+	return entries
+}
+
+// instanceEntries returns the descriptor, mod manifest and directory listing of an instance.
+// The descriptor records the installed build.
+func instanceEntries(inst *env.Instance) []entry {
+	prefix := "instances/" + inst.ID + "/"
+	return []entry{
+		{name: prefix + env.InstanceFileName, data: fileOf(filepath.Join(inst.DescriptorDir(), env.InstanceFileName))},
+		{name: prefix + "manifest.json", data: fileOf(filepath.Join(inst.UserDataDir(), "Mods", "manifest.json"))},
+		{name: prefix + "files.txt", data: func() ([]byte, error) { return listing(inst), nil }},
+	}
+}
+
+// sessionEntries returns the logs of an instance's most recent game sessions
+func sessionEntries(inst *env.Instance) []entry {
+	sessions, err := game.ListSessions(inst.ID)
+	if err != nil {
+		return nil
+	}
+	if len(sessions) > recentSessions {
+		sessions = sessions[:recentSessions]
+	}
+
+	var entries []entry
+	for _, s := range sessions {
+		id := s.ID
+		entries = append(entries, entry{
+			name: "instances/" + inst.ID + "/sessions/" + id + ".log",
+			data: func() ([]byte, error) {
+				text, err := game.ReadSessionLog(inst.ID, id, maxFileBytes)
+				return []byte(text), err
+			},
+			tail: true,
+		})
+	}
+	return entries
+}
+
+// listing lists every file and directory of an instance with its size and modification time
+func listing(inst *env.Instance) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Instance %s (%s)\n", inst.ID, inst.Name)
+	if inst.GameFrom != "" {
+		fmt.Fprintf(&b, "Game files shared from %s\n", inst.GameFrom)
+	}
+	b.WriteString("\n")
+
+	root := inst.Dir()
+	count := 0
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			rel, _ := filepath.Rel(root, path)
+			fmt.Fprintf(&b, "%12s  %-19s  %s (%v)\n", "?", "", filepath.ToSlash(rel), err)
+			return nil
+		}
+		if path == root {
+			return nil
+		}
+		count++
+		if count > maxListingEntries {
+			return filepath.SkipAll
+		}
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		info, err := d.Info()
+		if err != nil {
+			fmt.Fprintf(&b, "%12s  %-19s  %s\n", "?", "", rel)
+			return nil
+		}
+		size := fmt.Sprintf("%d", info.Size())
+		switch {
+		case d.IsDir():
+			size = "-"
+			rel += "/"
+		case d.Type()&fs.ModeSymlink != 0:
+			size = "link"
+		}
+		fmt.Fprintf(&b, "%12s  %s  %s\n", size, info.ModTime().Format("2006-01-02 15:04:05"), rel)
+		return nil
+	})
+	if count > maxListingEntries {
+		fmt.Fprintf(&b, "... listing stopped after %d entries\n", maxListingEntries)
+	}
+	return []byte(b.String())
+}
+
+// readme describes a bundle and what was left out of it
+func readme(result *Result, maxBytes int64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "HyPrism Support Bundle\nGenerated: %s\n\n", time.Now().Format(time.RFC3339))
+	b.WriteString("Usernames, home directory paths, tokens and API keys have been redacted.\n")
+	fmt.Fprintf(&b, "Contents are capped at %d MB; long logs keep their end.\n", maxBytes/(1024*1024))
+	if len(result.Skipped) > 0 {
+		b.WriteString("\nLeft out:\n")
+		for _, s := range result.Skipped {
+			fmt.Fprintf(&b, "  %s\n", s)
+		}
+	}
+	return b.String()
+}
+
+// addBytes writes one file to the zip
+func addBytes(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// tailOf returns the last n bytes of data, starting at a line boundary
+func tailOf(data []byte, n int64) []byte {
+	data = data[int64(len(data))-n:]
+	if i := strings.IndexByte(string(data[:min(len(data), 4096)]), '\n'); i >= 0 {
+		data = data[i+1:]
+	}
+	return data
+}
+
+func bytesOf(data []byte) func() ([]byte, error) {
+	return func() ([]byte, error) { return data, nil }
+}
+
+func fileOf(path string) func() ([]byte, error) {
+	return func() ([]byte, error) { return os.ReadFile(path) }
+}
+
+// LauncherLogs returns the launcher log at path and its rotated parts that exist, newest first
+func LauncherLogs(path string, backups int) []string {
+	paths := []string{path}
+	for i := 1; i <= backups; i++ {
+		rotated := fmt.Sprintf("%s.%d", path, i)
+		if _, err := os.Stat(rotated); err == nil {
+			paths = append(paths, rotated)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,431 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"HyPrism/internal/logging"
+)
+
+// logger writes task queue records to the launcher log
+var logger = logging.For(logging.Launcher)
+
+// State is the lifecycle state of a job
+type State string
+
+const (
+	StateQueued    State = "queued"
+	StateRunning   State = "running"
+	StatePaused    State = "paused"
+	StateCompleted State = "completed"
+	StateFailed    State = "failed"
+	StateCancelled State = "cancelled"
+)
+
+// Priorities used by the launcher; higher runs first
+const (
+	PriorityLow    = 0
+	PriorityNormal = 10
+	PriorityHigh   = 20
+)
+
+// ErrCancelled is returned by Wait when a job was cancelled
+var ErrCancelled = errors.New("task cancelled")
+
+// ErrAlreadyQueued is returned by Submit when a job with the same key has not finished.
+// The new work is not run; Submit returns the ID of the existing job with this error.
+var ErrAlreadyQueued = errors.New("the same task is already queued or running")
+
+// ProgressFunc has the same shape as the progress callbacks used across the launcher
+type ProgressFunc func(stage string, progress float64, message string, currentFile string, speed string, downloaded, total int64)
+
+// RunFunc does the work of a job. It must stop promptly when ctx is cancelled;
+// a paused job is run again from the start, so the work should be resumable.
+type RunFunc func(ctx context.Context, progress ProgressFunc) error
+
+// Job is a snapshot of a queued operation as seen by the frontend
+type Job struct {
+	ID          string  `json:"id"`
+	Kind        string  `json:"kind"`  // e.g. "install", "mod", "verify", "update"
+	Title       string  `json:"title"` // Human readable description
+	Priority    int     `json:"priority"`
+	State       State   `json:"state"`
+	Stage       string  `json:"stage"`
+	Progress    float64 `json:"progress"`
+	Message     string  `json:"message"`
+	CurrentFile string  `json:"currentFile"`
+	Speed       string  `json:"speed"`
+	Downloaded  int64   `json:"downloaded"`
+	Total       int64   `json:"total"`
+	Error       string  `json:"error,omitempty"`
+	CreatedAt   string  `json:"createdAt"`            // ISO 8601 format
+	StartedAt   string  `json:"startedAt,omitempty"`  // ISO 8601 format
+	FinishedAt  string  `json:"finishedAt,omitempty"` // ISO 8601 format
+}
+
+// Options describes a job being submitted
+type Options struct {
+	Kind     string
+	Title    string
+	Priority int
+	// Key identifies the work; submitting a key that is already queued, running or paused
+	// fails with ErrAlreadyQueued instead of starting a second copy
+	Key string
+	// Group serializes jobs: only one job of a group runs at a time
+	Group string
+}
+
+// job is the queue's private record of a Job
+type job struct {
+	Job
+	key    string
+	group  string
+	seq    int
+	run    RunFunc
+	cancel context.CancelFunc
+	// stopAs is the state the job moves to when its context is cancelled on purpose
+	stopAs State
+	err    error
+	done   chan struct{}
+}
+
+func (j *job) finished() bool {
+	return j.State == StateCompleted || j.State == StateFailed || j.State == StateCancelled
+}
+
+// Queue runs jobs by priority with a limit on how many run at once
+type Queue struct {
+	mu            sync.Mutex
+	ctx           context.Context
+	maxConcurrent int
+	jobs          map[string]*job
+	nextSeq       int
+	running       int
+	notify        func(Job)
+}
+
+// NewQueue creates a queue whose jobs live as long as ctx.
+// notify is called with a snapshot every time a job changes; it runs under the
+// queue lock and must not call back into the queue.
+func NewQueue(ctx context.Context, maxConcurrent int, notify func(Job)) *Queue {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	return &Queue{
+		ctx:           ctx,
+		maxConcurrent: maxConcurrent,
+		jobs:          make(map[string]*job),
+		notify:        notify,
+	}
+}
+
+// Submit adds a job to the queue and returns its ID.
+// If a job with the same key has not finished, its ID is returned with ErrAlreadyQueued and run is dropped.
+func (q *Queue) Submit(opts Options, run RunFunc) (string, error) {
+	q.mu.Lock()
+
+	if opts.Key != "" {
+		for _, j := range q.jobs {
+			if j.key == opts.Key && !j.finished() {
+				q.mu.Unlock()
+				return j.ID, fmt.Errorf("%w: %s (%s)", ErrAlreadyQueued, j.Title, j.State)
+			}
+		}
+	}
+
+	q.nextSeq++
+	j := &job{
+		Job: Job{
+			ID:        fmt.Sprintf("%s-%d", kindOrTask(opts.Kind), q.nextSeq),
+			Kind:      opts.Kind,
+			Title:     opts.Title,
+			Priority:  opts.Priority,
+			State:     StateQueued,
+			CreatedAt: time.Now().Format(time.RFC3339),
+		},
+		key:   opts.Key,
+		group: opts.Group,
+		seq:   q.nextSeq,
+		run:   run,
+		done:  make(chan struct{}),
+	}
+	q.jobs[j.ID] = j
+	logger.Info("Queued task", "task", j.ID, "title", j.Title)
+
+	q.changed(j)
+	q.schedule()
+	q.mu.Unlock()
+	return j.ID, nil
+}
+
+// Wait blocks until a job completes, fails or is cancelled and returns its error
+func (q *Queue) Wait(ctx context.Context, id string) error {
+	q.mu.Lock()
+	j, ok := q.jobs[id]
+	q.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("task %s not found", id)
+	}
+
+	select {
+	case <-j.done:
+		return j.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run submits a job and waits for it to finish
+func (q *Queue) Run(ctx context.Context, opts Options, run RunFunc) error {
+	id, err := q.Submit(opts, run)
+	if err != nil {
+		return err
+	}
+	return q.Wait(ctx, id)
+}
+
+// List returns every known job, running first, then by priority and age
+func (q *Queue) List() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	sorted := q.sorted()
+	jobs := make([]Job, 0, len(sorted))
+	for _, j := range sorted {
+		jobs = append(jobs, j.Job)
+	}
+	return jobs
+}
+
+// Get returns a snapshot of a job
+func (q *Queue) Get(id string) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	j, ok := q.jobs[id]
+	if !ok {
+		return Job{}, fmt.Errorf("task %s not found", id)
+	}
+	return j.Job, nil
+}
+
+// Pause stops a queued or running job without discarding its progress
+func (q *Queue) Pause(id string) error {
+	return q.stop(id, StatePaused)
+}
+
+// Cancel stops a job for good
+func (q *Queue) Cancel(id string) error {
+	return q.stop(id, StateCancelled)
+}
+
+// Resume puts a paused job back in the queue
+func (q *Queue) Resume(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	j, ok := q.jobs[id]
+	if !ok {
+		return fmt.Errorf("task %s not found", id)
+	}
+	if j.State != StatePaused {
+		return fmt.Errorf("task %s is %s, not paused", id, j.State)
+	}
+
+	j.State = StateQueued
+	j.Speed = ""
+	q.changed(j)
+	q.schedule()
+	return nil
+}
+
+// SetPriority changes the priority of a job that has not finished
+func (q *Queue) SetPriority(id string, priority int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	j, ok := q.jobs[id]
+	if !ok {
+		return fmt.Errorf("task %s not found", id)
+	}
+	if j.finished() {
+		return fmt.Errorf("task %s has already finished", id)
+	}
+
+	j.Priority = priority
+	q.changed(j)
+	q.schedule()
+	return nil
+}
+
+// ClearFinished forgets completed, failed and cancelled jobs
+func (q *Queue) ClearFinished() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for id, j := range q.jobs {
+		if j.finished() {
+			delete(q.jobs, id)
+		}
+	}
+}
+
+func (q *Queue) stop(id string, to State) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	j, ok := q.jobs[id]
+	if !ok {
+		return fmt.Errorf("task %s not found", id)
+	}
+
+	switch j.State {
+	case StateRunning:
+		// The job settles into its new state once its function returns
+		j.stopAs = to
+		j.cancel()
+	case StateQueued, StatePaused:
+		if to == StateCancelled {
+			q.finish(j, ErrCancelled)
+		} else {
+			j.State = to
+			q.changed(j)
+		}
+	default:
+		return fmt.Errorf("task %s has already finished", id)
+	}
+	return nil
+}
+
+// schedule starts queued jobs while there are free slots. Must be called with q.mu held.
+func (q *Queue) schedule() {
+	busyGroups := make(map[string]bool)
+	for _, j := range q.jobs {
+		if j.State == StateRunning && j.group != "" {
+			busyGroups[j.group] = true
+		}
+	}
+
+	for _, j := range q.sorted() {
+		if q.running >= q.maxConcurrent {
+			return
+		}
+		if j.State != StateQueued || (j.group != "" && busyGroups[j.group]) {
+			continue
+		}
+		if j.group != "" {
+			busyGroups[j.group] = true
+		}
+		q.start(j)
+	}
+}
+
+// start runs a job in the background. Must be called with q.mu held.
+func (q *Queue) start(j *job) {
+	ctx, cancel := context.WithCancel(q.ctx)
+	j.cancel = cancel
+	j.stopAs = ""
+	j.State = StateRunning
+	j.Error = ""
+	if j.StartedAt == "" {
+		j.StartedAt = time.Now().Format(time.RFC3339)
+	}
+	q.running++
+	q.changed(j)
+
+	go func() {
+		err := j.run(ctx, func(stage string, progress float64, message string, currentFile string, speed string, downloaded, total int64) {
+			q.mu.Lock()
+			j.Stage = stage
+			j.Progress = progress
+			j.Message = message
+			j.CurrentFile = currentFile
+			j.Speed = speed
+			j.Downloaded = downloaded
+			j.Total = total
+			q.changed(j)
+			q.mu.Unlock()
+		})
+		cancel()
+
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		q.running--
+
+		switch {
+		case j.stopAs == StatePaused:
+			logger.Info("Paused task", "task", j.ID)
+			j.State = StatePaused
+			j.Speed = ""
+			q.changed(j)
+		case j.stopAs == StateCancelled:
+			q.finish(j, ErrCancelled)
+		case err != nil && q.ctx.Err() != nil:
+			// The launcher is shutting down
+			q.finish(j, ErrCancelled)
+		default:
+			q.finish(j, err)
+		}
+		q.schedule()
+	}()
+}
+
+// finish moves a job to its final state. Must be called with q.mu held.
+func (q *Queue) finish(j *job, err error) {
+	j.err = err
+	j.Speed = ""
+	j.FinishedAt = time.Now().Format(time.RFC3339)
+
+	switch {
+	case errors.Is(err, ErrCancelled):
+		j.State = StateCancelled
+		logger.Info("Cancelled task", "task", j.ID)
+	case err != nil:
+		j.State = StateFailed
+		j.Error = err.Error()
+		logger.Error("Task failed", "task", j.ID, "error", err)
+	default:
+		j.State = StateCompleted
+		j.Progress = 100
+	}
+
+	q.changed(j)
+	close(j.done)
+}
+
+// changed reports a job snapshot. Must be called with q.mu held.
+func (q *Queue) changed(j *job) {
+	if q.notify != nil {
+		q.notify(j.Job)
+	}
+}
+
+// sorted returns jobs with running first, then by priority, then oldest first
+func (q *Queue) sorted() []*job {
+	jobs := make([]*job, 0, len(q.jobs))
+	for _, j := range q.jobs {
+		jobs = append(jobs, j)
+	}
+	sort.Slice(jobs, func(a, b int) bool {
+		ra, rb := jobs[a].State == StateRunning, jobs[b].State == StateRunning
+		if ra != rb {
+			return ra
+		}
+		if jobs[a].Priority != jobs[b].Priority {
+			return jobs[a].Priority > jobs[b].Priority
+		}
+		return jobs[a].seq < jobs[b].seq
+	})
+	return jobs
+}
+
+func kindOrTask(kind string) string {
+	if kind == "" {
+		return "task"
+	}
+	return kind
+}
@@ -0,0 +1,198 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testTimeout = 5 * time.Second
+
+// blocker is a RunFunc that runs until released or cancelled
+type blocker struct {
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func newBlocker() *blocker {
+	return &blocker{started: make(chan struct{}, 16), release: make(chan struct{})}
+}
+
+func (b *blocker) run(ctx context.Context, progress ProgressFunc) error {
+	b.started <- struct{}{}
+	select {
+	case <-b.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *blocker) done() { b.once.Do(func() { close(b.release) }) }
+
+func waitStarted(t *testing.T, b *blocker) {
+	t.Helper()
+	select {
+	case <-b.started:
+	case <-time.After(testTimeout):
+		t.Fatal("job did not start")
+	}
+}
+
+func waitState(t *testing.T, q *Queue, id string, want State) {
+	t.Helper()
+	deadline := time.Now().Add(testTimeout)
+	for time.Now().Before(deadline) {
+		if j, err := q.Get(id); err == nil && j.State == want {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	j, _ := q.Get(id)
+	t.Fatalf("job %s is %s, want %s", id, j.State, want)
+}
+
+func TestSubmitSameKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, q *Queue, id string)
+	}{
+		{name: "running", setup: func(t *testing.T, q *Queue, id string) {}},
+		{name: "paused", setup: func(t *testing.T, q *Queue, id string) {
+			if err := q.Pause(id); err != nil {
+				t.Fatal(err)
+			}
+			waitState(t, q, id, StatePaused)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewQueue(context.Background(), 2, nil)
+			b := newBlocker()
+			defer b.done()
+
+			first, err := q.Submit(Options{Title: "Verify", Key: "verify:a"}, b.run)
+			if err != nil {
+				t.Fatal(err)
+			}
+			waitStarted(t, b)
+			tt.setup(t, q, first)
+
+			ran := false
+			second, err := q.Submit(Options{Title: "Verify", Key: "verify:a"}, func(ctx context.Context, progress ProgressFunc) error {
+				ran = true
+				return nil
+			})
+			if !errors.Is(err, ErrAlreadyQueued) {
+				t.Fatalf("error = %v, want ErrAlreadyQueued", err)
+			}
+			if second != first {
+				t.Fatalf("returned job %s, want the existing %s", second, first)
+			}
+
+			// Run reports the duplicate instead of waiting on work it did not submit
+			ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+			defer cancel()
+			if err := q.Run(ctx, Options{Key: "verify:a"}, func(ctx context.Context, progress ProgressFunc) error {
+				ran = true
+				return nil
+			}); !errors.Is(err, ErrAlreadyQueued) {
+				t.Fatalf("Run error = %v, want ErrAlreadyQueued", err)
+			}
+			if ran {
+				t.Fatal("the duplicate's function ran")
+			}
+		})
+	}
+}
+
+func TestSubmitSameKeyAfterFinish(t *testing.T) {
+	q := NewQueue(context.Background(), 1, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	for i := 0; i < 2; i++ {
+		calls := 0
+		err := q.Run(ctx, Options{Key: "export:a"}, func(ctx context.Context, progress ProgressFunc) error {
+			calls++
+			return nil
+		})
+		if err != nil || calls != 1 {
+			t.Fatalf("run %d: error = %v, calls = %d", i, err, calls)
+		}
+	}
+}
+
+func TestGroupAndPriority(t *testing.T) {
+	q := NewQueue(context.Background(), 3, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	first := newBlocker()
+	firstID, _ := q.Submit(Options{Group: "install"}, first.run)
+	waitStarted(t, first)
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) RunFunc {
+		return func(ctx context.Context, progress ProgressFunc) error {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			return nil
+		}
+	}
+	low, _ := q.Submit(Options{Group: "install", Priority: PriorityLow}, record("low"))
+	high, _ := q.Submit(Options{Group: "install", Priority: PriorityHigh}, record("high"))
+
+	// The group is busy, so neither may start
+	time.Sleep(20 * time.Millisecond)
+	mu.Lock()
+	if len(order) != 0 {
+		t.Fatalf("jobs ran while their group was busy: %v", order)
+	}
+	mu.Unlock()
+
+	first.done()
+	for _, id := range []string{firstID, low, high} {
+		if err := q.Wait(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(order) != 2 || order[0] != "high" || order[1] != "low" {
+		t.Fatalf("order = %v, want [high low]", order)
+	}
+}
+
+func TestPauseResumeCancel(t *testing.T) {
+	q := NewQueue(context.Background(), 1, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	b := newBlocker()
+	id, _ := q.Submit(Options{Key: "install"}, b.run)
+	waitStarted(t, b)
+
+	if err := q.Pause(id); err != nil {
+		t.Fatal(err)
+	}
+	waitState(t, q, id, StatePaused)
+
+	if err := q.Resume(id); err != nil {
+		t.Fatal(err)
+	}
+	waitStarted(t, b)
+
+	if err := q.Cancel(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Wait(ctx, id); !errors.Is(err, ErrCancelled) {
+		t.Fatalf("error = %v, want ErrCancelled", err)
+	}
+	if err := q.Resume(id); err == nil {
+		t.Fatal("a cancelled job was resumed")
+	}
+}
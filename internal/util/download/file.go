@@ -11,8 +11,13 @@ import (
 	"path/filepath"
 	"runtime"
 	"time"
+
+	"HyPrism/internal/logging"
 )
 
+// logger writes download records to the launcher log
+var logger = logging.For(logging.Launcher)
+
 const (
 	maxRetries      = 3
 	retryDelay      = 2 * time.Second
@@ -21,6 +26,7 @@ const (
 
 // DownloadWithProgress downloads a file with progress reporting
 func DownloadWithProgress(
+	ctx context.Context,
 	dest string,
 	url string,
 	stage string,
@@ -30,16 +36,23 @@ func DownloadWithProgress(
 	var lastErr error
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		err := attemptDownload(dest, url, stage, progressWeight, callback)
+		err := attemptDownload(ctx, dest, url, stage, progressWeight, callback)
 		if err == nil {
 			return nil
 		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 
 		lastErr = err
-		fmt.Printf("Download attempt %d failed: %v\n", attempt, err)
+		logger.Warn("Download attempt failed", "attempt", attempt, "error", err)
 
 		if attempt < maxRetries {
-			time.Sleep(retryDelay)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(retryDelay):
+			}
 		}
 	}
 
@@ -47,6 +60,7 @@ func DownloadWithProgress(
 }
 
 func attemptDownload(
+	ctx context.Context,
 	dest string,
 	url string,
 	stage string,
@@ -68,7 +82,7 @@ func attemptDownload(
 	}
 
 	// Create request with context for timeout control
-	ctx, cancel := context.WithTimeout(context.Background(), downloadTimeout)
+	ctx, cancel := context.WithTimeout(ctx, downloadTimeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -116,7 +130,13 @@ func attemptDownload(
 	lastDownloaded := downloaded
 
 	for {
-		n, readErr := resp.Body.Read(buf)
+		granted, err := globalLimiter.reserve(ctx, len(buf))
+		if err != nil {
+			return err
+		}
+
+		n, readErr := resp.Body.Read(buf[:granted])
+		globalLimiter.refund(granted - n)
 		if n > 0 {
 			if _, writeErr := file.Write(buf[:n]); writeErr != nil {
 				return writeErr
@@ -227,7 +247,7 @@ func DownloadReleaseAsset(ctx context.Context, assetName, dest string, isNightly
 		url = fmt.Sprintf("https://github.com/%s/%s/releases/latest/download/%s", owner, repo, assetName)
 	}
 	
-	return DownloadWithProgress(dest, url, "download", 1.0, callback)
+	return DownloadWithProgress(ctx, dest, url, "download", 1.0, callback)
 }
 
 // GetSystemArch returns the system architecture in a normalized format
@@ -275,7 +295,13 @@ func DownloadFile(ctx context.Context, url, dest string, progressCallback func(d
 	buf := make([]byte, 32*1024)
 
 	for {
-		n, err := resp.Body.Read(buf)
+		granted, err := globalLimiter.reserve(ctx, len(buf))
+		if err != nil {
+			return err
+		}
+
+		n, err := resp.Body.Read(buf[:granted])
+		globalLimiter.refund(granted - n)
 		if n > 0 {
 			_, writeErr := out.Write(buf[:n])
 			if writeErr != nil {
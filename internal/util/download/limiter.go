@@ -0,0 +1,100 @@
+package download
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// bandwidthLimiter is a token bucket shared by every download in the launcher
+type bandwidthLimiter struct {
+	mu       sync.Mutex
+	rate     float64 // Bytes per second, 0 means unlimited
+	tokens   float64
+	lastFill time.Time
+}
+
+var globalLimiter = &bandwidthLimiter{}
+
+// minGrant is the least a capped read waits for, unless its buffer or the burst is smaller
+const minGrant = 16 * 1024
+
+// SetBandwidthLimit caps the combined download speed of the launcher
+// A limit of 0 or less removes the cap
+func SetBandwidthLimit(bytesPerSec int64) {
+	globalLimiter.mu.Lock()
+	defer globalLimiter.mu.Unlock()
+
+	if bytesPerSec <= 0 {
+		globalLimiter.rate = 0
+		return
+	}
+	globalLimiter.rate = float64(bytesPerSec)
+	globalLimiter.tokens = 0
+	globalLimiter.lastFill = time.Now()
+}
+
+// GetBandwidthLimit returns the current download cap in bytes per second (0 = unlimited)
+func GetBandwidthLimit() int64 {
+	globalLimiter.mu.Lock()
+	defer globalLimiter.mu.Unlock()
+	return int64(globalLimiter.rate)
+}
+
+// reserve blocks until some of up to max bytes may be transferred under the global cap
+// and returns how many. The caller reads at most that many bytes and refunds the rest,
+// so the cap is charged for what was actually transferred.
+func (l *bandwidthLimiter) reserve(ctx context.Context, max int) (int, error) {
+	for {
+		l.mu.Lock()
+		if l.rate <= 0 {
+			l.mu.Unlock()
+			return max, nil
+		}
+
+		now := time.Now()
+		l.tokens += now.Sub(l.lastFill).Seconds() * l.rate
+		l.lastFill = now
+		// Allow at most a quarter second of burst so the cap stays smooth
+		burst := l.rate / 4
+		if l.tokens > burst {
+			l.tokens = burst
+		}
+
+		// Wait for a useful amount rather than handing out a few bytes at a time,
+		// then grant whatever is there
+		need := float64(min(max, minGrant))
+		if need > burst {
+			need = burst
+		}
+		if need < 1 {
+			need = 1
+		}
+		if l.tokens >= need {
+			granted := min(max, int(l.tokens))
+			l.tokens -= float64(granted)
+			l.mu.Unlock()
+			return granted, nil
+		}
+		delay := time.Duration((need - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// refund returns reserved bytes that were not transferred
+func (l *bandwidthLimiter) refund(n int) {
+	if n <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate > 0 {
+		l.tokens += float64(n)
+	}
+}
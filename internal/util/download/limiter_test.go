@@ -0,0 +1,94 @@
+package download
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// transfer moves total bytes through l with the given number of readers, each reading up to
+// chunk bytes at a time but receiving only got of them, and returns how long it took
+func transfer(t *testing.T, l *bandwidthLimiter, total, readers, chunk, got int) time.Duration {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	left := total
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				granted, err := l.reserve(ctx, chunk)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				n := min(granted, got)
+				l.refund(granted - n)
+
+				mu.Lock()
+				left -= n
+				done := left <= 0
+				mu.Unlock()
+				if done {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return time.Since(start)
+}
+
+func TestLimiterRate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rate    int
+		total   int
+		readers int
+		chunk   int
+		got     int // Bytes a read returns out of what was granted
+	}{
+		{name: "single reader", rate: 400 * 1024, total: 200 * 1024, readers: 1, chunk: 32 * 1024, got: 32 * 1024},
+		{name: "chunk larger than burst", rate: 400 * 1024, total: 200 * 1024, readers: 1, chunk: 256 * 1024, got: 256 * 1024},
+		{name: "segments share the cap", rate: 400 * 1024, total: 200 * 1024, readers: 8, chunk: 256 * 1024, got: 256 * 1024},
+		// Short reads are charged for what they returned, not for the buffer
+		{name: "short reads", rate: 400 * 1024, total: 200 * 1024, readers: 4, chunk: 256 * 1024, got: 4 * 1024},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &bandwidthLimiter{rate: float64(tt.rate), lastFill: time.Now()}
+			elapsed := transfer(t, l, tt.total, tt.readers, tt.chunk, tt.got)
+
+			measured := float64(tt.total) / elapsed.Seconds()
+			if measured > float64(tt.rate)*1.1 {
+				t.Errorf("measured %.0f B/s, cap is %d B/s", measured, tt.rate)
+			}
+			if measured < float64(tt.rate)*0.6 {
+				t.Errorf("measured %.0f B/s, want close to the cap of %d B/s", measured, tt.rate)
+			}
+		})
+	}
+}
+
+func TestLimiterUnlimited(t *testing.T) {
+	l := &bandwidthLimiter{}
+	granted, err := l.reserve(context.Background(), 1<<20)
+	if err != nil || granted != 1<<20 {
+		t.Fatalf("granted %d, %v", granted, err)
+	}
+}
+
+func TestLimiterCancel(t *testing.T) {
+	l := &bandwidthLimiter{rate: 1, lastFill: time.Now()}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := l.reserve(ctx, 1024); err == nil {
+		t.Fatal("reserve ignored a cancelled context")
+	}
+}
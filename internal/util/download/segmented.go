@@ -0,0 +1,493 @@
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// errRangesIgnored is returned when a server answers a range request with the whole file
+var errRangesIgnored = errors.New("server ignored range request")
+
+const (
+	defaultConnections    = 4
+	minSegmentSize        = 16 * 1024 * 1024
+	segmentStateSaveEvery = 2 * time.Second
+)
+
+var (
+	connectionsMu  sync.Mutex
+	maxConnections = defaultConnections
+)
+
+// SetMaxConnections sets how many parallel connections a segmented download may use
+func SetMaxConnections(n int) {
+	if n < 1 {
+		n = 1
+	}
+	connectionsMu.Lock()
+	maxConnections = n
+	connectionsMu.Unlock()
+}
+
+// GetMaxConnections returns how many parallel connections a segmented download may use
+func GetMaxConnections() int {
+	connectionsMu.Lock()
+	defer connectionsMu.Unlock()
+	return maxConnections
+}
+
+// SegmentedOptions configures a segmented download
+type SegmentedOptions struct {
+	Stage        string            // Stage reported to the progress callback
+	Message      string            // Message reported to the progress callback
+	Headers      map[string]string // Extra request headers
+	ExpectedSize int64             // Known size of the file, 0 to ask the server
+	// ResumeKey identifies the file independently of where it is fetched from, so a download
+	// started on one mirror resumes on another; the URL is used when it is empty
+	ResumeKey string
+}
+
+// segment is a byte range of the file; Done counts bytes already written from Start
+type segment struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"` // Inclusive
+	Done  int64 `json:"done"`
+}
+
+func (s *segment) remaining() int64 {
+	return s.End - s.Start + 1 - s.Done
+}
+
+// segmentState is persisted next to the partial file so downloads resume across restarts
+type segmentState struct {
+	Key          string     `json:"key"`
+	URL          string     `json:"url"` // Where ETag and LastModified came from
+	Size         int64      `json:"size"`
+	ETag         string     `json:"etag,omitempty"`
+	LastModified string     `json:"lastModified,omitempty"`
+	Segments     []*segment `json:"segments"`
+}
+
+// resumes reports whether a saved state describes the same file as a new download.
+// Validators are only compared for the same URL, since every mirror has its own.
+func (s *segmentState) resumes(key, url string, size int64, etag, lastModified string) bool {
+	if s.Key != key || s.Size != size || size <= 0 {
+		return false
+	}
+	return s.URL != url || (s.ETag == etag && s.LastModified == lastModified)
+}
+
+// DownloadSegmented downloads url to dest by fetching byte ranges over several connections.
+// Data goes into dest.partial and progress into dest.segments.json, so an interrupted
+// download picks up where it left off, even after the launcher restarts. Servers without
+// range support are downloaded over a single connection.
+func DownloadSegmented(
+	ctx context.Context,
+	url string,
+	dest string,
+	opts SegmentedOptions,
+	callback func(stage string, progress float64, message string, currentFile string, speed string, downloaded, total int64),
+) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	partialPath := dest + ".partial"
+	statePath := dest + ".segments.json"
+
+	size, ranges, etag, lastModified, err := probeRemote(ctx, url, opts.Headers)
+	if err != nil {
+		return err
+	}
+	if size <= 0 {
+		size = opts.ExpectedSize
+	}
+
+	key := opts.ResumeKey
+	if key == "" {
+		key = url
+	}
+	state := loadSegmentState(statePath)
+	if state == nil || !state.resumes(key, url, size, etag, lastModified) {
+		// Start over: nothing we have matches the remote file, or its size is unknown
+		os.Remove(partialPath)
+		state = newSegmentState(key, url, size, ranges, etag, lastModified)
+	} else {
+		logger.Info("Resuming segmented download", "file", filepath.Base(dest))
+		// Validators from now on come from this URL
+		state.URL, state.ETag, state.LastModified = url, etag, lastModified
+	}
+
+	file, err := os.OpenFile(partialPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open partial file: %w", err)
+	}
+	defer file.Close()
+
+	if size > 0 {
+		if err := file.Truncate(size); err != nil {
+			return fmt.Errorf("failed to allocate %d bytes: %w", size, err)
+		}
+	}
+
+	d := &segmentedDownload{
+		ctx:       ctx,
+		url:       url,
+		headers:   opts.Headers,
+		file:      file,
+		size:      size,
+		state:     state,
+		statePath: statePath,
+	}
+
+	stopProgress := d.reportProgress(opts, filepath.Base(dest), callback)
+	err = d.run()
+	if errors.Is(err, errRangesIgnored) {
+		// The server advertised ranges but does not honour them; stream it in one piece
+		logger.Info("Server ignored range requests, downloading over a single connection", "file", filepath.Base(dest))
+		d.mu.Lock()
+		d.state = newSegmentState(key, url, size, false, etag, lastModified)
+		d.mu.Unlock()
+		err = d.run()
+	}
+	stopProgress()
+
+	if saveErr := d.saveState(); saveErr != nil {
+		logger.Warn("Failed to save download state", "error", saveErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		return err
+	}
+	file.Close()
+
+	if size > 0 {
+		info, err := os.Stat(partialPath)
+		if err != nil {
+			return fmt.Errorf("failed to verify downloaded file: %w", err)
+		}
+		if info.Size() != size {
+			return fmt.Errorf("downloaded file size mismatch: expected %d, got %d bytes", size, info.Size())
+		}
+	}
+
+	if err := os.Rename(partialPath, dest); err != nil {
+		return err
+	}
+	os.Remove(statePath)
+
+	if callback != nil {
+		callback(opts.Stage, 100, "Download complete", "", "", size, size)
+	}
+	return nil
+}
+
+// probeRemote asks the server for the file size and whether it accepts range requests
+func probeRemote(ctx context.Context, url string, headers map[string]string) (int64, bool, string, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", url, nil)
+	if err != nil {
+		return 0, false, "", "", err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := sharedClient.Do(req)
+	if err != nil {
+		return 0, false, "", "", fmt.Errorf("failed to reach %s: %w", url, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, false, "", "", fmt.Errorf("file not available: HTTP %d from %s", resp.StatusCode, url)
+	}
+
+	ranges := resp.Header.Get("Accept-Ranges") == "bytes" && resp.ContentLength > 0
+	return resp.ContentLength, ranges, resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"), nil
+}
+
+func newSegmentState(key, url string, size int64, ranges bool, etag, lastModified string) *segmentState {
+	state := &segmentState{Key: key, URL: url, Size: size, ETag: etag, LastModified: lastModified}
+
+	if !ranges || size <= 0 {
+		// One open-ended segment streamed from the start
+		state.Segments = []*segment{{Start: 0, End: size - 1}}
+		return state
+	}
+
+	count := GetMaxConnections()
+	if maxBySize := int(size / minSegmentSize); maxBySize < count {
+		count = maxBySize
+	}
+	if count < 1 {
+		count = 1
+	}
+
+	chunk := size / int64(count)
+	for i := 0; i < count; i++ {
+		start := int64(i) * chunk
+		end := start + chunk - 1
+		if i == count-1 {
+			end = size - 1
+		}
+		state.Segments = append(state.Segments, &segment{Start: start, End: end})
+	}
+	return state
+}
+
+func loadSegmentState(path string) *segmentState {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var state segmentState
+	if err := json.Unmarshal(data, &state); err != nil || len(state.Segments) == 0 {
+		return nil
+	}
+	return &state
+}
+
+// segmentedDownload is one run of a segmented download
+type segmentedDownload struct {
+	ctx       context.Context
+	url       string
+	headers   map[string]string
+	file      *os.File
+	size      int64
+	statePath string
+
+	mu        sync.Mutex
+	state     *segmentState
+	lastSaved time.Time
+}
+
+// run fetches every unfinished segment concurrently
+func (d *segmentedDownload) run() error {
+	ctx, cancel := context.WithCancel(d.ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+
+	d.mu.Lock()
+	var pending []*segment
+	for _, seg := range d.state.Segments {
+		if d.size <= 0 || seg.remaining() > 0 {
+			pending = append(pending, seg)
+		}
+	}
+	d.mu.Unlock()
+	errs := make(chan error, len(pending))
+
+	for _, seg := range pending {
+		wg.Add(1)
+		go func(seg *segment) {
+			defer wg.Done()
+			if err := d.fetch(ctx, seg); err != nil {
+				errs <- err
+				cancel()
+			}
+		}(seg)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	// Report the first real failure rather than the cancellations it caused
+	var firstErr error
+	for err := range errs {
+		if firstErr == nil || (errors.Is(firstErr, context.Canceled) && !errors.Is(err, context.Canceled)) {
+			firstErr = err
+		}
+	}
+	if firstErr != nil && d.ctx.Err() != nil {
+		return d.ctx.Err()
+	}
+	return firstErr
+}
+
+// fetch downloads the rest of one segment
+func (d *segmentedDownload) fetch(ctx context.Context, seg *segment) error {
+	d.mu.Lock()
+	offset := seg.Start + seg.Done
+	end := seg.End
+	d.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", d.url, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range d.headers {
+		req.Header.Set(k, v)
+	}
+
+	ranged := d.size > 0 && (offset > 0 || end < d.size-1)
+	if !ranged && offset > 0 {
+		// Without a range the body starts at byte 0 again
+		d.mu.Lock()
+		seg.Done = 0
+		d.mu.Unlock()
+		offset = 0
+	}
+	if ranged {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, end))
+	}
+
+	// No overall timeout here: large segments legitimately take a long time,
+	// and a stalled connection is caught by the transport's own timeouts
+	client := &http.Client{Transport: defaultTransport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download segment: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if ranged && resp.StatusCode != http.StatusPartialContent {
+		if resp.StatusCode == http.StatusOK {
+			return errRangesIgnored
+		}
+		return fmt.Errorf("range request failed: HTTP %d from %s", resp.StatusCode, d.url)
+	}
+	if !ranged && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("file not available: HTTP %d from %s", resp.StatusCode, d.url)
+	}
+
+	buf := make([]byte, 256*1024)
+	for {
+		granted, err := globalLimiter.reserve(ctx, len(buf))
+		if err != nil {
+			return err
+		}
+
+		n, readErr := resp.Body.Read(buf[:granted])
+		globalLimiter.refund(granted - n)
+		if n > 0 {
+			if _, err := d.file.WriteAt(buf[:n], offset); err != nil {
+				return err
+			}
+			offset += int64(n)
+
+			d.mu.Lock()
+			seg.Done += int64(n)
+			d.mu.Unlock()
+			d.maybeSaveState()
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("read error: %w", readErr)
+		}
+	}
+
+	d.mu.Lock()
+	missing := seg.remaining()
+	d.mu.Unlock()
+	if d.size > 0 && missing > 0 {
+		return fmt.Errorf("segment incomplete: %d bytes missing, will retry", missing)
+	}
+	return nil
+}
+
+// downloaded returns the number of bytes written so far
+func (d *segmentedDownload) downloaded() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var total int64
+	for _, seg := range d.state.Segments {
+		total += seg.Done
+	}
+	return total
+}
+
+// maybeSaveState persists progress every few seconds, syncing the data first
+// so the state file never claims bytes that are not on disk
+func (d *segmentedDownload) maybeSaveState() {
+	d.mu.Lock()
+	due := time.Since(d.lastSaved) >= segmentStateSaveEvery
+	if due {
+		d.lastSaved = time.Now()
+	}
+	d.mu.Unlock()
+
+	if due {
+		if err := d.saveState(); err != nil {
+			logger.Warn("Failed to save download state", "error", err)
+		}
+	}
+}
+
+func (d *segmentedDownload) saveState() error {
+	if err := d.file.Sync(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	data, err := json.Marshal(d.state)
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tmpPath := d.statePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, d.statePath)
+}
+
+// reportProgress sends aggregated progress every 100ms until the returned stop function is called
+func (d *segmentedDownload) reportProgress(
+	opts SegmentedOptions,
+	name string,
+	callback func(stage string, progress float64, message string, currentFile string, speed string, downloaded, total int64),
+) func() {
+	if callback == nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+
+		lastDownloaded := d.downloaded()
+		lastTime := time.Now()
+		// Smooth speed over about a second so the display does not jitter
+		speed := 0.0
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				downloaded := d.downloaded()
+				instant := float64(downloaded-lastDownloaded) / now.Sub(lastTime).Seconds()
+				speed = speed*0.9 + instant*0.1
+				lastDownloaded, lastTime = downloaded, now
+
+				progress := 0.0
+				if d.size > 0 {
+					progress = float64(downloaded) / float64(d.size) * 100
+				}
+				callback(opts.Stage, progress, opts.Message, name, formatSpeed(speed), downloaded, d.size)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
@@ -0,0 +1,81 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mirror serves content with its own ETag and records the ranges it was asked for
+func mirror(t *testing.T, content []byte, etag string) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			mu.Lock()
+			ranges = append(ranges, r.Header.Get("Range"))
+			mu.Unlock()
+		}
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "patch.pwr", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), ranges...)
+	}
+}
+
+func TestSegmentedResumesOnAnotherMirror(t *testing.T) {
+	content := []byte(strings.Repeat("patch data ", 1000))
+	half := int64(len(content) / 2)
+	first, _ := mirror(t, content, `"first"`)
+	second, requested := mirror(t, content, `"second"`)
+
+	tests := []struct {
+		name      string
+		key       string
+		wantRange string // Range of the only request to the second mirror
+	}{
+		{"same resume key continues the partial download", "/patch.pwr#11000", "bytes=5500-10999"},
+		{"without a resume key the download starts over", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "patch.pwr")
+			key := tt.key
+			if key == "" {
+				key = first.URL + "/patch.pwr"
+			}
+			// An interrupted download from the first mirror
+			state := newSegmentState(key, first.URL+"/patch.pwr", int64(len(content)), true, `"first"`, "")
+			state.Segments[0].Done = half
+			data, _ := json.Marshal(state)
+			os.WriteFile(dest+".segments.json", data, 0644)
+			os.WriteFile(dest+".partial", content[:half], 0644)
+
+			before := len(requested())
+			opts := SegmentedOptions{ExpectedSize: int64(len(content)), ResumeKey: tt.key}
+			if err := DownloadSegmented(context.Background(), second.URL+"/patch.pwr", dest, opts, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			if got, _ := os.ReadFile(dest); !bytes.Equal(got, content) {
+				t.Fatal("downloaded file does not match")
+			}
+			got := requested()[before:]
+			if len(got) != 1 || got[0] != tt.wantRange {
+				t.Errorf("second mirror was asked for %q, want [%q]", got, tt.wantRange)
+			}
+		})
+	}
+}
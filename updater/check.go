@@ -1,6 +1,7 @@
 package updater
 
 import (
+	"HyPrism/internal/logging"
 	"HyPrism/internal/util/download"
 	"context"
 	"encoding/json"
@@ -12,6 +13,9 @@ import (
 
 const versionJSONAsset = "version.json"
 
+// logger writes the updater subsystem's part of the launcher log
+var logger = logging.For(logging.Updater)
+
 // UpdateInfo represents the update information
 type UpdateInfo struct {
 	Version string `json:"version"`
@@ -54,10 +58,10 @@ func CheckUpdate(ctx context.Context, current string) (*Asset, string, error) {
 	currentClean := strings.TrimPrefix(strings.TrimSpace(current), "v")
 	latestClean := strings.TrimPrefix(strings.TrimSpace(info.Version), "v")
 
-	fmt.Printf("Current version: %s, Latest version: %s (nightly: %v)\n", current, info.Version, isNightly)
+	logger.Info("Checked for launcher update", "current", current, "latest", info.Version, "nightly", isNightly)
 
 	if currentClean == latestClean {
-		fmt.Println("Already on latest version")
+		logger.Debug("Already on latest version")
 		return nil, "", nil
 	}
 
@@ -65,22 +69,20 @@ func CheckUpdate(ctx context.Context, current string) (*Asset, string, error) {
 	switch runtime.GOOS {
 	case "windows":
 		asset = &info.Windows.Amd64.Launcher
-		fmt.Printf("Update available for Windows: %s -> %s\n", current, info.Version)
 	case "darwin":
 		if runtime.GOARCH == "arm64" {
 			asset = &info.Darwin.Arm64.Launcher
 		} else {
 			asset = &info.Darwin.Amd64.Launcher
 		}
-		fmt.Printf("Update available for macOS: %s -> %s\n", current, info.Version)
 	default:
 		asset = &info.Linux.Amd64.Launcher
-		fmt.Printf("Update available for Linux: %s -> %s\n", current, info.Version)
 	}
 
 	if asset.URL == "" {
 		return nil, "", fmt.Errorf("no download URL found for %s/%s", runtime.GOOS, runtime.GOARCH)
 	}
+	logger.Info("Launcher update available", "from", current, "to", info.Version, "os", runtime.GOOS)
 
 	return asset, info.Version, nil
 }
@@ -10,17 +10,17 @@ import (
 
 // DownloadUpdate downloads a launcher update
 func DownloadUpdate(ctx context.Context, url string, progress func(stage string, progress float64, message string, currentFile string, speed string, downloaded, total int64)) (string, error) {
-	fmt.Printf("Starting download from: %s\n", url)
+	logger.Info("Downloading launcher update", "url", url)
 
 	tmp := filepath.Join(os.TempDir(), "hyprism-update.tmp")
 
 	_ = os.Remove(tmp)
 
-	if err := download.DownloadWithProgress(tmp, url, "update", 1.0, progress); err != nil {
+	if err := download.DownloadWithProgress(ctx, tmp, url, "update", 1.0, progress); err != nil {
 		_ = os.Remove(tmp)
 		return "", fmt.Errorf("failed to download update: %w", err)
 	}
 
-	fmt.Printf("Download complete: %s\n", tmp)
+	logger.Info("Launcher update downloaded", "path", tmp)
 	return tmp, nil
 }